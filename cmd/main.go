@@ -1,65 +1,142 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
-	"time"
-
-	"github.com/joho/godotenv"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
 	"github.com/bercivarga/go-basic-server/internal/app"
+	"github.com/bercivarga/go-basic-server/internal/config"
 	"github.com/bercivarga/go-basic-server/internal/db/clients"
+	"github.com/bercivarga/go-basic-server/internal/db/migrate"
+	"github.com/bercivarga/go-basic-server/internal/db/querier"
+	"github.com/bercivarga/go-basic-server/internal/logger"
 	"github.com/bercivarga/go-basic-server/internal/middleware"
 	"github.com/bercivarga/go-basic-server/internal/router"
+	"github.com/bercivarga/go-basic-server/internal/server"
 	"github.com/bercivarga/go-basic-server/internal/wire"
 )
 
-const (
-	defaultDSN  = "localSQLite.db"
-	defaultPort = 8080
-)
-
 func main() {
-	err := godotenv.Load()
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run() (err error) {
+	cfg, opts, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
 	if err != nil {
-		log.Fatal("Error loading .env file")
+		return err
 	}
 
-	port := flag.Int("port", defaultPort, "Port to run the server on")
-	flag.Parse()
+	if opts.PrintConfig {
+		return cfg.Print(os.Stdout)
+	}
 
-	sqlite := clients.NewSQLite(defaultDSN)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	if _, err := sqlite.Connect(); err != nil {
-		log.Fatalf("DB connect: %v", err)
+	database, err := clients.New(cfg.Database)
+	if err != nil {
+		return err
 	}
-	defer func(sqlite *clients.SQLite) {
-		err := sqlite.Close()
-		if err != nil {
-			log.Fatalf("DB close: %v", err)
+
+	conn, err := database.Connect()
+	if err != nil {
+		return fmt.Errorf("DB connect: %w", err)
+	}
+	defer func() {
+		if closeErr := database.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("DB close: %w", closeErr)
+		}
+	}()
+
+	db, err := querier.New(conn, database.Writer(), database.Dialect())
+	if err != nil {
+		return err
+	}
+
+	if len(opts.Args) > 0 {
+		return runCommand(ctx, cfg, db, opts.Args)
+	}
+
+	if cfg.Database.AutoMigrate {
+		if err := autoMigrate(ctx, cfg, db); err != nil {
+			return err
 		}
-	}(sqlite)
+	}
 
-	app := app.NewApp(sqlite.DB)
+	app, err := app.NewApp(db, cfg)
+	if err != nil {
+		return err
+	}
 
 	router := router.New(app)
 
 	wire := wire.New(app)
 	wire.RegisterRoutes(router)
 
-	server := &http.Server{
-		Addr:         fmt.Sprintf(":%d", *port),
-		IdleTimeout:  time.Minute,
-		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 10 * time.Second,
-		Handler:      middleware.Logger(router),
+	srv := &http.Server{
+		Addr:         fmt.Sprintf(":%d", cfg.Server.Port),
+		IdleTimeout:  cfg.Server.IdleTimeout,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		Handler:      middleware.RequestID(middleware.Logger(middleware.Recover(app)(middleware.AuditClient(middleware.RateLimit(app.RateLimiter, middleware.ByIP)(router))))),
 	}
 
-	log.Printf("Starting server on port %d", *port)
-	serverStartErr := server.ListenAndServe()
-	if serverStartErr != nil {
-		log.Fatalf("Server listen: %v", serverStartErr)
+	app.Jobs.Start(ctx)
+
+	log.Printf("Starting server on port %d", cfg.Server.Port)
+	serveErr := server.Run(ctx, srv, cfg.Server.ShutdownTimeout)
+
+	// order matters: requests are drained by now, let running jobs finish,
+	// flush what both logged and the deferred close releases the database
+	// last
+	app.Jobs.Stop()
+	logger.Flush()
+
+	return serveErr
+}
+
+// runCommand handles `server [flags] <command> ...` invocations.
+func runCommand(ctx context.Context, cfg *config.Config, db *querier.DB, args []string) error {
+	switch args[0] {
+	case "migrate":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: migrate <%s>", strings.Join(migrate.Commands, "|"))
+		}
+		m, err := migrate.New(db.DB, db.Dialect(), cfg.Security.TokenPepper)
+		if err != nil {
+			return err
+		}
+		return m.Run(ctx, args[1], os.Stdout)
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+func autoMigrate(ctx context.Context, cfg *config.Config, db *querier.DB) error {
+	m, err := migrate.New(db.DB, db.Dialect(), cfg.Security.TokenPepper)
+	if err != nil {
+		return err
+	}
+	results, err := m.Up(ctx)
+	for _, r := range results {
+		slog.Info("migration applied", "source", r.Source.Path, "duration", r.Duration)
+	}
+	if err != nil {
+		return fmt.Errorf("auto-migrate: %w", err)
 	}
+	return nil
 }
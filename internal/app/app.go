@@ -1,34 +1,72 @@
 package app
 
 import (
-	"database/sql"
 	"log/slog"
+	"time"
 
+	"github.com/bercivarga/go-basic-server/internal/audit"
 	"github.com/bercivarga/go-basic-server/internal/config"
+	"github.com/bercivarga/go-basic-server/internal/db/querier"
+	"github.com/bercivarga/go-basic-server/internal/jobs"
 	"github.com/bercivarga/go-basic-server/internal/logger"
+	"github.com/bercivarga/go-basic-server/internal/mailer"
+	"github.com/bercivarga/go-basic-server/internal/ratelimit"
 	"github.com/bercivarga/go-basic-server/internal/services/auth"
 	"github.com/bercivarga/go-basic-server/internal/services/user"
+	auditStore "github.com/bercivarga/go-basic-server/internal/stores/audit"
+	ratelimitStore "github.com/bercivarga/go-basic-server/internal/stores/ratelimit"
 )
 
 type App struct {
-	DB          *sql.DB
+	DB          *querier.DB
 	Logger      *slog.Logger
 	Config      *config.Config
 	AuthService *auth.Service
 	UserService *user.Service
+	// Audit records security-relevant actions, see package audit.
+	Audit *audit.Recorder
+	// RateLimiter applies to every request, AuthRateLimiter to the
+	// endpoints open to credential guessing. Either is nil when disabled.
+	RateLimiter     *ratelimit.Limiter
+	AuthRateLimiter *ratelimit.Limiter
+	// Jobs runs in the background from Start until Stop, see cmd.
+	Jobs *jobs.Runner
 }
 
-func NewApp(db *sql.DB) *App {
+func NewApp(db *querier.DB, config *config.Config) (*App, error) {
 	logger := logger.New()
-	config := config.Load()
-	authService := auth.New(db, config)
-	userService := user.New(db)
+	mail, err := mailer.New(config.Mail)
+	if err != nil {
+		return nil, err
+	}
+	recorder := audit.NewRecorder(auditStore.NewStore(db))
+	authService, err := auth.New(db, config, mail, recorder)
+	if err != nil {
+		return nil, err
+	}
+	userService := user.New(db, config, recorder)
+
+	var store ratelimit.Store = ratelimit.NewMemoryStore()
+	if config.RateLimit.Store == "database" {
+		store = ratelimitStore.NewStore(db)
+	}
 
 	return &App{
-		DB:          db,
-		Logger:      logger,
-		Config:      config,
-		AuthService: authService,
-		UserService: userService,
+		DB:              db,
+		Logger:          logger,
+		Config:          config,
+		AuthService:     authService,
+		UserService:     userService,
+		Audit:           recorder,
+		RateLimiter:     newLimiter(store, "global", config.RateLimit.Requests, config.RateLimit.Window),
+		AuthRateLimiter: newLimiter(store, "auth", config.RateLimit.AuthRequests, config.RateLimit.AuthWindow),
+		Jobs:            jobs.NewRunner(jobs.Cleanup(db, config)...),
+	}, nil
+}
+
+func newLimiter(store ratelimit.Store, name string, requests int, window time.Duration) *ratelimit.Limiter {
+	if requests == 0 {
+		return nil
 	}
+	return ratelimit.New(store, name, requests, window)
 }
@@ -0,0 +1,38 @@
+// Package apperr defines the errors services hand to handlers. Each one
+// is an *Error of a kind, which decides the HTTP status, with a stable
+// code clients can match on and a message that is safe to show them.
+// Anything else reaching a handler is answered as an internal error
+// without its text, see utils.RespondError.
+package apperr
+
+import "errors"
+
+// Kinds of errors. Test for them with errors.Is.
+var (
+	ErrInvalid          = errors.New("invalid request")
+	ErrUnauthorized     = errors.New("unauthorized")
+	ErrForbidden        = errors.New("forbidden")
+	ErrNotFound         = errors.New("not found")
+	ErrMethodNotAllowed = errors.New("method not allowed")
+	ErrConflict         = errors.New("conflict")
+	ErrTooManyRequests  = errors.New("too many requests")
+	ErrUnavailable      = errors.New("service unavailable")
+)
+
+type Error struct {
+	Kind    error  // one of the kinds above
+	Code    string // machine-readable, e.g. "user_not_found"
+	Message string // shown to clients as is
+}
+
+func New(kind error, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
@@ -0,0 +1,150 @@
+// Package audit records security-relevant actions, who did them to whom
+// and from where, in the append-only audit_events table. Services call a
+// Recorder; admins read the log through GET /admin/audit.
+package audit
+
+import (
+	"context"
+	"encoding/base64"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/bercivarga/go-basic-server/internal/apperr"
+	auditStore "github.com/bercivarga/go-basic-server/internal/stores/audit"
+)
+
+// Actions recorded by the server.
+const (
+	ActionSignup           = "auth.signup"
+	ActionLogin            = "auth.login"
+	ActionLoginFailed      = "auth.login_failed"
+	ActionRefresh          = "auth.refresh"
+	ActionRefreshReused    = "auth.refresh_reused"
+	ActionLogout           = "auth.logout"
+	ActionLogoutAll        = "auth.logout_all"
+	ActionSessionRevoked   = "session.revoked"
+	ActionPasswordChanged  = "password.changed"
+	ActionPasswordReset    = "password.reset"
+	ActionEmailVerified    = "email.verified"
+	ActionTwoFactorEnabled = "2fa.enabled"
+	ActionTwoFactorReset   = "2fa.reset"
+	ActionLoginLocked      = "lockout.locked"
+	ActionLockoutCleared   = "lockout.cleared"
+	ActionPermissionDenied = "authz.denied"
+	ActionRoleAssigned     = "role.assigned"
+	ActionUserUpdated      = "user.updated"
+	ActionUserDeleted      = "user.deleted"
+	ActionUserImpersonated = "user.impersonated"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 500
+)
+
+var ErrInvalidCursor = apperr.New(apperr.ErrInvalid, "invalid_cursor", "invalid cursor")
+
+type (
+	Event  = auditStore.Event
+	Filter = auditStore.Filter
+)
+
+// Page is a slice of the log, newest first. NextCursor continues with the
+// events after it and is empty on the last page.
+type Page struct {
+	Events     []Event `json:"events"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+type Recorder struct {
+	store *auditStore.Store
+	now   func() time.Time
+}
+
+func NewRecorder(store *auditStore.Store) *Recorder {
+	return &Recorder{store: store, now: time.Now}
+}
+
+// Record appends e to the log. Time, ActorID, IP and UserAgent default to
+// what WithClient and WithActor put in ctx, and events of an impersonation
+// token carry the admin as impersonated_by. Failures are only logged: the
+// action itself already happened.
+func (r *Recorder) Record(ctx context.Context, e Event) {
+	if e.Time.IsZero() {
+		e.Time = r.now()
+	}
+	if c, ok := ctx.Value(clientKey).(client); ok {
+		if e.IP == "" {
+			e.IP = c.ip
+		}
+		if e.UserAgent == "" {
+			e.UserAgent = c.userAgent
+		}
+	}
+	if a, ok := ctx.Value(actorKey).(actor); ok {
+		if e.ActorID == 0 {
+			e.ActorID = a.userID
+		}
+		if a.impersonatorID != 0 {
+			metadata := map[string]any{"impersonated_by": a.impersonatorID}
+			for k, v := range e.Metadata {
+				metadata[k] = v
+			}
+			e.Metadata = metadata
+		}
+	}
+
+	// the request may be gone by now, the record should not
+	if err := r.store.Append(context.WithoutCancel(ctx), e); err != nil {
+		slog.ErrorContext(ctx, "recording audit event failed", "action", e.Action, "error", err)
+	}
+}
+
+// List returns a page of the events matching f, starting after cursor
+// (empty for the first page). f.BeforeID is set from the cursor.
+func (r *Recorder) List(ctx context.Context, f Filter, cursor string) (*Page, error) {
+	if cursor != "" {
+		id, err := decodeCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		f.BeforeID = id
+	}
+	if f.Limit <= 0 {
+		f.Limit = DefaultLimit
+	}
+	f.Limit = min(f.Limit, MaxLimit)
+
+	// one more than asked tells whether there is a next page
+	limit := f.Limit
+	f.Limit++
+	events, err := r.store.List(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &Page{Events: events}
+	if int64(len(events)) > limit {
+		page.Events = events[:limit]
+		page.NextCursor = encodeCursor(page.Events[limit-1].ID)
+	}
+	return page, nil
+}
+
+// Cursors are opaque to clients; they hold the ID of the last event seen.
+func encodeCursor(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+}
+
+func decodeCursor(cursor string) (int64, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+	id, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, ErrInvalidCursor
+	}
+	return id, nil
+}
@@ -0,0 +1,138 @@
+package audit
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bercivarga/go-basic-server/internal/db/dbtest"
+	auditStore "github.com/bercivarga/go-basic-server/internal/stores/audit"
+	"github.com/bercivarga/go-basic-server/internal/utils"
+)
+
+// newRecorder returns a recorder on a fresh database holding n events,
+// alternating between ActionLogin and ActionLogout.
+func newRecorder(t *testing.T, n int) *Recorder {
+	t.Helper()
+	r := NewRecorder(auditStore.NewStore(dbtest.OpenMemory(t)))
+	for i := range n {
+		action := ActionLogin
+		if i%2 == 1 {
+			action = ActionLogout
+		}
+		r.Record(context.Background(), Event{Action: action})
+	}
+	return r
+}
+
+// listAll follows the cursors from the first page to the last and
+// returns the size of every page and all events seen.
+func listAll(t *testing.T, r *Recorder, f Filter) (sizes []int, events []Event) {
+	t.Helper()
+	cursor := ""
+	for {
+		page, err := r.List(context.Background(), f, cursor)
+		if err != nil {
+			t.Fatalf("List(%q): %v", cursor, err)
+		}
+		sizes = append(sizes, len(page.Events))
+		events = append(events, page.Events...)
+		if page.NextCursor == "" {
+			return sizes, events
+		}
+		if len(sizes) > 100 {
+			t.Fatal("cursor never ends")
+		}
+		cursor = page.NextCursor
+	}
+}
+
+func TestListPages(t *testing.T) {
+	tests := []struct {
+		name   string
+		events int
+		filter Filter
+		sizes  []int
+	}{
+		{"empty log", 0, Filter{Limit: 3}, []int{0}},
+		{"short last page", 7, Filter{Limit: 3}, []int{3, 3, 1}},
+		{"full last page", 6, Filter{Limit: 3}, []int{3, 3}},
+		{"single page", 3, Filter{Limit: 3}, []int{3}},
+		{"filtered", 7, Filter{Action: ActionLogin, Limit: 2}, []int{2, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRecorder(t, tt.events)
+			sizes, events := listAll(t, r, tt.filter)
+
+			if len(sizes) != len(tt.sizes) {
+				t.Fatalf("page sizes = %v, want %v", sizes, tt.sizes)
+			}
+			for i := range sizes {
+				if sizes[i] != tt.sizes[i] {
+					t.Fatalf("page sizes = %v, want %v", sizes, tt.sizes)
+				}
+			}
+
+			// newest first, so IDs strictly decrease and none repeats
+			for i, e := range events {
+				if i > 0 && e.ID >= events[i-1].ID {
+					t.Errorf("event %d has ID %d after %d", i, e.ID, events[i-1].ID)
+				}
+				if tt.filter.Action != "" && e.Action != tt.filter.Action {
+					t.Errorf("event %d is %s, want only %s", e.ID, e.Action, tt.filter.Action)
+				}
+			}
+		})
+	}
+}
+
+func TestListLimits(t *testing.T) {
+	r := newRecorder(t, MaxLimit+1)
+
+	tests := []struct {
+		name  string
+		limit int64
+		want  int
+	}{
+		{"default", 0, DefaultLimit},
+		{"negative", -1, DefaultLimit},
+		{"within bounds", 10, 10},
+		{"at the maximum", MaxLimit, MaxLimit},
+		{"above the maximum", MaxLimit + 100, MaxLimit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := r.List(context.Background(), Filter{Limit: tt.limit}, "")
+			if err != nil {
+				t.Fatalf("List: %v", err)
+			}
+			if len(page.Events) != tt.want || page.NextCursor == "" {
+				t.Errorf("got %d events, next cursor %q, want %d and a cursor", len(page.Events), page.NextCursor, tt.want)
+			}
+		})
+	}
+}
+
+func TestListInvalidCursor(t *testing.T) {
+	r := newRecorder(t, 1)
+	b64 := base64.RawURLEncoding.EncodeToString
+
+	for _, cursor := range []string{"!!!", b64([]byte("abc")), b64([]byte("0")), b64([]byte("-5")), b64([]byte("1.5"))} {
+		t.Run(cursor, func(t *testing.T) {
+			_, err := r.List(context.Background(), Filter{}, cursor)
+			if err == nil {
+				t.Fatal("List succeeded")
+			}
+			req := httptest.NewRequest(http.MethodGet, "/admin/audit", nil)
+			p := utils.WriteProblem(httptest.NewRecorder(), req, err)
+			if p.Status != http.StatusBadRequest || p.Code != "invalid_cursor" {
+				t.Errorf("got %d %s, want 400 invalid_cursor", p.Status, p.Code)
+			}
+		})
+	}
+}
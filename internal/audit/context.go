@@ -0,0 +1,33 @@
+package audit
+
+import "context"
+
+type contextKey string
+
+const (
+	clientKey contextKey = "audit_client"
+	actorKey  contextKey = "audit_actor"
+)
+
+type client struct {
+	ip        string
+	userAgent string
+}
+
+type actor struct {
+	userID         int64
+	impersonatorID int64
+}
+
+// WithClient returns ctx carrying where the request came from, the
+// default IP and user agent of events recorded with it.
+func WithClient(ctx context.Context, ip, userAgent string) context.Context {
+	return context.WithValue(ctx, clientKey, client{ip: ip, userAgent: userAgent})
+}
+
+// WithActor returns ctx carrying the authenticated user, the default
+// actor of events recorded with it. impersonatorID is the admin behind an
+// impersonation token, or 0.
+func WithActor(ctx context.Context, userID, impersonatorID int64) context.Context {
+	return context.WithValue(ctx, actorKey, actor{userID: userID, impersonatorID: impersonatorID})
+}
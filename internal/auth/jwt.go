@@ -1,58 +1,209 @@
 package auth
 
 import (
+	"crypto/rand"
 	"errors"
+	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-const (
-	JWT_DURATION         = 24 * time.Hour * 7  // 7 days
-	JWT_REFRESH_DURATION = 24 * time.Hour * 14 // 14 days
+// Verify wraps every failure in one of these, so callers can tell the
+// client why its token was refused without leaking details.
+var (
+	ErrTokenExpired   = errors.New("token expired")
+	ErrTokenMalformed = errors.New("malformed token")
+	ErrTokenSignature = errors.New("invalid token signature")
+	ErrTokenClaims    = errors.New("invalid token claims")
 )
 
 type JWTManager struct {
-	secretKey       string
+	keys            *KeySet
+	issuer          string
+	audience        string // empty disables the aud claim
+	leeway          time.Duration
 	TokenDuration   time.Duration
 	RefreshDuration time.Duration
 }
 
+// purposeMFA marks the short-lived token that sits between the password
+// and the second factor during login.
+const purposeMFA = "mfa"
+
+// UserClaims are the claims of an access token. Role is informational,
+// authorization still checks the database. SessionID identifies the login
+// (token family) the token belongs to and survives refreshes. Purpose is
+// only set on tokens that are not access tokens. Actor is set when an
+// admin acts as the user.
 type UserClaims struct {
-	UserID int64 `json:"user_id"`
+	UserID    int64  `json:"user_id"`
+	Role      string `json:"role,omitempty"`
+	SessionID string `json:"sid,omitempty"`
+	Purpose   string `json:"purpose,omitempty"`
+	Actor     *Actor `json:"act,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func NewJWTManager(secretKey string) *JWTManager {
-	return &JWTManager{secretKey, JWT_DURATION, JWT_REFRESH_DURATION}
+// Actor is the RFC 8693 act claim: who is really behind a token issued
+// for someone else.
+type Actor struct {
+	Subject string `json:"sub"`
 }
 
-func (j *JWTManager) Generate(userID int64) (string, error) {
+// ActorID returns the user acting through the token, if it is an
+// impersonation token.
+func (c *UserClaims) ActorID() (int64, bool) {
+	if c.Actor == nil {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(c.Actor.Subject, 10, 64)
+	return id, err == nil
+}
+
+func NewJWTManager(keys *KeySet, issuer, audience string, leeway, tokenDuration, refreshDuration time.Duration) *JWTManager {
+	return &JWTManager{keys, issuer, audience, leeway, tokenDuration, refreshDuration}
+}
+
+// Keys exposes the key set, e.g. to publish it as JWKS.
+func (j *JWTManager) Keys() *KeySet {
+	return j.keys
+}
+
+func (j *JWTManager) Generate(userID int64, role, sessionID string) (string, error) {
+	claims := j.claims(userID, j.TokenDuration)
+	claims.Role = role
+	claims.SessionID = sessionID
+	return j.sign(claims)
+}
+
+// GenerateImpersonation issues an access token for userID that is marked
+// with actorID as its act claim and lives for ttl.
+func (j *JWTManager) GenerateImpersonation(userID int64, role, sessionID string, actorID int64, ttl time.Duration) (string, error) {
+	claims := j.claims(userID, ttl)
+	claims.Role = role
+	claims.SessionID = sessionID
+	claims.Actor = &Actor{Subject: strconv.FormatInt(actorID, 10)}
+	return j.sign(claims)
+}
+
+// GenerateMFAChallenge issues the token a client trades, together with a
+// second factor, for a session. It is never accepted as an access token.
+func (j *JWTManager) GenerateMFAChallenge(userID int64, ttl time.Duration) (string, error) {
+	claims := j.claims(userID, ttl)
+	claims.Purpose = purposeMFA
+	return j.sign(claims)
+}
+
+func (j *JWTManager) claims(userID int64, ttl time.Duration) *UserClaims {
+	now := time.Now()
 	claims := &UserClaims{
 		UserID: userID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(j.TokenDuration)),
+			Issuer:    j.issuer,
+			Subject:   strconv.FormatInt(userID, 10),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
+			// also keeps two tokens issued in the same second apart
+			ID: rand.Text(),
 		},
 	}
+	if j.audience != "" {
+		claims.Audience = jwt.ClaimStrings{j.audience}
+	}
+	return claims
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(j.secretKey))
+func (j *JWTManager) sign(claims *UserClaims) (string, error) {
+	key := j.keys.Signing()
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), claims)
+	if key.ID != "" {
+		token.Header["kid"] = key.ID
+	}
+	return token.SignedString(key.private)
 }
 
+// Verify checks an access token.
 func (j *JWTManager) Verify(tokenStr string) (*UserClaims, error) {
+	claims, err := j.parse(tokenStr)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != "" {
+		return nil, ErrTokenClaims
+	}
+	if _, ok := claims.ActorID(); claims.Actor != nil && !ok {
+		return nil, ErrTokenClaims
+	}
+	return claims, nil
+}
+
+// VerifyMFAChallenge checks a token from GenerateMFAChallenge and returns
+// the user it was issued for.
+func (j *JWTManager) VerifyMFAChallenge(tokenStr string) (int64, error) {
+	claims, err := j.parse(tokenStr)
+	if err != nil {
+		return 0, err
+	}
+	if claims.Purpose != purposeMFA {
+		return 0, ErrTokenClaims
+	}
+	return claims.UserID, nil
+}
+
+func (j *JWTManager) parse(tokenStr string) (*UserClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenStr, &UserClaims{}, func(token *jwt.Token) (any, error) {
-		return []byte(j.secretKey), nil
-	})
-	if err != nil || !token.Valid {
-		return nil, errors.New("invalid token")
+		kid, _ := token.Header["kid"].(string)
+		key, ok := j.keys.Lookup(kid)
+		if !ok {
+			return nil, errors.New("unknown signing key")
+		}
+		// the algorithm is bound to the key, never taken from the token
+		if token.Method.Alg() != key.Algorithm {
+			return nil, errors.New("unexpected signing method")
+		}
+		return key.verifyKey(), nil
+	}, j.parserOptions()...)
+	if err != nil {
+		return nil, classify(err)
 	}
 	claims, ok := token.Claims.(*UserClaims)
-	if !ok {
-		return nil, errors.New("invalid claims")
+	if !ok || !token.Valid || claims.UserID == 0 || claims.Subject != strconv.FormatInt(claims.UserID, 10) {
+		return nil, ErrTokenClaims
 	}
 	return claims, nil
 }
 
+func (j *JWTManager) parserOptions() []jwt.ParserOption {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(j.keys.Algorithms()),
+		jwt.WithLeeway(j.leeway),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithIssuer(j.issuer),
+	}
+	if j.audience != "" {
+		opts = append(opts, jwt.WithAudience(j.audience))
+	}
+	return opts
+}
+
+// classify maps golang-jwt errors onto our sentinels. Expiry wins over
+// the other claim errors so clients know a refresh will help.
+func classify(err error) error {
+	switch {
+	case errors.Is(err, jwt.ErrTokenMalformed):
+		return ErrTokenMalformed
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid), errors.Is(err, jwt.ErrTokenUnverifiable):
+		return ErrTokenSignature
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return ErrTokenExpired
+	default:
+		return ErrTokenClaims
+	}
+}
+
 func (j *JWTManager) CreateExpiry() (accessTokenExpireAt, refreshTokenExpireAt time.Time) {
 	accessTokenExpireAt = time.Now().Add(j.TokenDuration)
 	refreshTokenExpireAt = time.Now().Add(j.RefreshDuration)
@@ -0,0 +1,172 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// rsaKey returns a fresh RS256 signing key.
+func rsaKey(t *testing.T) *Key {
+	t.Helper()
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating RSA key: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatalf("marshalling RSA key: %v", err)
+	}
+	k, err := ParsePrivateKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	if err != nil {
+		t.Fatalf("parsing RSA key: %v", err)
+	}
+	return k
+}
+
+// newManager returns a manager signing with an RSA key and still
+// accepting a shared secret, the way a deployment mid-rotation does.
+func newManager(t *testing.T) *JWTManager {
+	t.Helper()
+	ks, err := NewKeySet(rsaKey(t), []*Key{NewHMACKey("secret")}, time.Hour)
+	if err != nil {
+		t.Fatalf("creating key set: %v", err)
+	}
+	return NewJWTManager(ks, "issuer", "audience", 30*time.Second, time.Minute, time.Hour)
+}
+
+// signWith signs claims with the manager's signing key after edit has
+// had a chance to tamper with them.
+func signWith(t *testing.T, j *JWTManager, edit func(*UserClaims)) string {
+	t.Helper()
+	claims := j.claims(1, time.Minute)
+	edit(claims)
+	token, err := j.sign(claims)
+	if err != nil {
+		t.Fatalf("signing: %v", err)
+	}
+	return token
+}
+
+func TestVerify(t *testing.T) {
+	j := newManager(t)
+
+	token, err := j.Generate(1, "user", "session")
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	claims, err := j.Verify(token)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if claims.UserID != 1 || claims.Role != "user" || claims.SessionID != "session" {
+		t.Errorf("claims = %+v, want user 1, role user, session session", claims)
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	j := newManager(t)
+	signing := j.Keys().Signing()
+
+	// HS256 keyed with the RSA public key, which anyone can fetch from
+	// the JWKS; an alg taken from the token would accept this
+	pub, err := x509.MarshalPKIXPublicKey(signing.public)
+	if err != nil {
+		t.Fatalf("marshalling public key: %v", err)
+	}
+	confused := jwt.NewWithClaims(jwt.SigningMethodHS256, j.claims(1, time.Minute))
+	confused.Header["kid"] = signing.ID
+	algMismatch, err := confused.SignedString(pub)
+	if err != nil {
+		t.Fatalf("signing HS256: %v", err)
+	}
+
+	unknown := jwt.NewWithClaims(jwt.SigningMethodRS256, j.claims(1, time.Minute))
+	unknown.Header["kid"] = "unknown"
+	unknownKid, err := unknown.SignedString(signing.private)
+	if err != nil {
+		t.Fatalf("signing RS256: %v", err)
+	}
+
+	mfa, err := j.GenerateMFAChallenge(1, time.Minute)
+	if err != nil {
+		t.Fatalf("GenerateMFAChallenge: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"alg differs from the key's", algMismatch, ErrTokenSignature},
+		{"unknown kid", unknownKid, ErrTokenSignature},
+		{"expired beyond leeway", signWith(t, j, func(c *UserClaims) {
+			c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+		}), ErrTokenExpired},
+		{"wrong issuer", signWith(t, j, func(c *UserClaims) {
+			c.Issuer = "someone-else"
+		}), ErrTokenClaims},
+		{"wrong audience", signWith(t, j, func(c *UserClaims) {
+			c.Audience = jwt.ClaimStrings{"someone-else"}
+		}), ErrTokenClaims},
+		{"subject differs from user_id", signWith(t, j, func(c *UserClaims) {
+			c.Subject = "2"
+		}), ErrTokenClaims},
+		{"MFA challenge", mfa, ErrTokenClaims},
+		{"non-numeric act", signWith(t, j, func(c *UserClaims) {
+			c.Actor = &Actor{Subject: "admin"}
+		}), ErrTokenClaims},
+		{"garbage", "not.a.token", ErrTokenMalformed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := j.Verify(tt.token)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Verify = %+v, %v, want %v", claims, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyAcceptsWithinLeeway(t *testing.T) {
+	j := newManager(t)
+	token := signWith(t, j, func(c *UserClaims) {
+		c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-10 * time.Second))
+	})
+	if _, err := j.Verify(token); err != nil {
+		t.Errorf("Verify of a token expired 10s ago with 30s leeway: %v", err)
+	}
+}
+
+func TestVerifyImpersonation(t *testing.T) {
+	j := newManager(t)
+	token, err := j.GenerateImpersonation(1, "user", "session", 7, time.Minute)
+	if err != nil {
+		t.Fatalf("GenerateImpersonation: %v", err)
+	}
+	claims, err := j.Verify(token)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if actor, ok := claims.ActorID(); !ok || actor != 7 {
+		t.Errorf("ActorID = %d, %v, want 7, true", actor, ok)
+	}
+}
+
+func TestVerifyMFAChallengeRejectsAccessToken(t *testing.T) {
+	j := newManager(t)
+	token, err := j.Generate(1, "user", "session")
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if _, err := j.VerifyMFAChallenge(token); !errors.Is(err, ErrTokenClaims) {
+		t.Errorf("VerifyMFAChallenge = %v, want %v", err, ErrTokenClaims)
+	}
+}
@@ -0,0 +1,320 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Key is one JWT signing or verification key. The algorithm follows from
+// the key type: RSA → RS256, ECDSA P-256 → ES256, Ed25519 → EdDSA and a
+// shared secret → HS256.
+type Key struct {
+	ID        string // kid header, the RFC 7638 thumbprint for asymmetric keys
+	Algorithm string
+
+	private  any              // []byte for HMAC, nil for verification-only keys
+	public   crypto.PublicKey // nil for HMAC
+	notAfter time.Time        // zero means the key never retires
+}
+
+// NewHMACKey wraps a shared secret. It has no kid, so it only matches
+// tokens without one, and a key set holds at most one secret: moving from
+// one secret to another logs everyone out, rotate to an asymmetric key
+// instead.
+func NewHMACKey(secret string) *Key {
+	return &Key{Algorithm: jwt.SigningMethodHS256.Alg(), private: []byte(secret)}
+}
+
+// ParsePrivateKeyPEM reads the first private key in data (PKCS#8, PKCS#1
+// or SEC 1).
+func ParsePrivateKeyPEM(data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	priv, err := parsePrivateKey(block)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := priv.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", priv)
+	}
+	key, err := newKey(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	key.private = priv
+	return key, nil
+}
+
+// ParsePublicKeysPEM reads every key in a PEM bundle. Private keys are
+// accepted too, only their public half is kept.
+func ParsePublicKeysPEM(data []byte) ([]*Key, error) {
+	var keys []*Key
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+
+		var pub crypto.PublicKey
+		if block.Type == "PUBLIC KEY" {
+			var err error
+			if pub, err = x509.ParsePKIXPublicKey(block.Bytes); err != nil {
+				return nil, err
+			}
+		} else {
+			priv, err := parsePrivateKey(block)
+			if err != nil {
+				return nil, err
+			}
+			signer, ok := priv.(crypto.Signer)
+			if !ok {
+				return nil, fmt.Errorf("unsupported private key type %T", priv)
+			}
+			pub = signer.Public()
+		}
+
+		key, err := newKey(pub)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no PEM block found")
+	}
+	return keys, nil
+}
+
+func parsePrivateKey(block *pem.Block) (any, error) {
+	switch block.Type {
+	case "PRIVATE KEY":
+		return x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+}
+
+func newKey(pub crypto.PublicKey) (*Key, error) {
+	key := &Key{public: pub}
+	switch p := pub.(type) {
+	case *rsa.PublicKey:
+		if p.N.BitLen() < 2048 {
+			return nil, fmt.Errorf("RSA keys must be at least 2048 bits, got %d", p.N.BitLen())
+		}
+		key.Algorithm = jwt.SigningMethodRS256.Alg()
+	case *ecdsa.PublicKey:
+		if p.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("ECDSA keys must use P-256, got %s", p.Curve.Params().Name)
+		}
+		key.Algorithm = jwt.SigningMethodES256.Alg()
+	case ed25519.PublicKey:
+		key.Algorithm = jwt.SigningMethodEdDSA.Alg()
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", pub)
+	}
+
+	jwk, err := key.JWK()
+	if err != nil {
+		return nil, err
+	}
+	key.ID = jwk.thumbprint()
+	return key, nil
+}
+
+// verifyKey is what golang-jwt expects when checking a signature.
+func (k *Key) verifyKey() any {
+	if k.public == nil {
+		return k.private
+	}
+	return k.public
+}
+
+/* ---------- key set ---------------------------------------------------- */
+
+// KeySet holds the key new tokens are signed with plus older keys that
+// still verify until their grace period runs out.
+type KeySet struct {
+	signing *Key
+	keys    map[string]*Key
+	now     func() time.Time
+}
+
+// NewKeySet signs with signing and keeps accepting tokens from previous
+// for grace, counted from now. A rotation is therefore: deploy the new
+// key as signing and the old one in previous, and drop it from previous
+// once grace has passed.
+func NewKeySet(signing *Key, previous []*Key, grace time.Duration) (*KeySet, error) {
+	ks := &KeySet{signing: signing, keys: map[string]*Key{signing.ID: signing}, now: time.Now}
+	retireAt := ks.now().Add(grace)
+	for _, k := range previous {
+		if _, dup := ks.keys[k.ID]; dup {
+			if k.ID == "" {
+				return nil, errors.New("a key set holds only one shared secret")
+			}
+			return nil, fmt.Errorf("duplicate key id %q", k.ID)
+		}
+		retired := *k
+		retired.private = retiredPrivate(k)
+		retired.notAfter = retireAt
+		ks.keys[k.ID] = &retired
+	}
+	return ks, nil
+}
+
+// retiredPrivate drops private keys of retired keys so they can never
+// sign again; HMAC secrets are kept since they are needed to verify.
+func retiredPrivate(k *Key) any {
+	if k.public == nil {
+		return k.private
+	}
+	return nil
+}
+
+// Signing returns the key used for new tokens.
+func (ks *KeySet) Signing() *Key {
+	return ks.signing
+}
+
+// Lookup finds an active key by kid.
+func (ks *KeySet) Lookup(kid string) (*Key, bool) {
+	k, ok := ks.keys[kid]
+	if !ok || (!k.notAfter.IsZero() && ks.now().After(k.notAfter)) {
+		return nil, false
+	}
+	return k, true
+}
+
+// Algorithms lists the algorithms of all keys, in no particular order.
+func (ks *KeySet) Algorithms() []string {
+	seen := map[string]bool{}
+	var algs []string
+	for _, k := range ks.keys {
+		if !seen[k.Algorithm] {
+			seen[k.Algorithm] = true
+			algs = append(algs, k.Algorithm)
+		}
+	}
+	return algs
+}
+
+// JWKS returns the public halves of all active asymmetric keys, the
+// signing key first. Shared secrets are never published.
+func (ks *KeySet) JWKS() (JWKS, error) {
+	set := JWKS{Keys: []JWK{}}
+	add := func(k *Key) error {
+		if k.public == nil {
+			return nil
+		}
+		if _, ok := ks.Lookup(k.ID); !ok {
+			return nil
+		}
+		jwk, err := k.JWK()
+		if err != nil {
+			return err
+		}
+		set.Keys = append(set.Keys, jwk)
+		return nil
+	}
+
+	if err := add(ks.signing); err != nil {
+		return set, err
+	}
+	for _, k := range ks.keys {
+		if k == ks.signing {
+			continue
+		}
+		if err := add(k); err != nil {
+			return set, err
+		}
+	}
+	return set, nil
+}
+
+/* ---------- JWK -------------------------------------------------------- */
+
+// JWKS is a JSON Web Key Set (RFC 7517).
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK is the public half of a Key in JSON Web Key form.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWK encodes the public key of k.
+func (k *Key) JWK() (JWK, error) {
+	b64 := base64.RawURLEncoding.EncodeToString
+	jwk := JWK{Kid: k.ID, Use: "sig", Alg: k.Algorithm}
+
+	switch p := k.public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = b64(p.N.Bytes())
+		jwk.E = b64(big.NewInt(int64(p.E)).Bytes())
+	case *ecdsa.PublicKey:
+		ecdhKey, err := p.ECDH()
+		if err != nil {
+			return jwk, err
+		}
+		// uncompressed point: 0x04 || X || Y
+		point := ecdhKey.Bytes()
+		size := (len(point) - 1) / 2
+		jwk.Kty = "EC"
+		jwk.Crv = p.Curve.Params().Name
+		jwk.X = b64(point[1 : 1+size])
+		jwk.Y = b64(point[1+size:])
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = b64(p)
+	default:
+		return jwk, fmt.Errorf("unsupported public key type %T", k.public)
+	}
+	return jwk, nil
+}
+
+// thumbprint is the RFC 7638 thumbprint: the SHA-256 of the required
+// members in lexicographic order, without whitespace.
+func (j JWK) thumbprint() string {
+	var canonical string
+	switch j.Kty {
+	case "RSA":
+		canonical = fmt.Sprintf(`{"e":%q,"kty":"RSA","n":%q}`, j.E, j.N)
+	case "EC":
+		canonical = fmt.Sprintf(`{"crv":%q,"kty":"EC","x":%q,"y":%q}`, j.Crv, j.X, j.Y)
+	case "OKP":
+		canonical = fmt.Sprintf(`{"crv":%q,"kty":"OKP","x":%q}`, j.Crv, j.X)
+	}
+	sum := sha256.Sum256([]byte(canonical))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
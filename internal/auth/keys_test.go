@@ -0,0 +1,183 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ed25519Key returns a fresh EdDSA signing key.
+func ed25519Key(t *testing.T) *Key {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generating Ed25519 key: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatalf("marshalling Ed25519 key: %v", err)
+	}
+	k, err := ParsePrivateKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	if err != nil {
+		t.Fatalf("parsing Ed25519 key: %v", err)
+	}
+	return k
+}
+
+func TestThumbprintRFC7638(t *testing.T) {
+	// the example key of RFC 7638, section 3.1
+	const (
+		n    = "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw"
+		want = "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs"
+	)
+	modulus, err := base64.RawURLEncoding.DecodeString(n)
+	if err != nil {
+		t.Fatalf("decoding n: %v", err)
+	}
+
+	k, err := newKey(&rsa.PublicKey{N: new(big.Int).SetBytes(modulus), E: 65537})
+	if err != nil {
+		t.Fatalf("newKey: %v", err)
+	}
+	if k.ID != want {
+		t.Errorf("kid = %s, want %s", k.ID, want)
+	}
+}
+
+func TestKeySetRetiresPreviousKeys(t *testing.T) {
+	signing, previous := ed25519Key(t), rsaKey(t)
+	ks, err := NewKeySet(signing, []*Key{previous}, time.Hour)
+	if err != nil {
+		t.Fatalf("NewKeySet: %v", err)
+	}
+	start := time.Now()
+
+	tests := []struct {
+		name   string
+		at     time.Time
+		active bool
+	}{
+		{"within grace", start.Add(59 * time.Minute), true},
+		{"after grace", start.Add(61 * time.Minute), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ks.now = func() time.Time { return tt.at }
+
+			if _, ok := ks.Lookup(previous.ID); ok != tt.active {
+				t.Errorf("Lookup(previous) = %v, want %v", ok, tt.active)
+			}
+			if _, ok := ks.Lookup(signing.ID); !ok {
+				t.Error("Lookup(signing) = false, want true")
+			}
+
+			set, err := ks.JWKS()
+			if err != nil {
+				t.Fatalf("JWKS: %v", err)
+			}
+			want := []string{signing.ID}
+			if tt.active {
+				want = append(want, previous.ID)
+			}
+			var got []string
+			for _, k := range set.Keys {
+				got = append(got, k.Kid)
+			}
+			if strings.Join(got, ",") != strings.Join(want, ",") {
+				t.Errorf("JWKS kids = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestKeySetRetiredKeysNeverSign(t *testing.T) {
+	previous := rsaKey(t)
+	ks, err := NewKeySet(ed25519Key(t), []*Key{previous}, time.Hour)
+	if err != nil {
+		t.Fatalf("NewKeySet: %v", err)
+	}
+
+	retired, ok := ks.Lookup(previous.ID)
+	if !ok {
+		t.Fatal("previous key not found")
+	}
+	if retired.private != nil {
+		t.Error("retired key kept its private key")
+	}
+	if previous.private == nil {
+		t.Error("NewKeySet dropped the private key of the caller's Key")
+	}
+	if ks.Signing().ID == previous.ID {
+		t.Error("signing with the retired key")
+	}
+}
+
+func TestJWKSOmitsSecrets(t *testing.T) {
+	const secret = "shared-secret"
+
+	tests := []struct {
+		name     string
+		signing  *Key
+		previous []*Key
+		keys     int
+	}{
+		{"secret only", NewHMACKey(secret), nil, 0},
+		{"secret retired", rsaKey(t), []*Key{NewHMACKey(secret)}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ks, err := NewKeySet(tt.signing, tt.previous, time.Hour)
+			if err != nil {
+				t.Fatalf("NewKeySet: %v", err)
+			}
+			set, err := ks.JWKS()
+			if err != nil {
+				t.Fatalf("JWKS: %v", err)
+			}
+			if len(set.Keys) != tt.keys {
+				t.Errorf("JWKS has %d keys, want %d", len(set.Keys), tt.keys)
+			}
+
+			body, err := json.Marshal(set)
+			if err != nil {
+				t.Fatalf("marshalling JWKS: %v", err)
+			}
+			for _, leak := range []string{secret, base64.RawURLEncoding.EncodeToString([]byte(secret)), `"oct"`} {
+				if strings.Contains(string(body), leak) {
+					t.Errorf("JWKS contains %s: %s", leak, body)
+				}
+			}
+		})
+	}
+}
+
+func TestNewKeySetDuplicates(t *testing.T) {
+	k := rsaKey(t)
+
+	tests := []struct {
+		name     string
+		signing  *Key
+		previous []*Key
+	}{
+		{"same key twice", k, []*Key{k}},
+		{"two secrets", NewHMACKey("old"), []*Key{NewHMACKey("new")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewKeySet(tt.signing, tt.previous, time.Hour); err == nil {
+				t.Error("NewKeySet succeeded, want an error")
+			}
+		})
+	}
+}
@@ -1,20 +1,393 @@
 package config
 
 import (
-	"log"
-	"os"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/yaml.v3"
 )
 
+const redacted = "[REDACTED]"
+
+var dsnPassword = regexp.MustCompile(`(password=)(?:'[^']*'|\S+)`)
+
+// Config is the full, typed server configuration. Values are layered as
+// defaults → config file → environment → command-line flags, see Load.
 type Config struct {
-	JWTSecret string
+	Server    ServerConfig    `yaml:"server" toml:"server"`
+	Database  DatabaseConfig  `yaml:"database" toml:"database"`
+	JWT       JWTConfig       `yaml:"jwt" toml:"jwt"`
+	Security  SecurityConfig  `yaml:"security" toml:"security"`
+	Mail      MailConfig      `yaml:"mail" toml:"mail"`
+	RateLimit RateLimitConfig `yaml:"rate_limit" toml:"rate_limit"`
+	Cleanup   CleanupConfig   `yaml:"cleanup" toml:"cleanup"`
+	Audit     AuditConfig     `yaml:"audit" toml:"audit"`
 }
 
-func Load() *Config {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		log.Fatal("JWT_SECRET env variable not set")
+type ServerConfig struct {
+	Port            int           `yaml:"port" toml:"port"`
+	ReadTimeout     time.Duration `yaml:"read_timeout" toml:"read_timeout"`
+	WriteTimeout    time.Duration `yaml:"write_timeout" toml:"write_timeout"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout" toml:"idle_timeout"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+	// PublicURL is where clients reach the server, used for links in
+	// emails. Defaults to http://localhost:<port>.
+	PublicURL string `yaml:"public_url" toml:"public_url"`
+	// Repanic makes the recovery middleware panic again after answering,
+	// so tests and debugging sessions see the original panic.
+	Repanic bool `yaml:"repanic" toml:"repanic"`
+}
+
+// BaseURL returns PublicURL without a trailing slash, or the local
+// address when it is not set.
+func (c ServerConfig) BaseURL() string {
+	if c.PublicURL == "" {
+		return fmt.Sprintf("http://localhost:%d", c.Port)
 	}
+	return strings.TrimRight(c.PublicURL, "/")
+}
+
+type DatabaseConfig struct {
+	Driver          string        `yaml:"driver" toml:"driver"` // sqlite | postgres
+	DSN             string        `yaml:"dsn" toml:"dsn"`
+	AutoMigrate     bool          `yaml:"auto_migrate" toml:"auto_migrate"`
+	MaxOpenConns    int           `yaml:"max_open_conns" toml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" toml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime"`
+	SQLite          SQLiteConfig  `yaml:"sqlite" toml:"sqlite"`
+}
+
+// SQLiteConfig only applies when Driver is sqlite.
+type SQLiteConfig struct {
+	JournalMode string        `yaml:"journal_mode" toml:"journal_mode"`
+	BusyTimeout time.Duration `yaml:"busy_timeout" toml:"busy_timeout"`
+	Synchronous string        `yaml:"synchronous" toml:"synchronous"`
+	ForeignKeys bool          `yaml:"foreign_keys" toml:"foreign_keys"`
+}
+
+// JWTConfig signs with HS256 and Secret unless PrivateKey is set. Keys
+// are PEM encoded; use JWT_PRIVATE_KEY_FILE to load them from disk.
+type JWTConfig struct {
+	Secret          string        `yaml:"secret" toml:"secret"`
+	Duration        time.Duration `yaml:"duration" toml:"duration"`
+	RefreshDuration time.Duration `yaml:"refresh_duration" toml:"refresh_duration"`
+	Issuer          string        `yaml:"issuer" toml:"issuer"`
+	Audience        string        `yaml:"audience" toml:"audience"` // empty leaves out the aud claim
+	// Leeway is the clock skew tolerated when checking exp, nbf and iat.
+	Leeway time.Duration `yaml:"leeway" toml:"leeway"`
+	// PrivateKey is an RSA, ECDSA P-256 or Ed25519 key signing new tokens.
+	PrivateKey string `yaml:"private_key" toml:"private_key"`
+	// PreviousKeys is a PEM bundle of rotated-out keys that still verify
+	// for RotationGracePeriod after startup.
+	PreviousKeys        string        `yaml:"previous_keys" toml:"previous_keys"`
+	RotationGracePeriod time.Duration `yaml:"rotation_grace_period" toml:"rotation_grace_period"`
+}
+
+type SecurityConfig struct {
+	BcryptCost int `yaml:"bcrypt_cost" toml:"bcrypt_cost"`
+	// TokenPepper keys the HMAC of session tokens stored in the database.
+	// Changing it invalidates every session.
+	TokenPepper      string        `yaml:"token_pepper" toml:"token_pepper"`
+	PasswordResetTTL time.Duration `yaml:"password_reset_ttl" toml:"password_reset_ttl"`
+	// RequireEmailVerification makes login refuse accounts whose email
+	// address was never verified.
+	RequireEmailVerification bool          `yaml:"require_email_verification" toml:"require_email_verification"`
+	EmailVerificationTTL     time.Duration `yaml:"email_verification_ttl" toml:"email_verification_ttl"`
+	// MFAChallengeTTL is how long a user has to enter the second factor
+	// after the password was accepted.
+	MFAChallengeTTL time.Duration `yaml:"mfa_challenge_ttl" toml:"mfa_challenge_ttl"`
+	// ImpersonationTTL is how long an admin's token for acting as another
+	// user lives. It cannot be refreshed.
+	ImpersonationTTL time.Duration `yaml:"impersonation_ttl" toml:"impersonation_ttl"`
+	// TOTPIssuer is the account label shown in authenticator apps.
+	TOTPIssuer string `yaml:"totp_issuer" toml:"totp_issuer"`
+	// After LoginMaxFailures failed logins in a row for an account, or
+	// LoginMaxFailuresPerIP from one IP, logins are locked for
+	// LoginLockout, doubling with every further failure up to
+	// LoginLockoutMax. Failures are forgotten after LoginLockoutMax
+	// without one.
+	LoginMaxFailures      int           `yaml:"login_max_failures" toml:"login_max_failures"`
+	LoginMaxFailuresPerIP int           `yaml:"login_max_failures_per_ip" toml:"login_max_failures_per_ip"`
+	LoginLockout          time.Duration `yaml:"login_lockout" toml:"login_lockout"`
+	LoginLockoutMax       time.Duration `yaml:"login_lockout_max" toml:"login_lockout_max"`
+}
+
+// MailConfig selects how transactional email is delivered. Both built-in
+// drivers are development stand-ins: log writes messages to the log, file
+// stores them as .eml files in Dir.
+type MailConfig struct {
+	Driver string `yaml:"driver" toml:"driver"` // log | file
+	From   string `yaml:"from" toml:"from"`
+	Dir    string `yaml:"dir" toml:"dir"`
+}
+
+// RateLimitConfig limits requests per client IP: Requests per Window on
+// every route, AuthRequests per AuthWindow on the login, signup and
+// password endpoints. A zero request count turns that limit off. Store is
+// memory (per instance) or database (shared by every instance using the
+// same database).
+type RateLimitConfig struct {
+	Store        string        `yaml:"store" toml:"store"` // memory | database
+	Requests     int           `yaml:"requests" toml:"requests"`
+	Window       time.Duration `yaml:"window" toml:"window"`
+	AuthRequests int           `yaml:"auth_requests" toml:"auth_requests"`
+	AuthWindow   time.Duration `yaml:"auth_window" toml:"auth_window"`
+}
+
+// CleanupConfig controls the background jobs that delete expired
+// sessions, tokens and counters. An Interval of 0 turns them off.
+type CleanupConfig struct {
+	Interval  time.Duration `yaml:"interval" toml:"interval"`
+	BatchSize int           `yaml:"batch_size" toml:"batch_size"`
+}
+
+// AuditConfig controls the audit log. Events older than Retention are
+// deleted by the cleanup jobs; 0 keeps them forever.
+type AuditConfig struct {
+	Retention time.Duration `yaml:"retention" toml:"retention"`
+}
+
+// Default returns the configuration used when nothing else is set.
+func Default() *Config {
 	return &Config{
-		JWTSecret: secret,
+		Server: ServerConfig{
+			Port:            8080,
+			ReadTimeout:     5 * time.Second,
+			WriteTimeout:    10 * time.Second,
+			IdleTimeout:     time.Minute,
+			ShutdownTimeout: 15 * time.Second,
+		},
+		Database: DatabaseConfig{
+			Driver:          "sqlite",
+			DSN:             "localSQLite.db",
+			MaxOpenConns:    10,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: 30 * time.Minute,
+			SQLite: SQLiteConfig{
+				JournalMode: "WAL",
+				BusyTimeout: 5 * time.Second,
+				Synchronous: "NORMAL",
+				ForeignKeys: true,
+			},
+		},
+		JWT: JWTConfig{
+			Duration:            24 * time.Hour * 7,  // 7 days
+			RefreshDuration:     24 * time.Hour * 14, // 14 days
+			Issuer:              "go-basic-server",
+			Leeway:              30 * time.Second,
+			RotationGracePeriod: 24 * time.Hour * 7, // as long as an access token lives
+		},
+		Security: SecurityConfig{
+			BcryptCost:            bcrypt.DefaultCost,
+			PasswordResetTTL:      time.Hour,
+			EmailVerificationTTL:  24 * time.Hour,
+			MFAChallengeTTL:       5 * time.Minute,
+			ImpersonationTTL:      15 * time.Minute,
+			TOTPIssuer:            "go-basic-server",
+			LoginMaxFailures:      5,
+			LoginMaxFailuresPerIP: 20,
+			LoginLockout:          time.Minute,
+			LoginLockoutMax:       time.Hour,
+		},
+		Mail: MailConfig{
+			Driver: "log",
+			From:   "no-reply@localhost",
+			Dir:    "mail",
+		},
+		RateLimit: RateLimitConfig{
+			Store:        "memory",
+			Requests:     300,
+			Window:       time.Minute,
+			AuthRequests: 10,
+			AuthWindow:   time.Minute,
+		},
+		Cleanup: CleanupConfig{
+			Interval:  time.Hour,
+			BatchSize: 1000,
+		},
+		Audit: AuditConfig{
+			Retention: 90 * 24 * time.Hour,
+		},
+	}
+}
+
+// Validate reports every problem with c at once.
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		errs = append(errs, fmt.Errorf("server.port must be between 1 and 65535, got %d", c.Server.Port))
+	}
+	if c.Server.ReadTimeout <= 0 {
+		errs = append(errs, errors.New("server.read_timeout must be positive"))
+	}
+	if c.Server.WriteTimeout <= 0 {
+		errs = append(errs, errors.New("server.write_timeout must be positive"))
+	}
+	if c.Server.IdleTimeout <= 0 {
+		errs = append(errs, errors.New("server.idle_timeout must be positive"))
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("server.shutdown_timeout must be positive"))
+	}
+	if c.Server.PublicURL != "" {
+		if u, err := url.Parse(c.Server.PublicURL); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("server.public_url %q must be an absolute URL", c.Server.PublicURL))
+		}
+	}
+
+	if c.Database.Driver != "sqlite" && c.Database.Driver != "postgres" {
+		errs = append(errs, fmt.Errorf("database.driver must be sqlite or postgres, got %q", c.Database.Driver))
+	}
+	if c.Database.DSN == "" {
+		errs = append(errs, errors.New("database.dsn is required"))
+	}
+	if c.Database.MaxOpenConns < 0 || c.Database.MaxIdleConns < 0 || c.Database.ConnMaxLifetime < 0 {
+		errs = append(errs, errors.New("database pool settings must not be negative"))
+	}
+	if !oneOf(c.Database.SQLite.JournalMode, "DELETE", "TRUNCATE", "PERSIST", "MEMORY", "WAL", "OFF") {
+		errs = append(errs, fmt.Errorf("database.sqlite.journal_mode %q is not a SQLite journal mode", c.Database.SQLite.JournalMode))
+	}
+	if !oneOf(c.Database.SQLite.Synchronous, "OFF", "NORMAL", "FULL", "EXTRA") {
+		errs = append(errs, fmt.Errorf("database.sqlite.synchronous %q is not a SQLite synchronous level", c.Database.SQLite.Synchronous))
+	}
+	if c.Database.SQLite.BusyTimeout < 0 {
+		errs = append(errs, errors.New("database.sqlite.busy_timeout must not be negative"))
+	}
+
+	if c.JWT.Secret == "" && c.JWT.PrivateKey == "" {
+		errs = append(errs, errors.New("jwt.secret or jwt.private_key is required (set JWT_SECRET or JWT_PRIVATE_KEY_FILE)"))
+	}
+	if c.JWT.Duration <= 0 {
+		errs = append(errs, errors.New("jwt.duration must be positive"))
+	}
+	if c.JWT.RefreshDuration <= c.JWT.Duration {
+		errs = append(errs, errors.New("jwt.refresh_duration must be longer than jwt.duration"))
+	}
+	if c.JWT.Issuer == "" {
+		errs = append(errs, errors.New("jwt.issuer is required"))
+	}
+	if c.JWT.Leeway < 0 {
+		errs = append(errs, errors.New("jwt.leeway must not be negative"))
+	}
+	if c.JWT.RotationGracePeriod < 0 {
+		errs = append(errs, errors.New("jwt.rotation_grace_period must not be negative"))
+	}
+
+	if c.Security.BcryptCost < bcrypt.MinCost || c.Security.BcryptCost > bcrypt.MaxCost {
+		errs = append(errs, fmt.Errorf("security.bcrypt_cost must be between %d and %d, got %d",
+			bcrypt.MinCost, bcrypt.MaxCost, c.Security.BcryptCost))
+	}
+	if c.Security.PasswordResetTTL <= 0 {
+		errs = append(errs, errors.New("security.password_reset_ttl must be positive"))
+	}
+	if c.Security.EmailVerificationTTL <= 0 {
+		errs = append(errs, errors.New("security.email_verification_ttl must be positive"))
+	}
+	if c.Security.MFAChallengeTTL <= 0 {
+		errs = append(errs, errors.New("security.mfa_challenge_ttl must be positive"))
+	}
+	if c.Security.ImpersonationTTL <= 0 {
+		errs = append(errs, errors.New("security.impersonation_ttl must be positive"))
+	}
+	if c.Security.TOTPIssuer == "" {
+		errs = append(errs, errors.New("security.totp_issuer is required"))
+	}
+	if c.Security.LoginMaxFailures < 1 {
+		errs = append(errs, errors.New("security.login_max_failures must be at least 1"))
+	}
+	if c.Security.LoginMaxFailuresPerIP < 1 {
+		errs = append(errs, errors.New("security.login_max_failures_per_ip must be at least 1"))
+	}
+	if c.Security.LoginLockout <= 0 {
+		errs = append(errs, errors.New("security.login_lockout must be positive"))
+	}
+	if c.Security.LoginLockoutMax < c.Security.LoginLockout {
+		errs = append(errs, errors.New("security.login_lockout_max must not be less than security.login_lockout"))
+	}
+
+	if c.Mail.Driver != "log" && c.Mail.Driver != "file" {
+		errs = append(errs, fmt.Errorf("mail.driver must be log or file, got %q", c.Mail.Driver))
+	}
+	if c.Mail.From == "" {
+		errs = append(errs, errors.New("mail.from is required"))
+	}
+	if c.Mail.Driver == "file" && c.Mail.Dir == "" {
+		errs = append(errs, errors.New("mail.dir is required for the file driver"))
+	}
+
+	if c.RateLimit.Store != "memory" && c.RateLimit.Store != "database" {
+		errs = append(errs, fmt.Errorf("rate_limit.store must be memory or database, got %q", c.RateLimit.Store))
+	}
+	if c.RateLimit.Requests < 0 || c.RateLimit.AuthRequests < 0 {
+		errs = append(errs, errors.New("rate_limit request counts must not be negative"))
+	}
+	if c.RateLimit.Window <= 0 || c.RateLimit.AuthWindow <= 0 {
+		errs = append(errs, errors.New("rate_limit windows must be positive"))
+	}
+
+	if c.Cleanup.Interval < 0 {
+		errs = append(errs, errors.New("cleanup.interval must not be negative"))
+	}
+	if c.Cleanup.BatchSize < 1 {
+		errs = append(errs, errors.New("cleanup.batch_size must be at least 1"))
+	}
+	if c.Audit.Retention < 0 {
+		errs = append(errs, errors.New("audit.retention must not be negative"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Redacted returns a copy of c that is safe to print or log.
+func (c *Config) Redacted() *Config {
+	out := *c
+	if out.JWT.Secret != "" {
+		out.JWT.Secret = redacted
+	}
+	if out.JWT.PrivateKey != "" {
+		out.JWT.PrivateKey = redacted
+	}
+	if out.JWT.PreviousKeys != "" {
+		out.JWT.PreviousKeys = redacted
+	}
+	if out.Security.TokenPepper != "" {
+		out.Security.TokenPepper = redacted
+	}
+	out.Database.DSN = redactDSN(out.Database.DSN)
+	return &out
+}
+
+func oneOf(v string, allowed ...string) bool {
+	for _, a := range allowed {
+		if strings.EqualFold(v, a) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactDSN hides the password in URL ("postgres://u:pw@host/db") and
+// keyword ("host=... password=pw") style connection strings.
+func redactDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.User != nil {
+		return u.Redacted()
+	}
+	return dsnPassword.ReplaceAllString(dsn, "${1}"+redacted)
+}
+
+// Print writes the redacted configuration to w as YAML.
+func (c *Config) Print(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(c.Redacted()); err != nil {
+		return err
 	}
+	return enc.Close()
 }
@@ -0,0 +1,279 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFile writes content to name in a fresh temporary directory and
+// returns its path.
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("writing %s: %v", name, err)
+	}
+	return path
+}
+
+func TestLoadLayers(t *testing.T) {
+	files := map[string]string{
+		"config.yaml": `
+server:
+  port: 1000
+  read_timeout: 1s
+  write_timeout: 2s
+  idle_timeout: 3s
+`,
+		"config.toml": `
+[server]
+port = 1000
+read_timeout = "1s"
+write_timeout = "2s"
+idle_timeout = "3s"
+`,
+	}
+
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("JWT_SECRET", "secret")
+			t.Setenv("SERVER_WRITE_TIMEOUT", "20s")
+			t.Setenv("SERVER_IDLE_TIMEOUT", "30s")
+
+			cfg, _, err := Load([]string{"-config", writeFile(t, name, content), "-idle-timeout", "300s"})
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+
+			got := cfg.Server
+			want := ServerConfig{
+				Port:            1000,              // file
+				ReadTimeout:     time.Second,       // file
+				WriteTimeout:    20 * time.Second,  // env over file
+				IdleTimeout:     300 * time.Second, // flag over env
+				ShutdownTimeout: Default().Server.ShutdownTimeout,
+			}
+			if got != want {
+				t.Errorf("server config = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsUnknownFileKeys(t *testing.T) {
+	files := map[string]string{
+		"config.yaml": "server:\n  prot: 1000\n",
+		"config.toml": "[server]\nprot = 1000\n",
+	}
+
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("JWT_SECRET", "secret")
+			if _, _, err := Load([]string{"-config", writeFile(t, name, content)}); err == nil {
+				t.Error("Load succeeded, want an error for the misspelt key")
+			}
+		})
+	}
+}
+
+func TestLoadReportsEveryBadValue(t *testing.T) {
+	t.Setenv("JWT_SECRET", "secret")
+	t.Setenv("SERVER_PORT", "eighty")
+
+	_, _, err := Load([]string{"-jwt-duration", "forever", "-repanic=maybe"})
+	if err == nil {
+		t.Fatal("Load succeeded, want an error")
+	}
+	for _, want := range []string{"env SERVER_PORT", "flag -jwt-duration", "flag -repanic"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %s", err, want)
+		}
+	}
+}
+
+func TestValidateJoinsErrors(t *testing.T) {
+	cfg := Default()
+	cfg.JWT.Secret = "secret"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate of the defaults with a secret: %v", err)
+	}
+
+	cfg.Server.Port = 0
+	cfg.Database.Driver = "mysql"
+	cfg.JWT.Secret = ""
+	cfg.Mail.Driver = "smtp"
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate succeeded, want an error")
+	}
+	for _, want := range []string{"server.port", "database.driver", "jwt.secret", "mail.driver"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %s", err, want)
+		}
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	tests := []struct {
+		dsn  string
+		want string
+	}{
+		{"localSQLite.db", "localSQLite.db"},
+		{"postgres://app:hunter2@db/app", "postgres://app:xxxxx@db/app"},
+		{"host=db user=app password=hunter2 dbname=app", "host=db user=app password=[REDACTED] dbname=app"},
+		{"host=db password='hunter 2' dbname=app", "host=db password=[REDACTED] dbname=app"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dsn, func(t *testing.T) {
+			cfg := Default()
+			cfg.Database.DSN = tt.dsn
+			cfg.JWT.Secret = "jwt-secret"
+			cfg.JWT.PrivateKey = "private-key"
+			cfg.JWT.PreviousKeys = "previous-keys"
+			cfg.Security.TokenPepper = "token-pepper"
+
+			r := cfg.Redacted()
+			if r.Database.DSN != tt.want {
+				t.Errorf("DSN = %q, want %q", r.Database.DSN, tt.want)
+			}
+			for name, v := range map[string]string{
+				"jwt.secret":            r.JWT.Secret,
+				"jwt.private_key":       r.JWT.PrivateKey,
+				"jwt.previous_keys":     r.JWT.PreviousKeys,
+				"security.token_pepper": r.Security.TokenPepper,
+			} {
+				if v != redacted {
+					t.Errorf("%s = %q, want %q", name, v, redacted)
+				}
+			}
+			if cfg.JWT.Secret != "jwt-secret" || cfg.Database.DSN != tt.dsn {
+				t.Error("Redacted changed the original config")
+			}
+		})
+	}
+}
+
+func TestPrintConfigHidesSecrets(t *testing.T) {
+	secrets := []string{"jwt-secret", "private-key", "previous-keys", "token-pepper", "hunter2"}
+	t.Setenv("JWT_SECRET", secrets[0])
+	t.Setenv("JWT_PRIVATE_KEY", secrets[1])
+	t.Setenv("JWT_PREVIOUS_KEYS", secrets[2])
+	t.Setenv("TOKEN_PEPPER", secrets[3])
+	t.Setenv("DB_DSN", "postgres://app:hunter2@db/app")
+
+	cfg, opts, err := Load([]string{"-print-config", "-port", "9000"})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !opts.PrintConfig {
+		t.Fatal("PrintConfig not set")
+	}
+
+	var out bytes.Buffer
+	if err := cfg.Print(&out); err != nil {
+		t.Fatalf("Print: %v", err)
+	}
+	for _, secret := range secrets {
+		if strings.Contains(out.String(), secret) {
+			t.Errorf("printed config contains %q:\n%s", secret, out.String())
+		}
+	}
+	if !strings.Contains(out.String(), "port: 9000") {
+		t.Errorf("printed config is missing the effective port:\n%s", out.String())
+	}
+}
+
+func TestLoadEnvFiles(t *testing.T) {
+	configFile := writeFile(t, "config.yaml", "server:\n  read_timeout: 1s\n")
+	first := writeFile(t, "first.env", "JWT_SECRET=first\nSERVER_PORT=1001\nJWT_ISSUER=first\nCONFIG_FILE="+configFile+"\n")
+	second := writeFile(t, "second.env", "SERVER_PORT=1002\n")
+	t.Setenv("JWT_ISSUER", "process")
+
+	cfg, opts, err := Load([]string{"-env-file", first, "-env-file", second})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if len(opts.EnvFiles) != 2 {
+		t.Errorf("EnvFiles = %v, want both files", opts.EnvFiles)
+	}
+	if cfg.JWT.Secret != "first" {
+		t.Errorf("jwt.secret = %q, want first", cfg.JWT.Secret)
+	}
+	if cfg.Server.Port != 1002 {
+		t.Errorf("server.port = %d, want 1002 from the later file", cfg.Server.Port)
+	}
+	if cfg.JWT.Issuer != "process" {
+		t.Errorf("jwt.issuer = %q, want process from the environment", cfg.JWT.Issuer)
+	}
+	if cfg.Server.ReadTimeout != time.Second {
+		t.Errorf("server.read_timeout = %s, want 1s from CONFIG_FILE", cfg.Server.ReadTimeout)
+	}
+}
+
+func TestLoadReadsEnvFilesOnRequest(t *testing.T) {
+	t.Chdir(filepath.Dir(writeFile(t, ".env", "SERVER_PORT=1001\n")))
+	t.Setenv("JWT_SECRET", "secret")
+
+	cfg, _, err := Load(nil)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Server.Port != Default().Server.Port {
+		t.Errorf("server.port = %d, .env was read without -env-file", cfg.Server.Port)
+	}
+
+	if _, _, err := Load([]string{"-env-file", "missing.env"}); err == nil {
+		t.Error("Load with a missing env file succeeded, want an error")
+	}
+}
+
+func TestLoadFileVariables(t *testing.T) {
+	secretFile := writeFile(t, "jwt_secret", "from-file\n")
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		envFile string
+		want    string // empty when Load must fail
+	}{
+		{"from the environment", map[string]string{"JWT_SECRET_FILE": secretFile}, "", "from-file"},
+		{"from an env file", nil, "JWT_SECRET_FILE=" + secretFile, "from-file"},
+		{"environment over env file", map[string]string{"JWT_SECRET": "process"}, "JWT_SECRET_FILE=" + secretFile, "process"},
+		{"both in the environment", map[string]string{"JWT_SECRET": "process", "JWT_SECRET_FILE": secretFile}, "", ""},
+		{"both in an env file", nil, "JWT_SECRET=env-file\nJWT_SECRET_FILE=" + secretFile, ""},
+		{"missing file", map[string]string{"JWT_SECRET_FILE": secretFile + ".missing"}, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			var args []string
+			if tt.envFile != "" {
+				args = []string{"-env-file", writeFile(t, "test.env", tt.envFile)}
+			}
+
+			cfg, _, err := Load(args)
+			if tt.want == "" {
+				if err == nil {
+					t.Errorf("Load succeeded with jwt.secret %q, want an error", cfg.JWT.Secret)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if cfg.JWT.Secret != tt.want {
+				t.Errorf("jwt.secret = %q, want %q", cfg.JWT.Secret, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/joho/godotenv"
+)
+
+// fileSuffix marks a variable whose value is the path of a file holding
+// the real value, e.g. JWT_SECRET_FILE=/run/secrets/jwt.
+const fileSuffix = "_FILE"
+
+// environment resolves variables from the process environment first and
+// then from the env files passed with -env-file.
+type environment struct {
+	files map[string]string
+}
+
+// readEnvFiles merges the given dotenv files; later files override
+// earlier ones.
+func readEnvFiles(paths []string) (environment, error) {
+	env := environment{files: map[string]string{}}
+	for _, path := range paths {
+		vars, err := godotenv.Read(path)
+		if err != nil {
+			return env, fmt.Errorf("env file: %w", err)
+		}
+		for k, v := range vars {
+			env.files[k] = v
+		}
+	}
+	return env, nil
+}
+
+// lookup returns the value of key, following a key_FILE reference when
+// present. Setting both key and key_FILE in the same source is an error.
+func (e environment) lookup(key string) (string, bool, error) {
+	sources := []func(string) (string, bool){
+		os.LookupEnv,
+		func(k string) (string, bool) {
+			v, ok := e.files[k]
+			return v, ok
+		},
+	}
+
+	for _, get := range sources {
+		value, hasValue := get(key)
+		path, hasFile := get(key + fileSuffix)
+
+		switch {
+		case hasValue && hasFile:
+			return "", false, fmt.Errorf("both %s and %s%s are set", key, key, fileSuffix)
+		case hasFile:
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return "", false, fmt.Errorf("%s%s: %w", key, fileSuffix, err)
+			}
+			// mounted secrets almost always end in a newline
+			return strings.TrimRight(string(data), "\r\n"), true, nil
+		case hasValue:
+			return value, true, nil
+		}
+	}
+
+	return "", false, nil
+}
@@ -0,0 +1,257 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Options control how the configuration is loaded rather than what the
+// server does with it.
+type Options struct {
+	File        string   // path to a YAML or TOML config file
+	EnvFiles    []string // dotenv files, later ones take precedence
+	PrintConfig bool     // print the redacted config and exit
+	Args        []string // positional arguments left after the flags, e.g. a subcommand
+}
+
+// setting binds one Config field to its env variable and flag.
+type setting struct {
+	env   string
+	flag  string // empty when the value must not come from the command line
+	usage string
+	ptr   any // *string, *int, *bool or *time.Duration
+}
+
+func settings(c *Config) []setting {
+	return []setting{
+		{"SERVER_PORT", "port", "Port to run the server on", &c.Server.Port},
+		{"SERVER_READ_TIMEOUT", "read-timeout", "Max duration for reading a request", &c.Server.ReadTimeout},
+		{"SERVER_WRITE_TIMEOUT", "write-timeout", "Max duration for writing a response", &c.Server.WriteTimeout},
+		{"SERVER_IDLE_TIMEOUT", "idle-timeout", "Max keep-alive idle time", &c.Server.IdleTimeout},
+		{"SERVER_SHUTDOWN_TIMEOUT", "shutdown-timeout", "How long to wait for in-flight requests on shutdown", &c.Server.ShutdownTimeout},
+		{"SERVER_PUBLIC_URL", "public-url", "URL clients reach the server at, used in emailed links", &c.Server.PublicURL},
+		{"SERVER_REPANIC", "repanic", "Panic again after recovering from a handler panic, for tests and debugging", &c.Server.Repanic},
+		{"DB_DRIVER", "db-driver", "Database driver: sqlite or postgres", &c.Database.Driver},
+		{"DB_DSN", "dsn", "Database data source name", &c.Database.DSN},
+		{"DB_AUTO_MIGRATE", "auto-migrate", "Apply pending migrations on startup", &c.Database.AutoMigrate},
+		{"DB_MAX_OPEN_CONNS", "db-max-open-conns", "Max open connections in the pool (0 = unlimited)", &c.Database.MaxOpenConns},
+		{"DB_MAX_IDLE_CONNS", "db-max-idle-conns", "Max idle connections in the pool", &c.Database.MaxIdleConns},
+		{"DB_CONN_MAX_LIFETIME", "db-conn-max-lifetime", "Max lifetime of a pooled connection (0 = forever)", &c.Database.ConnMaxLifetime},
+		{"SQLITE_JOURNAL_MODE", "sqlite-journal-mode", "SQLite journal mode", &c.Database.SQLite.JournalMode},
+		{"SQLITE_BUSY_TIMEOUT", "sqlite-busy-timeout", "How long SQLite waits on a locked database", &c.Database.SQLite.BusyTimeout},
+		{"SQLITE_SYNCHRONOUS", "sqlite-synchronous", "SQLite synchronous level", &c.Database.SQLite.Synchronous},
+		{"SQLITE_FOREIGN_KEYS", "sqlite-foreign-keys", "Enforce foreign keys in SQLite", &c.Database.SQLite.ForeignKeys},
+		{"JWT_SECRET", "", "", &c.JWT.Secret},
+		{"JWT_DURATION", "jwt-duration", "Access token lifetime", &c.JWT.Duration},
+		{"JWT_REFRESH_DURATION", "jwt-refresh-duration", "Refresh token lifetime", &c.JWT.RefreshDuration},
+		{"JWT_ISSUER", "jwt-issuer", "iss claim of issued tokens", &c.JWT.Issuer},
+		{"JWT_AUDIENCE", "jwt-audience", "aud claim of issued tokens, empty for none", &c.JWT.Audience},
+		{"JWT_LEEWAY", "jwt-leeway", "Clock skew tolerated when verifying tokens", &c.JWT.Leeway},
+		{"JWT_PRIVATE_KEY", "", "", &c.JWT.PrivateKey},
+		{"JWT_PREVIOUS_KEYS", "", "", &c.JWT.PreviousKeys},
+		{"JWT_ROTATION_GRACE_PERIOD", "jwt-rotation-grace-period", "How long previous JWT keys keep verifying", &c.JWT.RotationGracePeriod},
+		{"BCRYPT_COST", "bcrypt-cost", "bcrypt cost for password hashes", &c.Security.BcryptCost},
+		{"TOKEN_PEPPER", "", "", &c.Security.TokenPepper},
+		{"PASSWORD_RESET_TTL", "password-reset-ttl", "How long a password reset token stays valid", &c.Security.PasswordResetTTL},
+		{"REQUIRE_EMAIL_VERIFICATION", "require-email-verification", "Refuse login until the email address is verified", &c.Security.RequireEmailVerification},
+		{"EMAIL_VERIFICATION_TTL", "email-verification-ttl", "How long an email verification token stays valid", &c.Security.EmailVerificationTTL},
+		{"MFA_CHALLENGE_TTL", "mfa-challenge-ttl", "How long the second login step may take", &c.Security.MFAChallengeTTL},
+		{"IMPERSONATION_TTL", "impersonation-ttl", "How long an admin's impersonation token lives", &c.Security.ImpersonationTTL},
+		{"TOTP_ISSUER", "totp-issuer", "Issuer name shown in authenticator apps", &c.Security.TOTPIssuer},
+		{"LOGIN_MAX_FAILURES", "login-max-failures", "Failed logins per account before it is locked", &c.Security.LoginMaxFailures},
+		{"LOGIN_MAX_FAILURES_PER_IP", "login-max-failures-per-ip", "Failed logins per IP before it is locked", &c.Security.LoginMaxFailuresPerIP},
+		{"LOGIN_LOCKOUT", "login-lockout", "First lockout after too many failed logins, doubles each time", &c.Security.LoginLockout},
+		{"LOGIN_LOCKOUT_MAX", "login-lockout-max", "Longest lockout after failed logins", &c.Security.LoginLockoutMax},
+		{"MAIL_DRIVER", "mail-driver", "Mail driver: log or file", &c.Mail.Driver},
+		{"MAIL_FROM", "mail-from", "Sender address of outgoing mail", &c.Mail.From},
+		{"MAIL_DIR", "mail-dir", "Directory the file mail driver writes to", &c.Mail.Dir},
+		{"RATE_LIMIT_STORE", "rate-limit-store", "Rate limit counters: memory or database", &c.RateLimit.Store},
+		{"RATE_LIMIT_REQUESTS", "rate-limit-requests", "Requests per window and IP, 0 disables", &c.RateLimit.Requests},
+		{"RATE_LIMIT_WINDOW", "rate-limit-window", "Rate limit window", &c.RateLimit.Window},
+		{"RATE_LIMIT_AUTH_REQUESTS", "rate-limit-auth-requests", "Requests per window and IP to auth endpoints, 0 disables", &c.RateLimit.AuthRequests},
+		{"RATE_LIMIT_AUTH_WINDOW", "rate-limit-auth-window", "Rate limit window of auth endpoints", &c.RateLimit.AuthWindow},
+		{"CLEANUP_INTERVAL", "cleanup-interval", "How often expired sessions and tokens are deleted, 0 disables", &c.Cleanup.Interval},
+		{"CLEANUP_BATCH_SIZE", "cleanup-batch-size", "Rows deleted per statement by the cleanup jobs", &c.Cleanup.BatchSize},
+		{"AUDIT_RETENTION", "audit-retention", "How long audit events are kept, 0 keeps them forever", &c.Audit.Retention},
+	}
+}
+
+// Load builds the configuration from defaults, an optional config file,
+// the environment and finally args (usually os.Args[1:]). Env files are
+// only read when passed with -env-file, and never override variables
+// already set in the process. Any variable may instead be given as
+// NAME_FILE pointing at a file with the value. The result is validated;
+// nothing here exits the process.
+func Load(args []string) (*Config, Options, error) {
+	var opts Options
+
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	fs.StringVar(&opts.File, "config", "", "Path to a YAML or TOML config file (env CONFIG_FILE)")
+	fs.Func("env-file", "Load variables from a dotenv file, may be repeated; later files win", func(v string) error {
+		opts.EnvFiles = append(opts.EnvFiles, v)
+		return nil
+	})
+	fs.BoolVar(&opts.PrintConfig, "print-config", false, "Print the effective configuration with secrets redacted and exit")
+
+	// flags are parsed first (we need -config) but applied last, so only
+	// record what was passed for now
+	flagValues := map[string]string{}
+	defaults := Default()
+	for _, s := range settings(defaults) {
+		if s.flag == "" {
+			continue
+		}
+		name := s.flag
+		usage := fmt.Sprintf("%s (env %s, default %s)", s.usage, s.env, format(s.ptr))
+		record := func(v string) error {
+			flagValues[name] = v
+			return nil
+		}
+		if _, ok := s.ptr.(*bool); ok {
+			fs.BoolFunc(name, usage, record)
+		} else {
+			fs.Func(name, usage, record)
+		}
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, opts, err
+	}
+	opts.Args = fs.Args()
+
+	env, err := readEnvFiles(opts.EnvFiles)
+	if err != nil {
+		return nil, opts, err
+	}
+
+	if opts.File == "" {
+		file, _, err := env.lookup("CONFIG_FILE")
+		if err != nil {
+			return nil, opts, err
+		}
+		opts.File = file
+	}
+
+	cfg := Default()
+
+	if opts.File != "" {
+		if err := loadFile(cfg, opts.File); err != nil {
+			return nil, opts, err
+		}
+	}
+
+	var errs []error
+	for _, s := range settings(cfg) {
+		v, ok, err := env.lookup(s.env)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if err := parse(s.ptr, v); err != nil {
+			errs = append(errs, fmt.Errorf("env %s: %w", s.env, err))
+		}
+	}
+	for _, s := range settings(cfg) {
+		v, ok := flagValues[s.flag]
+		if s.flag == "" || !ok {
+			continue
+		}
+		if err := parse(s.ptr, v); err != nil {
+			errs = append(errs, fmt.Errorf("flag -%s: %w", s.flag, err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, opts, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, opts, fmt.Errorf("invalid config: %w", err)
+	}
+
+	return cfg, opts, nil
+}
+
+/* ---------- helpers ---------------------------------------------------- */
+
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(cfg); err != nil {
+			return fmt.Errorf("config file %s: %w", path, err)
+		}
+	case ".toml":
+		md, err := toml.Decode(string(data), cfg)
+		if err != nil {
+			return fmt.Errorf("config file %s: %w", path, err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("config file %s: unknown keys %v", path, undecoded)
+		}
+	default:
+		return fmt.Errorf("config file %s: unsupported format, use .yaml, .yml or .toml", path)
+	}
+	return nil
+}
+
+func parse(ptr any, v string) error {
+	switch p := ptr.(type) {
+	case *string:
+		*p = v
+	case *int:
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", v)
+		}
+		*p = n
+	case *bool:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", v)
+		}
+		*p = b
+	case *time.Duration:
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", v)
+		}
+		*p = d
+	default:
+		return fmt.Errorf("unsupported setting type %T", ptr)
+	}
+	return nil
+}
+
+func format(ptr any) string {
+	switch p := ptr.(type) {
+	case *string:
+		return strconv.Quote(*p)
+	case *int:
+		return strconv.Itoa(*p)
+	case *bool:
+		return strconv.FormatBool(*p)
+	case *time.Duration:
+		return p.String()
+	default:
+		return ""
+	}
+}
@@ -2,11 +2,62 @@ package clients
 
 import (
 	"database/sql"
+	"fmt"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/bercivarga/go-basic-server/internal/config"
+	"github.com/bercivarga/go-basic-server/internal/db/querier"
 )
 
 type Database interface {
 	Connect() (*sql.DB, error)
+	// Writer returns the pool writes and transactions must go through.
+	// It is the same pool as Connect's unless the driver needs a
+	// dedicated writer.
+	Writer() *sql.DB
 	Close() error
+	// Dialect names the SQL flavour, one of the querier.Dialect* values.
+	Dialect() string
+}
+
+// PoolOptions mirror the database/sql pool settings; zero values keep
+// the database/sql defaults.
+type PoolOptions struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
+
+func (p PoolOptions) apply(db *sql.DB) {
+	db.SetMaxOpenConns(p.MaxOpenConns)
+	if p.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(p.MaxIdleConns)
+	}
+	db.SetConnMaxLifetime(p.ConnMaxLifetime)
+}
+
+// New returns the client for the configured driver.
+func New(cfg config.DatabaseConfig) (Database, error) {
+	pool := PoolOptions{
+		MaxOpenConns:    cfg.MaxOpenConns,
+		MaxIdleConns:    cfg.MaxIdleConns,
+		ConnMaxLifetime: cfg.ConnMaxLifetime,
+	}
+
+	switch cfg.Driver {
+	case querier.DialectSQLite:
+		return NewSQLite(cfg.DSN, SQLiteOptions{
+			JournalMode: cfg.SQLite.JournalMode,
+			BusyTimeout: cfg.SQLite.BusyTimeout,
+			Synchronous: cfg.SQLite.Synchronous,
+			ForeignKeys: cfg.SQLite.ForeignKeys,
+			Pool:        pool,
+		}), nil
+	case querier.DialectPostgres:
+		return NewPostgres(cfg.DSN, pool), nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", cfg.Driver)
+	}
 }
@@ -0,0 +1,53 @@
+package clients
+
+import (
+	"database/sql"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+
+	"github.com/bercivarga/go-basic-server/internal/db/querier"
+)
+
+const (
+	PostgresDriver = "pgx"
+)
+
+type Postgres struct {
+	Driver string
+	DSN    string
+	Pool   PoolOptions
+	DB     *sql.DB
+}
+
+func NewPostgres(dsn string, pool PoolOptions) *Postgres {
+	return &Postgres{Driver: PostgresDriver, DSN: dsn, Pool: pool}
+}
+
+func (p *Postgres) Connect() (*sql.DB, error) {
+	db, err := sql.Open(p.Driver, p.DSN)
+	if err != nil {
+		return nil, err
+	}
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	p.Pool.apply(db)
+	p.DB = db
+	return db, nil
+}
+
+func (p *Postgres) Writer() *sql.DB {
+	return p.DB
+}
+
+func (p *Postgres) Dialect() string {
+	return querier.DialectPostgres
+}
+
+func (p *Postgres) Close() error {
+	if p.DB != nil {
+		return p.DB.Close()
+	}
+	return nil
+}
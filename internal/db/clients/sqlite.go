@@ -2,40 +2,121 @@ package clients
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/bercivarga/go-basic-server/internal/db/querier"
 )
 
 const (
 	SQLiteDriver = "sqlite3"
 )
 
+// SQLiteOptions are applied to every connection through go-sqlite3 DSN
+// parameters, since pragmas like foreign_keys are per connection.
+type SQLiteOptions struct {
+	JournalMode string        // DELETE | TRUNCATE | PERSIST | MEMORY | WAL | OFF
+	BusyTimeout time.Duration // how long a connection waits on a locked database
+	Synchronous string        // OFF | NORMAL | FULL | EXTRA
+	ForeignKeys bool
+	Pool        PoolOptions
+}
+
+// SQLite keeps two pools on the same file: DB for reads and WriteDB, a
+// single connection that serialises writes so they queue on the Go side
+// instead of failing with "database is locked".
 type SQLite struct {
-	Driver string
-	DSN    string
-	DB     *sql.DB
+	Driver  string
+	DSN     string
+	Options SQLiteOptions
+	DB      *sql.DB
+	WriteDB *sql.DB
 }
 
-func NewSQLite(dsn string) *SQLite {
-	return &SQLite{Driver: SQLiteDriver, DSN: dsn}
+func NewSQLite(dsn string, opts SQLiteOptions) *SQLite {
+	return &SQLite{Driver: SQLiteDriver, DSN: dsn, Options: opts}
 }
 
 func (s *SQLite) Connect() (*sql.DB, error) {
-	db, err := sql.Open("sqlite3", s.DSN)
+	db, err := s.open(false)
 	if err != nil {
 		return nil, err
 	}
-	if err = db.Ping(); err != nil {
+	s.Options.Pool.apply(db)
+
+	writeDB, err := s.open(true)
+	if err != nil {
 		db.Close()
 		return nil, err
 	}
+	writeDB.SetMaxOpenConns(1)
+	writeDB.SetMaxIdleConns(1)
+	writeDB.SetConnMaxLifetime(s.Options.Pool.ConnMaxLifetime)
+
 	s.DB = db
+	s.WriteDB = writeDB
 	return db, nil
 }
 
+func (s *SQLite) Writer() *sql.DB {
+	return s.WriteDB
+}
+
+func (s *SQLite) Dialect() string {
+	return querier.DialectSQLite
+}
+
 func (s *SQLite) Close() error {
+	var errs []error
+	if s.WriteDB != nil {
+		errs = append(errs, s.WriteDB.Close())
+	}
 	if s.DB != nil {
-		return s.DB.Close()
+		errs = append(errs, s.DB.Close())
+	}
+	return errors.Join(errs...)
+}
+
+func (s *SQLite) open(writer bool) (*sql.DB, error) {
+	db, err := sql.Open(s.Driver, s.dsn(writer))
+	if err != nil {
+		return nil, err
+	}
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// dsn appends the connection pragmas to the configured DSN. The writer
+// takes its lock when a transaction begins rather than on the first
+// write, so it never has to upgrade a read lock and deadlock.
+func (s *SQLite) dsn(writer bool) string {
+	params := url.Values{}
+	if s.Options.JournalMode != "" {
+		params.Set("_journal_mode", strings.ToUpper(s.Options.JournalMode))
+	}
+	if s.Options.BusyTimeout > 0 {
+		params.Set("_busy_timeout", strconv.FormatInt(s.Options.BusyTimeout.Milliseconds(), 10))
+	}
+	if s.Options.Synchronous != "" {
+		params.Set("_synchronous", strings.ToUpper(s.Options.Synchronous))
+	}
+	params.Set("_foreign_keys", strconv.FormatBool(s.Options.ForeignKeys))
+	if writer {
+		params.Set("_txlock", "immediate")
+	}
+
+	sep := "?"
+	if strings.Contains(s.DSN, "?") {
+		sep = "&"
 	}
-	return nil
+	return fmt.Sprintf("%s%s%s", s.DSN, sep, params.Encode())
 }
@@ -0,0 +1,96 @@
+// Package dberr turns driver errors into sentinels stores can test for
+// without knowing whether they run on SQLite or PostgreSQL.
+package dberr
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/mattn/go-sqlite3"
+
+	"github.com/bercivarga/go-basic-server/internal/apperr"
+)
+
+var (
+	ErrNotFound   = errors.New("no rows")
+	ErrUnique     = errors.New("unique constraint violated")
+	ErrForeignKey = errors.New("foreign key constraint violated")
+
+	// ErrCheck means a CHECK constraint refused a value that request
+	// validation let through. It is an apperr so clients get a 400
+	// rather than a 500.
+	ErrCheck = apperr.New(apperr.ErrInvalid, "value_not_allowed", "a value is not allowed")
+
+	// ErrUnavailable means the database could not be reached or was too
+	// busy to answer. It is an apperr so it reaches clients as a 503
+	// through any layer that wraps it.
+	ErrUnavailable = apperr.New(apperr.ErrUnavailable, "database_unavailable", "service temporarily unavailable")
+)
+
+// Translate returns err wrapped in the sentinel it matches, so errors.Is
+// finds both the sentinel and the driver error. Other errors, and nil,
+// are returned as they are.
+func Translate(err error) error {
+	if err == nil {
+		return nil
+	}
+	if sentinel := classify(err); sentinel != nil {
+		return fmt.Errorf("%w: %w", sentinel, err)
+	}
+	return err
+}
+
+func classify(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) {
+		return ErrUnavailable
+	}
+
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.ExtendedCode {
+		case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey:
+			return ErrUnique
+		case sqlite3.ErrConstraintCheck:
+			return ErrCheck
+		case sqlite3.ErrConstraintForeignKey:
+			return ErrForeignKey
+		}
+		switch sqliteErr.Code {
+		case sqlite3.ErrBusy, sqlite3.ErrLocked, sqlite3.ErrCantOpen:
+			return ErrUnavailable
+		}
+		return nil
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == "23505":
+			return ErrUnique
+		case pgErr.Code == "23514":
+			return ErrCheck
+		case pgErr.Code == "23503":
+			return ErrForeignKey
+		// connection exceptions, insufficient resources, shutting down
+		case strings.HasPrefix(pgErr.Code, "08"), strings.HasPrefix(pgErr.Code, "53"),
+			pgErr.Code == "57P01", pgErr.Code == "57P02", pgErr.Code == "57P03":
+			return ErrUnavailable
+		}
+		return nil
+	}
+
+	var connectErr *pgconn.ConnectError
+	var netErr *net.OpError
+	if errors.As(err, &connectErr) || errors.As(err, &netErr) {
+		return ErrUnavailable
+	}
+	return nil
+}
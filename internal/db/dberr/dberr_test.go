@@ -0,0 +1,98 @@
+package dberr
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/mattn/go-sqlite3"
+
+	"github.com/bercivarga/go-basic-server/internal/apperr"
+)
+
+func TestTranslate(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error // nil: returned as it is
+	}{
+		{"no rows", sql.ErrNoRows, ErrNotFound},
+		{"wrapped no rows", fmt.Errorf("fetching user: %w", sql.ErrNoRows), ErrNotFound},
+		{"bad connection", driver.ErrBadConn, ErrUnavailable},
+		{"connection done", sql.ErrConnDone, ErrUnavailable},
+
+		{"sqlite unique", sqliteErr(sqlite3.ErrConstraint, sqlite3.ErrConstraintUnique), ErrUnique},
+		{"sqlite primary key", sqliteErr(sqlite3.ErrConstraint, sqlite3.ErrConstraintPrimaryKey), ErrUnique},
+		{"sqlite check", sqliteErr(sqlite3.ErrConstraint, sqlite3.ErrConstraintCheck), ErrCheck},
+		{"sqlite foreign key", sqliteErr(sqlite3.ErrConstraint, sqlite3.ErrConstraintForeignKey), ErrForeignKey},
+		{"sqlite busy", sqliteErr(sqlite3.ErrBusy, sqlite3.ErrBusySnapshot), ErrUnavailable},
+		{"sqlite locked", sqliteErr(sqlite3.ErrLocked, 0), ErrUnavailable},
+		{"sqlite cannot open", sqliteErr(sqlite3.ErrCantOpen, 0), ErrUnavailable},
+		{"sqlite not null", sqliteErr(sqlite3.ErrConstraint, sqlite3.ErrConstraintNotNull), nil},
+		{"sqlite other", sqliteErr(sqlite3.ErrError, 0), nil},
+
+		{"postgres unique", &pgconn.PgError{Code: "23505"}, ErrUnique},
+		{"postgres check", &pgconn.PgError{Code: "23514"}, ErrCheck},
+		{"postgres foreign key", &pgconn.PgError{Code: "23503"}, ErrForeignKey},
+		{"postgres connection failure", &pgconn.PgError{Code: "08006"}, ErrUnavailable},
+		{"postgres too many connections", &pgconn.PgError{Code: "53300"}, ErrUnavailable},
+		{"postgres admin shutdown", &pgconn.PgError{Code: "57P01"}, ErrUnavailable},
+		{"postgres crash shutdown", &pgconn.PgError{Code: "57P02"}, ErrUnavailable},
+		{"postgres cannot connect now", &pgconn.PgError{Code: "57P03"}, ErrUnavailable},
+		{"postgres undefined table", &pgconn.PgError{Code: "42P01"}, nil},
+
+		{"network", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, ErrUnavailable},
+		{"other", errors.New("boom"), nil},
+	}
+
+	sentinels := []error{ErrNotFound, ErrUnique, ErrCheck, ErrForeignKey, ErrUnavailable}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Translate(tt.err)
+
+			if !errors.Is(got, tt.err) {
+				t.Errorf("Translate(%v) = %v, lost the original error", tt.err, got)
+			}
+			if tt.want == nil && got != tt.err {
+				t.Errorf("Translate(%v) = %v, want it unchanged", tt.err, got)
+			}
+			for _, s := range sentinels {
+				if is := errors.Is(got, s); is != (s == tt.want) {
+					t.Errorf("errors.Is(Translate(%v), %v) = %v", tt.err, s, is)
+				}
+			}
+		})
+	}
+}
+
+func TestTranslateNil(t *testing.T) {
+	if err := Translate(nil); err != nil {
+		t.Errorf("Translate(nil) = %v, want nil", err)
+	}
+}
+
+func TestTranslateKinds(t *testing.T) {
+	tests := []struct {
+		err  error
+		kind error
+	}{
+		{sqliteErr(sqlite3.ErrBusy, 0), apperr.ErrUnavailable},
+		{sqliteErr(sqlite3.ErrConstraint, sqlite3.ErrConstraintCheck), apperr.ErrInvalid},
+		{&pgconn.PgError{Code: "23514"}, apperr.ErrInvalid},
+	}
+
+	for _, tt := range tests {
+		var ae *apperr.Error
+		if !errors.As(Translate(tt.err), &ae) || !errors.Is(ae, tt.kind) {
+			t.Errorf("Translate(%v) is not an apperr of kind %v", tt.err, tt.kind)
+		}
+	}
+}
+
+func sqliteErr(code sqlite3.ErrNo, extended sqlite3.ErrNoExtended) sqlite3.Error {
+	return sqlite3.Error{Code: code, ExtendedCode: extended}
+}
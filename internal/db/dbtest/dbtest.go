@@ -0,0 +1,66 @@
+// Package dbtest opens migrated SQLite databases for tests.
+package dbtest
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bercivarga/go-basic-server/internal/db/clients"
+	"github.com/bercivarga/go-basic-server/internal/db/migrate"
+	"github.com/bercivarga/go-basic-server/internal/db/querier"
+)
+
+// TokenPepper is the pepper the migrations of Open ran with.
+const TokenPepper = "test-pepper"
+
+// Open returns a database with every migration applied, set up like the
+// server's: a read pool and a single-connection writer on one file in
+// t.TempDir. It uses the rollback journal and a short busy timeout, so
+// tests can hold a lock through DB and see writes fail quickly. The
+// database is closed when the test ends.
+func Open(t testing.TB) *querier.DB {
+	t.Helper()
+	return open(t, filepath.Join(t.TempDir(), "test.db"))
+}
+
+var memoryDBs atomic.Int64
+
+// OpenMemory is Open on a fresh in-memory database, for tests that do
+// not depend on file locking.
+func OpenMemory(t testing.TB) *querier.DB {
+	t.Helper()
+	return open(t, fmt.Sprintf("file:dbtest%d?mode=memory&cache=shared", memoryDBs.Add(1)))
+}
+
+func open(t testing.TB, dsn string) *querier.DB {
+	t.Helper()
+
+	database := clients.NewSQLite(dsn, clients.SQLiteOptions{
+		JournalMode: "DELETE",
+		BusyTimeout: 20 * time.Millisecond,
+		ForeignKeys: true,
+	})
+	conn, err := database.Connect()
+	if err != nil {
+		t.Fatalf("connecting: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	db, err := querier.New(conn, database.Writer(), database.Dialect())
+	if err != nil {
+		t.Fatalf("binding queries: %v", err)
+	}
+
+	m, err := migrate.New(db.DB, db.Dialect(), TokenPepper)
+	if err != nil {
+		t.Fatalf("loading migrations: %v", err)
+	}
+	if _, err := m.Up(context.Background()); err != nil {
+		t.Fatalf("migrating: %v", err)
+	}
+	return db
+}
@@ -0,0 +1,73 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+
+	"github.com/bercivarga/go-basic-server/internal/db/querier"
+	"github.com/bercivarga/go-basic-server/internal/utils"
+)
+
+// hashSessionTokensVersion follows 0003_session_token_hashes.sql, which
+// renamed the token columns. Hashing needs the server's token pepper, so
+// it is a Go migration rather than SQL.
+const hashSessionTokensVersion = 4
+
+func hashSessionTokens(dialect, pepper string) *goose.Migration {
+	update := `UPDATE sessions SET token_hash = ?, refresh_token_hash = ? WHERE id = ?`
+	if dialect == querier.DialectPostgres {
+		update = `UPDATE sessions SET token_hash = $1, refresh_token_hash = $2 WHERE id = $3`
+	}
+
+	up := func(ctx context.Context, tx *sql.Tx) error {
+		type row struct {
+			id                  int64
+			token, refreshToken string
+		}
+
+		rows, err := tx.QueryContext(ctx, `SELECT id, token_hash, refresh_token_hash FROM sessions`)
+		if err != nil {
+			return err
+		}
+		var sessions []row
+		for rows.Next() {
+			var r row
+			if err := rows.Scan(&r.id, &r.token, &r.refreshToken); err != nil {
+				rows.Close()
+				return err
+			}
+			sessions = append(sessions, r)
+		}
+		if err := rows.Close(); err != nil {
+			return err
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, r := range sessions {
+			_, err := tx.ExecContext(ctx, update,
+				utils.HashToken(r.token, pepper),
+				utils.HashToken(r.refreshToken, pepper),
+				r.id,
+			)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// hashes cannot be turned back into tokens, everyone logs in again
+	down := func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `DELETE FROM sessions`)
+		return err
+	}
+
+	return goose.NewGoMigration(hashSessionTokensVersion,
+		&goose.GoFunc{RunTx: up},
+		&goose.GoFunc{RunTx: down},
+	)
+}
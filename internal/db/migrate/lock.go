@@ -0,0 +1,120 @@
+package migrate
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const (
+	lockPollInterval = 500 * time.Millisecond
+	lockTimeout      = 2 * time.Minute
+	// the holder refreshes the lock this often while migrating, however
+	// long the migrations take
+	lockRefreshInterval = time.Minute
+	// a lock not refreshed for this long was left behind by a crashed
+	// process
+	lockStaleAfter = 10 * time.Minute
+)
+
+// sqliteLocker is a goose lock.SessionLocker backed by a single-row table,
+// so two server instances sharing a database never migrate at once.
+type sqliteLocker struct {
+	db           *sql.DB
+	owner        string
+	refreshEvery time.Duration
+	staleAfter   time.Duration
+
+	stopRefresh context.CancelFunc
+	refreshed   chan struct{} // closed when the refresh goroutine returned
+}
+
+func newSQLiteLocker(db *sql.DB) (*sqliteLocker, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return &sqliteLocker{
+		db:           db,
+		owner:        hex.EncodeToString(b),
+		refreshEvery: lockRefreshInterval,
+		staleAfter:   lockStaleAfter,
+	}, nil
+}
+
+func (l *sqliteLocker) SessionLock(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS goose_lock (
+		id        INTEGER PRIMARY KEY CHECK (id = 1),
+		owner     TEXT NOT NULL,
+		locked_at DATETIME NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("create lock table: %w", err)
+	}
+
+	acquireCtx, cancel := context.WithTimeout(ctx, lockTimeout)
+	defer cancel()
+
+	for {
+		_, err := conn.ExecContext(acquireCtx, `DELETE FROM goose_lock WHERE locked_at < ?`, time.Now().UTC().Add(-l.staleAfter))
+		if err == nil {
+			_, err = conn.ExecContext(acquireCtx, `INSERT INTO goose_lock (id, owner, locked_at) VALUES (1, ?, ?)`, l.owner, time.Now().UTC())
+			if err == nil {
+				l.startRefresh(ctx)
+				return nil
+			}
+		}
+
+		// either another instance holds the lock or the database is busy
+		select {
+		case <-acquireCtx.Done():
+			return fmt.Errorf("acquire migration lock: %w (last error: %v)", acquireCtx.Err(), err)
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+// startRefresh keeps locked_at current until SessionUnlock, so a slow
+// migration is never mistaken for a crashed one. It goes through its own
+// connection: goose runs the migrations in transactions on conn.
+func (l *sqliteLocker) startRefresh(ctx context.Context) {
+	ctx, l.stopRefresh = context.WithCancel(context.WithoutCancel(ctx))
+	l.refreshed = make(chan struct{})
+
+	go func() {
+		defer close(l.refreshed)
+		ticker := time.NewTicker(l.refreshEvery)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			_, err := l.db.ExecContext(ctx, `UPDATE goose_lock SET locked_at = ? WHERE id = 1 AND owner = ?`, time.Now().UTC(), l.owner)
+			if err != nil && ctx.Err() == nil {
+				// the database may be busy migrating, try again next tick
+				slog.WarnContext(ctx, "refreshing migration lock failed", "error", err)
+			}
+		}
+	}()
+}
+
+func (l *sqliteLocker) SessionUnlock(ctx context.Context, conn *sql.Conn) error {
+	if l.stopRefresh != nil {
+		l.stopRefresh()
+		<-l.refreshed
+		l.stopRefresh = nil
+	}
+
+	_, err := conn.ExecContext(ctx, `DELETE FROM goose_lock WHERE id = 1 AND owner = ?`, l.owner)
+	if err != nil {
+		return fmt.Errorf("release migration lock: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// newLockers returns two lockers on the same database, as two server
+// instances would have, refreshing every 20ms and going stale after 200ms.
+func newLockers(t *testing.T) (db *sql.DB, a, b *sqliteLocker) {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "lock.db")+"?_busy_timeout=1000")
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db, newFastLocker(t, db), newFastLocker(t, db)
+}
+
+func newFastLocker(t *testing.T, db *sql.DB) *sqliteLocker {
+	t.Helper()
+	l, err := newSQLiteLocker(db)
+	if err != nil {
+		t.Fatalf("newSQLiteLocker: %v", err)
+	}
+	l.refreshEvery = 20 * time.Millisecond
+	l.staleAfter = 200 * time.Millisecond
+	return l
+}
+
+// acquire runs l.SessionLock on a new connection, giving up after timeout.
+func acquire(t *testing.T, db *sql.DB, l *sqliteLocker, timeout time.Duration) (*sql.Conn, error) {
+	t.Helper()
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatalf("getting connection: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return conn, l.SessionLock(ctx, conn)
+}
+
+func TestSQLiteLockRefreshedWhileHeld(t *testing.T) {
+	db, a, b := newLockers(t)
+
+	conn, err := acquire(t, db, a, time.Second)
+	if err != nil {
+		t.Fatalf("first lock: %v", err)
+	}
+
+	// well past staleAfter, but a keeps refreshing
+	time.Sleep(400 * time.Millisecond)
+	if _, err := acquire(t, db, b, 700*time.Millisecond); err == nil {
+		t.Fatal("second instance took a lock that is still held")
+	}
+
+	if err := a.SessionUnlock(context.Background(), conn); err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+	if _, err := acquire(t, db, b, time.Second); err != nil {
+		t.Errorf("lock after unlock: %v", err)
+	}
+}
+
+func TestSQLiteLockTakesOverStaleLock(t *testing.T) {
+	db, a, b := newLockers(t)
+
+	if _, err := acquire(t, db, a, time.Second); err != nil {
+		t.Fatalf("first lock: %v", err)
+	}
+	// the holder crashes: no more refreshes, no unlock
+	a.stopRefresh()
+	<-a.refreshed
+
+	time.Sleep(300 * time.Millisecond)
+	if _, err := acquire(t, db, b, time.Second); err != nil {
+		t.Errorf("taking over a stale lock: %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+
+	"github.com/bercivarga/go-basic-server/internal/db/migrations"
+	"github.com/bercivarga/go-basic-server/internal/db/querier"
+)
+
+// Commands lists the subcommands understood by Run.
+var Commands = []string{"up", "down", "status", "redo", "version"}
+
+// Migrator runs the embedded migrations. It never closes the database it
+// was given.
+type Migrator struct {
+	provider *goose.Provider
+}
+
+// New returns a Migrator applying the embedded migrations for dialect
+// to db. tokenPepper must match the one the server hashes session tokens
+// with.
+func New(db *sql.DB, dialect, tokenPepper string) (*Migrator, error) {
+	fsys, err := migrations.For(dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		gooseDialect goose.Dialect
+		locker       lock.SessionLocker
+	)
+	switch dialect {
+	case querier.DialectPostgres:
+		gooseDialect = goose.DialectPostgres
+		locker, err = lock.NewPostgresSessionLocker()
+	default:
+		gooseDialect = goose.DialectSQLite3
+		locker, err = newSQLiteLocker(db)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	provider, err := goose.NewProvider(gooseDialect, db, fsys,
+		goose.WithSessionLocker(locker),
+		goose.WithGoMigrations(
+			hashSessionTokens(dialect, tokenPepper),
+			userRoleReferences(dialect),
+		),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("migrations: %w", err)
+	}
+	return &Migrator{provider: provider}, nil
+}
+
+// Up applies every pending migration and returns what was run.
+func (m *Migrator) Up(ctx context.Context) ([]*goose.MigrationResult, error) {
+	return m.provider.Up(ctx)
+}
+
+// Run executes one migrate subcommand and writes a human-readable report
+// to w.
+func (m *Migrator) Run(ctx context.Context, command string, w io.Writer) error {
+	switch command {
+	case "up":
+		results, err := m.provider.Up(ctx)
+		printResults(w, results)
+		if len(results) == 0 && err == nil {
+			fmt.Fprintln(w, "no migrations to run")
+		}
+		return err
+
+	case "down":
+		result, err := m.provider.Down(ctx)
+		if errors.Is(err, goose.ErrNoNextVersion) {
+			fmt.Fprintln(w, "no migrations to roll back")
+			return nil
+		}
+		printResults(w, []*goose.MigrationResult{result})
+		return err
+
+	case "redo":
+		down, err := m.provider.Down(ctx)
+		if errors.Is(err, goose.ErrNoNextVersion) {
+			fmt.Fprintln(w, "no migrations to redo")
+			return nil
+		}
+		printResults(w, []*goose.MigrationResult{down})
+		if err != nil {
+			return err
+		}
+		up, err := m.provider.UpByOne(ctx)
+		printResults(w, []*goose.MigrationResult{up})
+		return err
+
+	case "status":
+		statuses, err := m.provider.Status(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%-24s %s\n", "Applied At", "Migration")
+		for _, s := range statuses {
+			appliedAt := "Pending"
+			if s.State == goose.StateApplied {
+				appliedAt = s.AppliedAt.Format(time.DateTime)
+			}
+			fmt.Fprintf(w, "%-24s %s\n", appliedAt, s.Source.Path)
+		}
+		return nil
+
+	case "version":
+		version, err := m.provider.GetDBVersion(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "version: %d\n", version)
+		return nil
+
+	default:
+		return fmt.Errorf("unknown migrate command %q, expected one of %v", command, Commands)
+	}
+}
+
+/* ---------- helpers ---------------------------------------------------- */
+
+func printResults(w io.Writer, results []*goose.MigrationResult) {
+	for _, r := range results {
+		if r != nil {
+			fmt.Fprintln(w, r)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/pressly/goose/v3"
+
+	"github.com/bercivarga/go-basic-server/internal/db/querier"
+)
+
+// userRoleReferencesVersion follows 0011_roles.sql. It swaps the CHECK
+// constraint limiting users.role to 'user' and 'admin' for a reference to
+// roles. SQLite cannot drop a constraint and has to rebuild the table,
+// which needs foreign keys off outside of a transaction, hence Go.
+const userRoleReferencesVersion = 12
+
+// usersColumns are the columns of users as of this migration.
+const usersColumns = `id, email, password_hash, role, created_at, email_verified_at,
+	totp_secret, totp_enabled_at, totp_last_step`
+
+func userRoleReferences(dialect string) *goose.Migration {
+	if dialect == querier.DialectPostgres {
+		return goose.NewGoMigration(userRoleReferencesVersion,
+			&goose.GoFunc{RunTx: execAll(
+				`ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check`,
+				`ALTER TABLE users ADD CONSTRAINT users_role_fkey
+					FOREIGN KEY (role) REFERENCES roles(name) ON UPDATE CASCADE`,
+			)},
+			&goose.GoFunc{RunTx: execAll(
+				`ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_fkey`,
+				`UPDATE users SET role = 'user' WHERE role NOT IN ('user', 'admin')`,
+				`ALTER TABLE users ADD CONSTRAINT users_role_check CHECK (role IN ('user', 'admin'))`,
+			)},
+		)
+	}
+
+	up := func(ctx context.Context, db *sql.DB) error {
+		return rebuildSQLiteUsers(ctx, db, `CREATE TABLE users_new (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			email TEXT NOT NULL UNIQUE,
+			password_hash TEXT NOT NULL,
+			role TEXT NOT NULL DEFAULT 'user' REFERENCES roles(name) ON UPDATE CASCADE,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			email_verified_at DATETIME,
+			totp_secret TEXT,
+			totp_enabled_at DATETIME,
+			totp_last_step INTEGER NOT NULL DEFAULT 0
+		)`)
+	}
+
+	// roles added since cannot pass the old CHECK, their users fall back
+	// to 'user'
+	down := func(ctx context.Context, db *sql.DB) error {
+		return rebuildSQLiteUsers(ctx, db, `CREATE TABLE users_new (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			email TEXT NOT NULL UNIQUE,
+			password_hash TEXT NOT NULL,
+			role TEXT NOT NULL DEFAULT 'user',
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			email_verified_at DATETIME,
+			totp_secret TEXT,
+			totp_enabled_at DATETIME,
+			totp_last_step INTEGER NOT NULL DEFAULT 0,
+			CHECK (role IN ('user', 'admin'))
+		)`, `UPDATE users SET role = 'user' WHERE role NOT IN ('user', 'admin')`)
+	}
+
+	return goose.NewGoMigration(userRoleReferencesVersion,
+		&goose.GoFunc{RunDB: up},
+		&goose.GoFunc{RunDB: down},
+	)
+}
+
+func execAll(stmts ...string) func(context.Context, *sql.Tx) error {
+	return func(ctx context.Context, tx *sql.Tx) error {
+		for _, stmt := range stmts {
+			if _, err := tx.ExecContext(ctx, stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// rebuildSQLiteUsers replaces users with the table created by create,
+// which must be named users_new, following the steps in
+// https://www.sqlite.org/lang_altertable.html#otheralter. before runs
+// first, in the same transaction.
+func rebuildSQLiteUsers(ctx context.Context, db *sql.DB, create string, before ...string) error {
+	// foreign_keys is per connection and ignored inside transactions. With
+	// it on, dropping users would cascade into every table referencing it.
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var foreignKeys bool
+	if err := conn.QueryRowContext(ctx, `PRAGMA foreign_keys`).Scan(&foreignKeys); err != nil {
+		return err
+	}
+	if foreignKeys {
+		if _, err := conn.ExecContext(ctx, `PRAGMA foreign_keys = OFF`); err != nil {
+			return err
+		}
+		// the connection goes back to the pool, restore it even when ctx
+		// was cancelled
+		defer conn.ExecContext(context.WithoutCancel(ctx), `PRAGMA foreign_keys = ON`)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// dropping the table drops its AUTOINCREMENT counter too, keep it so
+	// IDs of deleted users are not handed out again
+	var seq sql.NullInt64
+	err = tx.QueryRowContext(ctx, `SELECT seq FROM sqlite_sequence WHERE name = 'users'`).Scan(&seq)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	stmts := append(before,
+		create,
+		`INSERT INTO users_new (`+usersColumns+`) SELECT `+usersColumns+` FROM users`,
+		`DROP TABLE users`,
+		`ALTER TABLE users_new RENAME TO users`,
+	)
+	if err := execAll(stmts...)(ctx, tx); err != nil {
+		return err
+	}
+	if seq.Valid {
+		_, err := tx.ExecContext(ctx, `DELETE FROM sqlite_sequence WHERE name = 'users'`)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `INSERT INTO sqlite_sequence (name, seq) VALUES ('users', ?)`, seq.Int64)
+		if err != nil {
+			return err
+		}
+	}
+
+	rows, err := tx.QueryContext(ctx, `PRAGMA foreign_key_check(users)`)
+	if err != nil {
+		return err
+	}
+	violations := 0
+	for rows.Next() {
+		violations++
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	if violations > 0 {
+		return fmt.Errorf("rebuilding users leaves %d foreign key violations", violations)
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,23 @@
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+)
+
+// files holds every goose migration so the server binary can apply them
+// without the source tree. Each dialect has its own directory.
+//
+//go:embed sqlite/*.sql postgres/*.sql
+var files embed.FS
+
+// For returns the migrations written for dialect ("sqlite" or "postgres").
+func For(dialect string) (fs.FS, error) {
+	switch dialect {
+	case "sqlite", "postgres":
+		return fs.Sub(files, dialect)
+	default:
+		return nil, fmt.Errorf("no migrations for dialect %q", dialect)
+	}
+}
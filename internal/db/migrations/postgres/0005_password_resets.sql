@@ -0,0 +1,19 @@
+-- +goose Up
+-- Version 4 is the Go migration hashing existing session tokens.
+-- Reset tokens are stored hashed like session tokens; used_at makes them
+-- single-use.
+CREATE TABLE IF NOT EXISTS password_resets (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_resets_user_id ON password_resets(user_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_password_resets_user_id;
+DROP TABLE IF EXISTS password_resets;
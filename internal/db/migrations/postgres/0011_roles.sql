@@ -0,0 +1,41 @@
+-- +goose Up
+-- Roles grant permissions, users.role names one role. The built-in roles
+-- and permissions are seeded here, later migrations add to them.
+CREATE TABLE IF NOT EXISTS roles (
+    name TEXT PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS permissions (
+    name TEXT PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role TEXT NOT NULL,
+    permission TEXT NOT NULL,
+    PRIMARY KEY(role, permission),
+    FOREIGN KEY(role) REFERENCES roles(name) ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY(permission) REFERENCES permissions(name) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+INSERT INTO roles (name, description) VALUES
+    ('user', 'Default role of new accounts'),
+    ('admin', 'Administrators');
+
+INSERT INTO permissions (name, description) VALUES
+    ('users:list', 'List users'),
+    ('users:write', 'Change other users, e.g. reset their two-factor authentication'),
+    ('roles:read', 'List roles and their permissions'),
+    ('roles:assign', 'Assign roles to users'),
+    ('lockouts:manage', 'List and clear login lockouts'),
+    ('jobs:read', 'Inspect background jobs');
+
+INSERT INTO role_permissions (role, permission)
+SELECT 'admin', name FROM permissions;
+
+-- +goose Down
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS permissions;
+DROP TABLE IF EXISTS roles;
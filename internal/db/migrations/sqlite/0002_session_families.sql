@@ -0,0 +1,16 @@
+-- +goose Up
+-- Sessions created by rotating a refresh token share the family of the
+-- session they replace. Rotated sessions are kept (rotated_at set) so a
+-- replayed refresh token can be recognised and its family revoked.
+ALTER TABLE sessions ADD COLUMN family_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE sessions ADD COLUMN rotated_at DATETIME;
+
+UPDATE sessions SET family_id = lower(hex(randomblob(16))) WHERE family_id = '';
+
+CREATE INDEX IF NOT EXISTS idx_sessions_family_id ON sessions(family_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_sessions_family_id;
+DELETE FROM sessions WHERE rotated_at IS NOT NULL;
+ALTER TABLE sessions DROP COLUMN rotated_at;
+ALTER TABLE sessions DROP COLUMN family_id;
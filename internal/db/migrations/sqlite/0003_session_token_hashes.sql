@@ -0,0 +1,9 @@
+-- +goose Up
+-- Sessions only keep hashes of their tokens from now on, the values are
+-- hashed by the Go migration that follows (version 4).
+ALTER TABLE sessions RENAME COLUMN token TO token_hash;
+ALTER TABLE sessions RENAME COLUMN refresh_token TO refresh_token_hash;
+
+-- +goose Down
+ALTER TABLE sessions RENAME COLUMN refresh_token_hash TO refresh_token;
+ALTER TABLE sessions RENAME COLUMN token_hash TO token;
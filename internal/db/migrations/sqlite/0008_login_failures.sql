@@ -0,0 +1,20 @@
+-- +goose Up
+-- Failed logins per account (scope 'account', subject the email address,
+-- registered or not) and per client IP (scope 'ip'). failures counts the
+-- failures since the last quiet period; locked_until is set once there
+-- were too many.
+CREATE TABLE IF NOT EXISTS login_failures (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    scope TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at DATETIME NOT NULL,
+    locked_until DATETIME,
+    UNIQUE(scope, subject)
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_failures_locked_until ON login_failures(locked_until);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_login_failures_locked_until;
+DROP TABLE IF EXISTS login_failures;
@@ -0,0 +1,16 @@
+-- +goose Up
+-- Sliding window rate limit counters shared by all server instances:
+-- hits in the current window (bucket) and in the one before it.
+CREATE TABLE IF NOT EXISTS rate_limits (
+    limiter_key TEXT PRIMARY KEY,
+    bucket INTEGER NOT NULL,
+    hits INTEGER NOT NULL,
+    prev_hits INTEGER NOT NULL,
+    expires_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_rate_limits_expires_at ON rate_limits(expires_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_rate_limits_expires_at;
+DROP TABLE IF EXISTS rate_limits;
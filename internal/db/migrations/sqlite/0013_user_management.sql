@@ -0,0 +1,18 @@
+-- +goose Up
+-- Disabled accounts cannot log in; disabling also ends their sessions.
+ALTER TABLE users ADD COLUMN disabled_at DATETIME;
+
+INSERT INTO permissions (name, description) VALUES
+    ('users:read', 'View single users'),
+    ('users:delete', 'Delete users'),
+    ('users:impersonate', 'Act as another user for a short time');
+
+INSERT INTO role_permissions (role, permission)
+SELECT r.name, p.name
+FROM   roles r, permissions p
+WHERE  r.name = 'admin' AND p.name IN ('users:read', 'users:delete', 'users:impersonate');
+
+-- +goose Down
+DELETE FROM role_permissions WHERE permission IN ('users:read', 'users:delete', 'users:impersonate');
+DELETE FROM permissions WHERE name IN ('users:read', 'users:delete', 'users:impersonate');
+ALTER TABLE users DROP COLUMN disabled_at;
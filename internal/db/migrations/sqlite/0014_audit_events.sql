@@ -0,0 +1,39 @@
+-- +goose Up
+-- Security-relevant actions. actor_id and target_id are plain user IDs
+-- without foreign keys, so events outlive deleted users. metadata is a
+-- JSON object. Rows are never updated; only the retention job deletes.
+CREATE TABLE IF NOT EXISTS audit_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    actor_id INTEGER,
+    target_id INTEGER,
+    action TEXT NOT NULL,
+    ip TEXT NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    metadata TEXT NOT NULL DEFAULT '{}'
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_created_at ON audit_events(created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor_id ON audit_events(actor_id, id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_target_id ON audit_events(target_id, id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_action ON audit_events(action, id);
+
+-- +goose StatementBegin
+CREATE TRIGGER IF NOT EXISTS audit_events_append_only
+BEFORE UPDATE ON audit_events
+BEGIN
+    SELECT RAISE(ABORT, 'audit_events is append-only');
+END;
+-- +goose StatementEnd
+
+INSERT INTO permissions (name, description) VALUES
+    ('audit:read', 'Read the audit log');
+
+INSERT INTO role_permissions (role, permission)
+SELECT name, 'audit:read' FROM roles WHERE name = 'admin';
+
+-- +goose Down
+DELETE FROM role_permissions WHERE permission = 'audit:read';
+DELETE FROM permissions WHERE name = 'audit:read';
+DROP TRIGGER IF EXISTS audit_events_append_only;
+DROP TABLE IF EXISTS audit_events;
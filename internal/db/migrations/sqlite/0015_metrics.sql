@@ -0,0 +1,10 @@
+-- +goose Up
+INSERT INTO permissions (name, description) VALUES
+    ('metrics:read', 'Read server metrics');
+
+INSERT INTO role_permissions (role, permission)
+SELECT name, 'metrics:read' FROM roles WHERE name = 'admin';
+
+-- +goose Down
+DELETE FROM role_permissions WHERE permission = 'metrics:read';
+DELETE FROM permissions WHERE name = 'metrics:read';
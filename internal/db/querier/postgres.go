@@ -0,0 +1,337 @@
+package querier
+
+import (
+	"context"
+
+	"github.com/bercivarga/go-basic-server/internal/db/sqlc"
+	"github.com/bercivarga/go-basic-server/internal/db/sqlc/postgres"
+)
+
+// postgresQuerier adapts the postgres sqlc output to sqlc.Querier. Both
+// packages are generated from the same queries, so params and rows only
+// differ in their package and convert field for field.
+type postgresQuerier struct {
+	q *postgres.Queries
+}
+
+var _ sqlc.Querier = (*postgresQuerier)(nil)
+
+func (p *postgresQuerier) ConsumeEmailVerification(ctx context.Context, tokenHash string) (int64, error) {
+	return p.q.ConsumeEmailVerification(ctx, tokenHash)
+}
+
+func (p *postgresQuerier) ConsumePasswordReset(ctx context.Context, tokenHash string) (int64, error) {
+	return p.q.ConsumePasswordReset(ctx, tokenHash)
+}
+
+func (p *postgresQuerier) CreateAuditEvent(ctx context.Context, arg sqlc.CreateAuditEventParams) error {
+	return p.q.CreateAuditEvent(ctx, postgres.CreateAuditEventParams(arg))
+}
+
+func (p *postgresQuerier) CreateEmailVerification(ctx context.Context, arg sqlc.CreateEmailVerificationParams) error {
+	return p.q.CreateEmailVerification(ctx, postgres.CreateEmailVerificationParams(arg))
+}
+
+func (p *postgresQuerier) CreatePasswordReset(ctx context.Context, arg sqlc.CreatePasswordResetParams) error {
+	return p.q.CreatePasswordReset(ctx, postgres.CreatePasswordResetParams(arg))
+}
+
+func (p *postgresQuerier) CreateRecoveryCode(ctx context.Context, arg sqlc.CreateRecoveryCodeParams) error {
+	return p.q.CreateRecoveryCode(ctx, postgres.CreateRecoveryCodeParams(arg))
+}
+
+func (p *postgresQuerier) CreateSession(ctx context.Context, arg sqlc.CreateSessionParams) error {
+	return p.q.CreateSession(ctx, postgres.CreateSessionParams(arg))
+}
+
+func (p *postgresQuerier) CreateUser(ctx context.Context, arg sqlc.CreateUserParams) (sqlc.CreateUserRow, error) {
+	r, err := p.q.CreateUser(ctx, postgres.CreateUserParams(arg))
+	return sqlc.CreateUserRow(r), err
+}
+
+func (p *postgresQuerier) DeleteLoginFailure(ctx context.Context, arg sqlc.DeleteLoginFailureParams) (int64, error) {
+	return p.q.DeleteLoginFailure(ctx, postgres.DeleteLoginFailureParams(arg))
+}
+
+func (p *postgresQuerier) DeleteOtherSessions(ctx context.Context, arg sqlc.DeleteOtherSessionsParams) error {
+	return p.q.DeleteOtherSessions(ctx, postgres.DeleteOtherSessionsParams(arg))
+}
+
+func (p *postgresQuerier) DeleteRecoveryCodes(ctx context.Context, userID int64) error {
+	return p.q.DeleteRecoveryCodes(ctx, userID)
+}
+
+func (p *postgresQuerier) DeleteSessionByRefreshToken(ctx context.Context, refreshTokenHash string) error {
+	return p.q.DeleteSessionByRefreshToken(ctx, refreshTokenHash)
+}
+
+func (p *postgresQuerier) DeleteSessionByToken(ctx context.Context, tokenHash string) error {
+	return p.q.DeleteSessionByToken(ctx, tokenHash)
+}
+
+func (p *postgresQuerier) DeleteSessionFamily(ctx context.Context, familyID string) error {
+	return p.q.DeleteSessionFamily(ctx, familyID)
+}
+
+func (p *postgresQuerier) DeleteSessionsByUser(ctx context.Context, userID int64) error {
+	return p.q.DeleteSessionsByUser(ctx, userID)
+}
+
+func (p *postgresQuerier) DeleteUnusedEmailVerifications(ctx context.Context, userID int64) error {
+	return p.q.DeleteUnusedEmailVerifications(ctx, userID)
+}
+
+func (p *postgresQuerier) DeleteUnusedPasswordResets(ctx context.Context, userID int64) error {
+	return p.q.DeleteUnusedPasswordResets(ctx, userID)
+}
+
+func (p *postgresQuerier) DeleteUser(ctx context.Context, id int64) (int64, error) {
+	return p.q.DeleteUser(ctx, id)
+}
+
+func (p *postgresQuerier) DeleteUserSessionFamily(ctx context.Context, arg sqlc.DeleteUserSessionFamilyParams) (int64, error) {
+	return p.q.DeleteUserSessionFamily(ctx, postgres.DeleteUserSessionFamilyParams(arg))
+}
+
+func (p *postgresQuerier) DisableTOTP(ctx context.Context, id int64) error {
+	return p.q.DisableTOTP(ctx, id)
+}
+
+func (p *postgresQuerier) EnableTOTP(ctx context.Context, arg sqlc.EnableTOTPParams) (int64, error) {
+	return p.q.EnableTOTP(ctx, postgres.EnableTOTPParams(arg))
+}
+
+func (p *postgresQuerier) GetLoginFailure(ctx context.Context, arg sqlc.GetLoginFailureParams) (sqlc.LoginFailure, error) {
+	r, err := p.q.GetLoginFailure(ctx, postgres.GetLoginFailureParams(arg))
+	return sqlc.LoginFailure(r), err
+}
+
+func (p *postgresQuerier) GetRole(ctx context.Context, id int64) (string, error) {
+	return p.q.GetRole(ctx, id)
+}
+
+func (p *postgresQuerier) GetRolePermissions(ctx context.Context, role string) ([]string, error) {
+	return p.q.GetRolePermissions(ctx, role)
+}
+
+func (p *postgresQuerier) GetSessionByRefreshToken(ctx context.Context, refreshTokenHash string) (sqlc.Session, error) {
+	r, err := p.q.GetSessionByRefreshToken(ctx, refreshTokenHash)
+	return sqlc.Session(r), err
+}
+
+func (p *postgresQuerier) GetTOTP(ctx context.Context, id int64) (sqlc.GetTOTPRow, error) {
+	r, err := p.q.GetTOTP(ctx, id)
+	return sqlc.GetTOTPRow(r), err
+}
+
+func (p *postgresQuerier) GetUserByEmail(ctx context.Context, email string) (sqlc.GetUserByEmailRow, error) {
+	r, err := p.q.GetUserByEmail(ctx, email)
+	return sqlc.GetUserByEmailRow(r), err
+}
+
+func (p *postgresQuerier) GetUserByID(ctx context.Context, id int64) (sqlc.User, error) {
+	r, err := p.q.GetUserByID(ctx, id)
+	return sqlc.User(r), err
+}
+
+func (p *postgresQuerier) GetUserPermissions(ctx context.Context, id int64) ([]sqlc.GetUserPermissionsRow, error) {
+	rows, err := p.q.GetUserPermissions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]sqlc.GetUserPermissionsRow, len(rows))
+	for i, r := range rows {
+		items[i] = sqlc.GetUserPermissionsRow(r)
+	}
+	return items, nil
+}
+
+func (p *postgresQuerier) IncrementRateLimit(ctx context.Context, arg sqlc.IncrementRateLimitParams) (sqlc.IncrementRateLimitRow, error) {
+	r, err := p.q.IncrementRateLimit(ctx, postgres.IncrementRateLimitParams(arg))
+	return sqlc.IncrementRateLimitRow(r), err
+}
+
+func (p *postgresQuerier) IsValidSession(ctx context.Context, arg sqlc.IsValidSessionParams) (int64, error) {
+	return p.q.IsValidSession(ctx, postgres.IsValidSessionParams(arg))
+}
+
+func (p *postgresQuerier) ListActiveSessions(ctx context.Context, userID int64) ([]sqlc.ListActiveSessionsRow, error) {
+	rows, err := p.q.ListActiveSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]sqlc.ListActiveSessionsRow, len(rows))
+	for i, r := range rows {
+		items[i] = sqlc.ListActiveSessionsRow(r)
+	}
+	return items, nil
+}
+
+func (p *postgresQuerier) ListAuditEvents(ctx context.Context, arg sqlc.ListAuditEventsParams) ([]sqlc.AuditEvent, error) {
+	rows, err := p.q.ListAuditEvents(ctx, postgres.ListAuditEventsParams{
+		ActorID:  arg.ActorID,
+		TargetID: arg.TargetID,
+		Action:   arg.Action,
+		Since:    arg.Since,
+		Until:    arg.Until,
+		BeforeID: arg.BeforeID,
+		Limit:    int32(arg.Limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+	items := make([]sqlc.AuditEvent, len(rows))
+	for i, r := range rows {
+		items[i] = sqlc.AuditEvent(r)
+	}
+	return items, nil
+}
+
+func (p *postgresQuerier) ListLoginLockouts(ctx context.Context, arg sqlc.ListLoginLockoutsParams) ([]sqlc.LoginFailure, error) {
+	rows, err := p.q.ListLoginLockouts(ctx, postgres.ListLoginLockoutsParams{
+		LockedUntil: arg.LockedUntil,
+		Limit:       int32(arg.Limit),
+		Offset:      int32(arg.Offset),
+	})
+	if err != nil {
+		return nil, err
+	}
+	items := make([]sqlc.LoginFailure, len(rows))
+	for i, r := range rows {
+		items[i] = sqlc.LoginFailure(r)
+	}
+	return items, nil
+}
+
+func (p *postgresQuerier) ListRolePermissions(ctx context.Context) ([]sqlc.RolePermission, error) {
+	rows, err := p.q.ListRolePermissions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]sqlc.RolePermission, len(rows))
+	for i, r := range rows {
+		items[i] = sqlc.RolePermission(r)
+	}
+	return items, nil
+}
+
+func (p *postgresQuerier) ListRoles(ctx context.Context) ([]sqlc.ListRolesRow, error) {
+	rows, err := p.q.ListRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]sqlc.ListRolesRow, len(rows))
+	for i, r := range rows {
+		items[i] = sqlc.ListRolesRow(r)
+	}
+	return items, nil
+}
+
+func (p *postgresQuerier) ListUsers(ctx context.Context, arg sqlc.ListUsersParams) ([]sqlc.ListUsersRow, error) {
+	rows, err := p.q.ListUsers(ctx, postgres.ListUsersParams{
+		Limit:  int32(arg.Limit),
+		Offset: int32(arg.Offset),
+	})
+	if err != nil {
+		return nil, err
+	}
+	items := make([]sqlc.ListUsersRow, len(rows))
+	for i, r := range rows {
+		items[i] = sqlc.ListUsersRow(r)
+	}
+	return items, nil
+}
+
+func (p *postgresQuerier) LockLogin(ctx context.Context, arg sqlc.LockLoginParams) error {
+	return p.q.LockLogin(ctx, postgres.LockLoginParams(arg))
+}
+
+func (p *postgresQuerier) MarkEmailVerified(ctx context.Context, id int64) error {
+	return p.q.MarkEmailVerified(ctx, id)
+}
+
+func (p *postgresQuerier) MarkSessionRotated(ctx context.Context, id int64) (int64, error) {
+	return p.q.MarkSessionRotated(ctx, id)
+}
+
+func (p *postgresQuerier) PurgeAuditEvents(ctx context.Context, arg sqlc.PurgeAuditEventsParams) (int64, error) {
+	return p.q.PurgeAuditEvents(ctx, postgres.PurgeAuditEventsParams{
+		Before:    arg.Before,
+		BatchSize: int32(arg.BatchSize),
+	})
+}
+
+func (p *postgresQuerier) PurgeExpiredEmailVerifications(ctx context.Context, arg sqlc.PurgeExpiredEmailVerificationsParams) (int64, error) {
+	return p.q.PurgeExpiredEmailVerifications(ctx, postgres.PurgeExpiredEmailVerificationsParams{
+		Before:    arg.Before,
+		BatchSize: int32(arg.BatchSize),
+	})
+}
+
+func (p *postgresQuerier) PurgeExpiredPasswordResets(ctx context.Context, arg sqlc.PurgeExpiredPasswordResetsParams) (int64, error) {
+	return p.q.PurgeExpiredPasswordResets(ctx, postgres.PurgeExpiredPasswordResetsParams{
+		Before:    arg.Before,
+		BatchSize: int32(arg.BatchSize),
+	})
+}
+
+func (p *postgresQuerier) PurgeExpiredRateLimits(ctx context.Context, arg sqlc.PurgeExpiredRateLimitsParams) (int64, error) {
+	return p.q.PurgeExpiredRateLimits(ctx, postgres.PurgeExpiredRateLimitsParams{
+		Before:    arg.Before,
+		BatchSize: int32(arg.BatchSize),
+	})
+}
+
+func (p *postgresQuerier) PurgeExpiredSessions(ctx context.Context, arg sqlc.PurgeExpiredSessionsParams) (int64, error) {
+	return p.q.PurgeExpiredSessions(ctx, postgres.PurgeExpiredSessionsParams{
+		Before:    arg.Before,
+		BatchSize: int32(arg.BatchSize),
+	})
+}
+
+func (p *postgresQuerier) PurgeStaleLoginFailures(ctx context.Context, arg sqlc.PurgeStaleLoginFailuresParams) (int64, error) {
+	return p.q.PurgeStaleLoginFailures(ctx, postgres.PurgeStaleLoginFailuresParams{
+		Before:    arg.Before,
+		BatchSize: int32(arg.BatchSize),
+	})
+}
+
+func (p *postgresQuerier) RecordLoginFailure(ctx context.Context, arg sqlc.RecordLoginFailureParams) (int64, error) {
+	return p.q.RecordLoginFailure(ctx, postgres.RecordLoginFailureParams(arg))
+}
+
+func (p *postgresQuerier) RoleExists(ctx context.Context, name string) (int64, error) {
+	return p.q.RoleExists(ctx, name)
+}
+
+func (p *postgresQuerier) SetTOTPSecret(ctx context.Context, arg sqlc.SetTOTPSecretParams) (int64, error) {
+	return p.q.SetTOTPSecret(ctx, postgres.SetTOTPSecretParams(arg))
+}
+
+func (p *postgresQuerier) SetUserDisabled(ctx context.Context, arg sqlc.SetUserDisabledParams) (int64, error) {
+	return p.q.SetUserDisabled(ctx, postgres.SetUserDisabledParams(arg))
+}
+
+func (p *postgresQuerier) SetUserRole(ctx context.Context, arg sqlc.SetUserRoleParams) (int64, error) {
+	return p.q.SetUserRole(ctx, postgres.SetUserRoleParams(arg))
+}
+
+func (p *postgresQuerier) TouchSession(ctx context.Context, arg sqlc.TouchSessionParams) error {
+	return p.q.TouchSession(ctx, postgres.TouchSessionParams(arg))
+}
+
+func (p *postgresQuerier) UpdatePasswordHash(ctx context.Context, arg sqlc.UpdatePasswordHashParams) error {
+	return p.q.UpdatePasswordHash(ctx, postgres.UpdatePasswordHashParams(arg))
+}
+
+func (p *postgresQuerier) UpdateUserEmail(ctx context.Context, arg sqlc.UpdateUserEmailParams) (int64, error) {
+	return p.q.UpdateUserEmail(ctx, postgres.UpdateUserEmailParams(arg))
+}
+
+func (p *postgresQuerier) UseRecoveryCode(ctx context.Context, arg sqlc.UseRecoveryCodeParams) (int64, error) {
+	return p.q.UseRecoveryCode(ctx, postgres.UseRecoveryCodeParams(arg))
+}
+
+func (p *postgresQuerier) UseTOTPStep(ctx context.Context, arg sqlc.UseTOTPStepParams) (int64, error) {
+	return p.q.UseTOTPStep(ctx, postgres.UseTOTPStepParams(arg))
+}
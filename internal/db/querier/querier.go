@@ -0,0 +1,82 @@
+package querier
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/bercivarga/go-basic-server/internal/db/dberr"
+	"github.com/bercivarga/go-basic-server/internal/db/sqlc"
+	"github.com/bercivarga/go-basic-server/internal/db/sqlc/postgres"
+)
+
+const (
+	DialectSQLite   = "sqlite"
+	DialectPostgres = "postgres"
+)
+
+// DB pairs the connection pools with the sqlc queries generated for their
+// dialect. Stores only ever see the sqlc.Querier interface. Writes and
+// transactions go to the writer pool, everything else to the embedded
+// read pool; for most drivers both are the same *sql.DB.
+type DB struct {
+	*sql.DB
+	writer  *sql.DB
+	dialect string
+}
+
+func New(db, writer *sql.DB, dialect string) (*DB, error) {
+	if dialect != DialectSQLite && dialect != DialectPostgres {
+		return nil, fmt.Errorf("unsupported dialect %q", dialect)
+	}
+	if writer == nil {
+		writer = db
+	}
+	return &DB{DB: db, writer: writer, dialect: dialect}, nil
+}
+
+func (d *DB) Dialect() string {
+	return d.dialect
+}
+
+// Queries returns the querier bound to the connection pools.
+func (d *DB) Queries() sqlc.Querier {
+	if d.writer == d.DB {
+		return d.bind(d.DB)
+	}
+	return d.bind(&router{read: d.DB, write: d.writer})
+}
+
+// Tx is a transaction whose Commit error goes through dberr.Translate
+// like the errors of its queries, so a busy database fails the commit
+// with dberr.ErrUnavailable.
+type Tx struct {
+	*sql.Tx
+}
+
+func (tx *Tx) Commit() error {
+	return dberr.Translate(tx.Tx.Commit())
+}
+
+// BeginTx starts a transaction on the writer pool.
+func (d *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	tx, err := d.writer.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, dberr.Translate(err)
+	}
+	return &Tx{Tx: tx}, nil
+}
+
+// WithTx returns the querier bound to tx.
+func (d *DB) WithTx(tx *Tx) sqlc.Querier {
+	return d.bind(tx.Tx)
+}
+
+// bind returns the querier of the dialect on db. Its errors go through
+// dberr.Translate.
+func (d *DB) bind(db sqlc.DBTX) sqlc.Querier {
+	if d.dialect == DialectPostgres {
+		return &translatingQuerier{q: &postgresQuerier{q: postgres.New(db)}}
+	}
+	return &translatingQuerier{q: sqlc.New(db)}
+}
@@ -0,0 +1,51 @@
+package querier
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+// router is a sqlc.DBTX that sends statements which modify data to the
+// write pool and plain reads to the read pool.
+type router struct {
+	read  *sql.DB
+	write *sql.DB
+}
+
+func (r *router) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return r.write.ExecContext(ctx, query, args...)
+}
+
+func (r *router) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return r.pick(query).PrepareContext(ctx, query)
+}
+
+func (r *router) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return r.pick(query).QueryContext(ctx, query, args...)
+}
+
+func (r *router) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return r.pick(query).QueryRowContext(ctx, query, args...)
+}
+
+// pick looks at the first keyword after sqlc's "-- name:" comment lines,
+// so INSERT ... RETURNING run through QueryRow still hit the writer.
+func (r *router) pick(query string) *sql.DB {
+	if isRead(query) {
+		return r.read
+	}
+	return r.write
+}
+
+func isRead(query string) bool {
+	for _, line := range strings.Split(query, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "--") {
+			continue
+		}
+		keyword, _, _ := strings.Cut(line, " ")
+		return strings.EqualFold(keyword, "SELECT")
+	}
+	return false
+}
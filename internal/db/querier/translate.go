@@ -0,0 +1,278 @@
+package querier
+
+import (
+	"context"
+
+	"github.com/bercivarga/go-basic-server/internal/db/dberr"
+	"github.com/bercivarga/go-basic-server/internal/db/sqlc"
+)
+
+// translatingQuerier passes the errors of every query through
+// dberr.Translate, so stores see the same sentinels on either dialect.
+type translatingQuerier struct {
+	q sqlc.Querier
+}
+
+var _ sqlc.Querier = (*translatingQuerier)(nil)
+
+func (t *translatingQuerier) ConsumeEmailVerification(ctx context.Context, tokenHash string) (int64, error) {
+	r, err := t.q.ConsumeEmailVerification(ctx, tokenHash)
+	return r, dberr.Translate(err)
+}
+
+func (t *translatingQuerier) ConsumePasswordReset(ctx context.Context, tokenHash string) (int64, error) {
+	r, err := t.q.ConsumePasswordReset(ctx, tokenHash)
+	return r, dberr.Translate(err)
+}
+
+func (t *translatingQuerier) CreateAuditEvent(ctx context.Context, arg sqlc.CreateAuditEventParams) error {
+	return dberr.Translate(t.q.CreateAuditEvent(ctx, arg))
+}
+
+func (t *translatingQuerier) CreateEmailVerification(ctx context.Context, arg sqlc.CreateEmailVerificationParams) error {
+	return dberr.Translate(t.q.CreateEmailVerification(ctx, arg))
+}
+
+func (t *translatingQuerier) CreatePasswordReset(ctx context.Context, arg sqlc.CreatePasswordResetParams) error {
+	return dberr.Translate(t.q.CreatePasswordReset(ctx, arg))
+}
+
+func (t *translatingQuerier) CreateRecoveryCode(ctx context.Context, arg sqlc.CreateRecoveryCodeParams) error {
+	return dberr.Translate(t.q.CreateRecoveryCode(ctx, arg))
+}
+
+func (t *translatingQuerier) CreateSession(ctx context.Context, arg sqlc.CreateSessionParams) error {
+	return dberr.Translate(t.q.CreateSession(ctx, arg))
+}
+
+func (t *translatingQuerier) CreateUser(ctx context.Context, arg sqlc.CreateUserParams) (sqlc.CreateUserRow, error) {
+	r, err := t.q.CreateUser(ctx, arg)
+	return r, dberr.Translate(err)
+}
+
+func (t *translatingQuerier) DeleteLoginFailure(ctx context.Context, arg sqlc.DeleteLoginFailureParams) (int64, error) {
+	r, err := t.q.DeleteLoginFailure(ctx, arg)
+	return r, dberr.Translate(err)
+}
+
+func (t *translatingQuerier) DeleteOtherSessions(ctx context.Context, arg sqlc.DeleteOtherSessionsParams) error {
+	return dberr.Translate(t.q.DeleteOtherSessions(ctx, arg))
+}
+
+func (t *translatingQuerier) DeleteRecoveryCodes(ctx context.Context, userID int64) error {
+	return dberr.Translate(t.q.DeleteRecoveryCodes(ctx, userID))
+}
+
+func (t *translatingQuerier) DeleteSessionByRefreshToken(ctx context.Context, refreshTokenHash string) error {
+	return dberr.Translate(t.q.DeleteSessionByRefreshToken(ctx, refreshTokenHash))
+}
+
+func (t *translatingQuerier) DeleteSessionByToken(ctx context.Context, tokenHash string) error {
+	return dberr.Translate(t.q.DeleteSessionByToken(ctx, tokenHash))
+}
+
+func (t *translatingQuerier) DeleteSessionFamily(ctx context.Context, familyID string) error {
+	return dberr.Translate(t.q.DeleteSessionFamily(ctx, familyID))
+}
+
+func (t *translatingQuerier) DeleteSessionsByUser(ctx context.Context, userID int64) error {
+	return dberr.Translate(t.q.DeleteSessionsByUser(ctx, userID))
+}
+
+func (t *translatingQuerier) DeleteUnusedEmailVerifications(ctx context.Context, userID int64) error {
+	return dberr.Translate(t.q.DeleteUnusedEmailVerifications(ctx, userID))
+}
+
+func (t *translatingQuerier) DeleteUnusedPasswordResets(ctx context.Context, userID int64) error {
+	return dberr.Translate(t.q.DeleteUnusedPasswordResets(ctx, userID))
+}
+
+func (t *translatingQuerier) DeleteUser(ctx context.Context, id int64) (int64, error) {
+	r, err := t.q.DeleteUser(ctx, id)
+	return r, dberr.Translate(err)
+}
+
+func (t *translatingQuerier) DeleteUserSessionFamily(ctx context.Context, arg sqlc.DeleteUserSessionFamilyParams) (int64, error) {
+	r, err := t.q.DeleteUserSessionFamily(ctx, arg)
+	return r, dberr.Translate(err)
+}
+
+func (t *translatingQuerier) DisableTOTP(ctx context.Context, id int64) error {
+	return dberr.Translate(t.q.DisableTOTP(ctx, id))
+}
+
+func (t *translatingQuerier) EnableTOTP(ctx context.Context, arg sqlc.EnableTOTPParams) (int64, error) {
+	r, err := t.q.EnableTOTP(ctx, arg)
+	return r, dberr.Translate(err)
+}
+
+func (t *translatingQuerier) GetLoginFailure(ctx context.Context, arg sqlc.GetLoginFailureParams) (sqlc.LoginFailure, error) {
+	r, err := t.q.GetLoginFailure(ctx, arg)
+	return r, dberr.Translate(err)
+}
+
+func (t *translatingQuerier) GetRole(ctx context.Context, id int64) (string, error) {
+	r, err := t.q.GetRole(ctx, id)
+	return r, dberr.Translate(err)
+}
+
+func (t *translatingQuerier) GetRolePermissions(ctx context.Context, role string) ([]string, error) {
+	r, err := t.q.GetRolePermissions(ctx, role)
+	return r, dberr.Translate(err)
+}
+
+func (t *translatingQuerier) GetSessionByRefreshToken(ctx context.Context, refreshTokenHash string) (sqlc.Session, error) {
+	r, err := t.q.GetSessionByRefreshToken(ctx, refreshTokenHash)
+	return r, dberr.Translate(err)
+}
+
+func (t *translatingQuerier) GetTOTP(ctx context.Context, id int64) (sqlc.GetTOTPRow, error) {
+	r, err := t.q.GetTOTP(ctx, id)
+	return r, dberr.Translate(err)
+}
+
+func (t *translatingQuerier) GetUserByEmail(ctx context.Context, email string) (sqlc.GetUserByEmailRow, error) {
+	r, err := t.q.GetUserByEmail(ctx, email)
+	return r, dberr.Translate(err)
+}
+
+func (t *translatingQuerier) GetUserByID(ctx context.Context, id int64) (sqlc.User, error) {
+	r, err := t.q.GetUserByID(ctx, id)
+	return r, dberr.Translate(err)
+}
+
+func (t *translatingQuerier) GetUserPermissions(ctx context.Context, id int64) ([]sqlc.GetUserPermissionsRow, error) {
+	r, err := t.q.GetUserPermissions(ctx, id)
+	return r, dberr.Translate(err)
+}
+
+func (t *translatingQuerier) IncrementRateLimit(ctx context.Context, arg sqlc.IncrementRateLimitParams) (sqlc.IncrementRateLimitRow, error) {
+	r, err := t.q.IncrementRateLimit(ctx, arg)
+	return r, dberr.Translate(err)
+}
+
+func (t *translatingQuerier) IsValidSession(ctx context.Context, arg sqlc.IsValidSessionParams) (int64, error) {
+	r, err := t.q.IsValidSession(ctx, arg)
+	return r, dberr.Translate(err)
+}
+
+func (t *translatingQuerier) ListActiveSessions(ctx context.Context, userID int64) ([]sqlc.ListActiveSessionsRow, error) {
+	r, err := t.q.ListActiveSessions(ctx, userID)
+	return r, dberr.Translate(err)
+}
+
+func (t *translatingQuerier) ListAuditEvents(ctx context.Context, arg sqlc.ListAuditEventsParams) ([]sqlc.AuditEvent, error) {
+	r, err := t.q.ListAuditEvents(ctx, arg)
+	return r, dberr.Translate(err)
+}
+
+func (t *translatingQuerier) ListLoginLockouts(ctx context.Context, arg sqlc.ListLoginLockoutsParams) ([]sqlc.LoginFailure, error) {
+	r, err := t.q.ListLoginLockouts(ctx, arg)
+	return r, dberr.Translate(err)
+}
+
+func (t *translatingQuerier) ListRolePermissions(ctx context.Context) ([]sqlc.RolePermission, error) {
+	r, err := t.q.ListRolePermissions(ctx)
+	return r, dberr.Translate(err)
+}
+
+func (t *translatingQuerier) ListRoles(ctx context.Context) ([]sqlc.ListRolesRow, error) {
+	r, err := t.q.ListRoles(ctx)
+	return r, dberr.Translate(err)
+}
+
+func (t *translatingQuerier) ListUsers(ctx context.Context, arg sqlc.ListUsersParams) ([]sqlc.ListUsersRow, error) {
+	r, err := t.q.ListUsers(ctx, arg)
+	return r, dberr.Translate(err)
+}
+
+func (t *translatingQuerier) LockLogin(ctx context.Context, arg sqlc.LockLoginParams) error {
+	return dberr.Translate(t.q.LockLogin(ctx, arg))
+}
+
+func (t *translatingQuerier) MarkEmailVerified(ctx context.Context, id int64) error {
+	return dberr.Translate(t.q.MarkEmailVerified(ctx, id))
+}
+
+func (t *translatingQuerier) MarkSessionRotated(ctx context.Context, id int64) (int64, error) {
+	r, err := t.q.MarkSessionRotated(ctx, id)
+	return r, dberr.Translate(err)
+}
+
+func (t *translatingQuerier) PurgeAuditEvents(ctx context.Context, arg sqlc.PurgeAuditEventsParams) (int64, error) {
+	r, err := t.q.PurgeAuditEvents(ctx, arg)
+	return r, dberr.Translate(err)
+}
+
+func (t *translatingQuerier) PurgeExpiredEmailVerifications(ctx context.Context, arg sqlc.PurgeExpiredEmailVerificationsParams) (int64, error) {
+	r, err := t.q.PurgeExpiredEmailVerifications(ctx, arg)
+	return r, dberr.Translate(err)
+}
+
+func (t *translatingQuerier) PurgeExpiredPasswordResets(ctx context.Context, arg sqlc.PurgeExpiredPasswordResetsParams) (int64, error) {
+	r, err := t.q.PurgeExpiredPasswordResets(ctx, arg)
+	return r, dberr.Translate(err)
+}
+
+func (t *translatingQuerier) PurgeExpiredRateLimits(ctx context.Context, arg sqlc.PurgeExpiredRateLimitsParams) (int64, error) {
+	r, err := t.q.PurgeExpiredRateLimits(ctx, arg)
+	return r, dberr.Translate(err)
+}
+
+func (t *translatingQuerier) PurgeExpiredSessions(ctx context.Context, arg sqlc.PurgeExpiredSessionsParams) (int64, error) {
+	r, err := t.q.PurgeExpiredSessions(ctx, arg)
+	return r, dberr.Translate(err)
+}
+
+func (t *translatingQuerier) PurgeStaleLoginFailures(ctx context.Context, arg sqlc.PurgeStaleLoginFailuresParams) (int64, error) {
+	r, err := t.q.PurgeStaleLoginFailures(ctx, arg)
+	return r, dberr.Translate(err)
+}
+
+func (t *translatingQuerier) RecordLoginFailure(ctx context.Context, arg sqlc.RecordLoginFailureParams) (int64, error) {
+	r, err := t.q.RecordLoginFailure(ctx, arg)
+	return r, dberr.Translate(err)
+}
+
+func (t *translatingQuerier) RoleExists(ctx context.Context, name string) (int64, error) {
+	r, err := t.q.RoleExists(ctx, name)
+	return r, dberr.Translate(err)
+}
+
+func (t *translatingQuerier) SetTOTPSecret(ctx context.Context, arg sqlc.SetTOTPSecretParams) (int64, error) {
+	r, err := t.q.SetTOTPSecret(ctx, arg)
+	return r, dberr.Translate(err)
+}
+
+func (t *translatingQuerier) SetUserDisabled(ctx context.Context, arg sqlc.SetUserDisabledParams) (int64, error) {
+	r, err := t.q.SetUserDisabled(ctx, arg)
+	return r, dberr.Translate(err)
+}
+
+func (t *translatingQuerier) SetUserRole(ctx context.Context, arg sqlc.SetUserRoleParams) (int64, error) {
+	r, err := t.q.SetUserRole(ctx, arg)
+	return r, dberr.Translate(err)
+}
+
+func (t *translatingQuerier) TouchSession(ctx context.Context, arg sqlc.TouchSessionParams) error {
+	return dberr.Translate(t.q.TouchSession(ctx, arg))
+}
+
+func (t *translatingQuerier) UpdatePasswordHash(ctx context.Context, arg sqlc.UpdatePasswordHashParams) error {
+	return dberr.Translate(t.q.UpdatePasswordHash(ctx, arg))
+}
+
+func (t *translatingQuerier) UpdateUserEmail(ctx context.Context, arg sqlc.UpdateUserEmailParams) (int64, error) {
+	r, err := t.q.UpdateUserEmail(ctx, arg)
+	return r, dberr.Translate(err)
+}
+
+func (t *translatingQuerier) UseRecoveryCode(ctx context.Context, arg sqlc.UseRecoveryCodeParams) (int64, error) {
+	r, err := t.q.UseRecoveryCode(ctx, arg)
+	return r, dberr.Translate(err)
+}
+
+func (t *translatingQuerier) UseTOTPStep(ctx context.Context, arg sqlc.UseTOTPStepParams) (int64, error) {
+	r, err := t.q.UseTOTPStep(ctx, arg)
+	return r, dberr.Translate(err)
+}
@@ -24,47 +24,209 @@ func New(db DBTX) *Queries {
 func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	q := Queries{db: db}
 	var err error
+	if q.consumeEmailVerificationStmt, err = db.PrepareContext(ctx, consumeEmailVerification); err != nil {
+		return nil, fmt.Errorf("error preparing query ConsumeEmailVerification: %w", err)
+	}
+	if q.consumePasswordResetStmt, err = db.PrepareContext(ctx, consumePasswordReset); err != nil {
+		return nil, fmt.Errorf("error preparing query ConsumePasswordReset: %w", err)
+	}
+	if q.createAuditEventStmt, err = db.PrepareContext(ctx, createAuditEvent); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateAuditEvent: %w", err)
+	}
+	if q.createEmailVerificationStmt, err = db.PrepareContext(ctx, createEmailVerification); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateEmailVerification: %w", err)
+	}
+	if q.createPasswordResetStmt, err = db.PrepareContext(ctx, createPasswordReset); err != nil {
+		return nil, fmt.Errorf("error preparing query CreatePasswordReset: %w", err)
+	}
+	if q.createRecoveryCodeStmt, err = db.PrepareContext(ctx, createRecoveryCode); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateRecoveryCode: %w", err)
+	}
 	if q.createSessionStmt, err = db.PrepareContext(ctx, createSession); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateSession: %w", err)
 	}
 	if q.createUserStmt, err = db.PrepareContext(ctx, createUser); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateUser: %w", err)
 	}
+	if q.deleteLoginFailureStmt, err = db.PrepareContext(ctx, deleteLoginFailure); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteLoginFailure: %w", err)
+	}
+	if q.deleteOtherSessionsStmt, err = db.PrepareContext(ctx, deleteOtherSessions); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteOtherSessions: %w", err)
+	}
+	if q.deleteRecoveryCodesStmt, err = db.PrepareContext(ctx, deleteRecoveryCodes); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteRecoveryCodes: %w", err)
+	}
 	if q.deleteSessionByRefreshTokenStmt, err = db.PrepareContext(ctx, deleteSessionByRefreshToken); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteSessionByRefreshToken: %w", err)
 	}
 	if q.deleteSessionByTokenStmt, err = db.PrepareContext(ctx, deleteSessionByToken); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteSessionByToken: %w", err)
 	}
+	if q.deleteSessionFamilyStmt, err = db.PrepareContext(ctx, deleteSessionFamily); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteSessionFamily: %w", err)
+	}
+	if q.deleteSessionsByUserStmt, err = db.PrepareContext(ctx, deleteSessionsByUser); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteSessionsByUser: %w", err)
+	}
+	if q.deleteUnusedEmailVerificationsStmt, err = db.PrepareContext(ctx, deleteUnusedEmailVerifications); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteUnusedEmailVerifications: %w", err)
+	}
+	if q.deleteUnusedPasswordResetsStmt, err = db.PrepareContext(ctx, deleteUnusedPasswordResets); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteUnusedPasswordResets: %w", err)
+	}
 	if q.deleteUserStmt, err = db.PrepareContext(ctx, deleteUser); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteUser: %w", err)
 	}
+	if q.deleteUserSessionFamilyStmt, err = db.PrepareContext(ctx, deleteUserSessionFamily); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteUserSessionFamily: %w", err)
+	}
+	if q.disableTOTPStmt, err = db.PrepareContext(ctx, disableTOTP); err != nil {
+		return nil, fmt.Errorf("error preparing query DisableTOTP: %w", err)
+	}
+	if q.enableTOTPStmt, err = db.PrepareContext(ctx, enableTOTP); err != nil {
+		return nil, fmt.Errorf("error preparing query EnableTOTP: %w", err)
+	}
+	if q.getLoginFailureStmt, err = db.PrepareContext(ctx, getLoginFailure); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLoginFailure: %w", err)
+	}
 	if q.getRoleStmt, err = db.PrepareContext(ctx, getRole); err != nil {
 		return nil, fmt.Errorf("error preparing query GetRole: %w", err)
 	}
+	if q.getRolePermissionsStmt, err = db.PrepareContext(ctx, getRolePermissions); err != nil {
+		return nil, fmt.Errorf("error preparing query GetRolePermissions: %w", err)
+	}
 	if q.getSessionByRefreshTokenStmt, err = db.PrepareContext(ctx, getSessionByRefreshToken); err != nil {
 		return nil, fmt.Errorf("error preparing query GetSessionByRefreshToken: %w", err)
 	}
+	if q.getTOTPStmt, err = db.PrepareContext(ctx, getTOTP); err != nil {
+		return nil, fmt.Errorf("error preparing query GetTOTP: %w", err)
+	}
 	if q.getUserByEmailStmt, err = db.PrepareContext(ctx, getUserByEmail); err != nil {
 		return nil, fmt.Errorf("error preparing query GetUserByEmail: %w", err)
 	}
 	if q.getUserByIDStmt, err = db.PrepareContext(ctx, getUserByID); err != nil {
 		return nil, fmt.Errorf("error preparing query GetUserByID: %w", err)
 	}
+	if q.getUserPermissionsStmt, err = db.PrepareContext(ctx, getUserPermissions); err != nil {
+		return nil, fmt.Errorf("error preparing query GetUserPermissions: %w", err)
+	}
+	if q.incrementRateLimitStmt, err = db.PrepareContext(ctx, incrementRateLimit); err != nil {
+		return nil, fmt.Errorf("error preparing query IncrementRateLimit: %w", err)
+	}
 	if q.isValidSessionStmt, err = db.PrepareContext(ctx, isValidSession); err != nil {
 		return nil, fmt.Errorf("error preparing query IsValidSession: %w", err)
 	}
+	if q.listActiveSessionsStmt, err = db.PrepareContext(ctx, listActiveSessions); err != nil {
+		return nil, fmt.Errorf("error preparing query ListActiveSessions: %w", err)
+	}
+	if q.listAuditEventsStmt, err = db.PrepareContext(ctx, listAuditEvents); err != nil {
+		return nil, fmt.Errorf("error preparing query ListAuditEvents: %w", err)
+	}
+	if q.listLoginLockoutsStmt, err = db.PrepareContext(ctx, listLoginLockouts); err != nil {
+		return nil, fmt.Errorf("error preparing query ListLoginLockouts: %w", err)
+	}
+	if q.listRolePermissionsStmt, err = db.PrepareContext(ctx, listRolePermissions); err != nil {
+		return nil, fmt.Errorf("error preparing query ListRolePermissions: %w", err)
+	}
+	if q.listRolesStmt, err = db.PrepareContext(ctx, listRoles); err != nil {
+		return nil, fmt.Errorf("error preparing query ListRoles: %w", err)
+	}
 	if q.listUsersStmt, err = db.PrepareContext(ctx, listUsers); err != nil {
 		return nil, fmt.Errorf("error preparing query ListUsers: %w", err)
 	}
+	if q.lockLoginStmt, err = db.PrepareContext(ctx, lockLogin); err != nil {
+		return nil, fmt.Errorf("error preparing query LockLogin: %w", err)
+	}
+	if q.markEmailVerifiedStmt, err = db.PrepareContext(ctx, markEmailVerified); err != nil {
+		return nil, fmt.Errorf("error preparing query MarkEmailVerified: %w", err)
+	}
+	if q.markSessionRotatedStmt, err = db.PrepareContext(ctx, markSessionRotated); err != nil {
+		return nil, fmt.Errorf("error preparing query MarkSessionRotated: %w", err)
+	}
+	if q.purgeAuditEventsStmt, err = db.PrepareContext(ctx, purgeAuditEvents); err != nil {
+		return nil, fmt.Errorf("error preparing query PurgeAuditEvents: %w", err)
+	}
+	if q.purgeExpiredEmailVerificationsStmt, err = db.PrepareContext(ctx, purgeExpiredEmailVerifications); err != nil {
+		return nil, fmt.Errorf("error preparing query PurgeExpiredEmailVerifications: %w", err)
+	}
+	if q.purgeExpiredPasswordResetsStmt, err = db.PrepareContext(ctx, purgeExpiredPasswordResets); err != nil {
+		return nil, fmt.Errorf("error preparing query PurgeExpiredPasswordResets: %w", err)
+	}
+	if q.purgeExpiredRateLimitsStmt, err = db.PrepareContext(ctx, purgeExpiredRateLimits); err != nil {
+		return nil, fmt.Errorf("error preparing query PurgeExpiredRateLimits: %w", err)
+	}
+	if q.purgeExpiredSessionsStmt, err = db.PrepareContext(ctx, purgeExpiredSessions); err != nil {
+		return nil, fmt.Errorf("error preparing query PurgeExpiredSessions: %w", err)
+	}
+	if q.purgeStaleLoginFailuresStmt, err = db.PrepareContext(ctx, purgeStaleLoginFailures); err != nil {
+		return nil, fmt.Errorf("error preparing query PurgeStaleLoginFailures: %w", err)
+	}
+	if q.recordLoginFailureStmt, err = db.PrepareContext(ctx, recordLoginFailure); err != nil {
+		return nil, fmt.Errorf("error preparing query RecordLoginFailure: %w", err)
+	}
+	if q.roleExistsStmt, err = db.PrepareContext(ctx, roleExists); err != nil {
+		return nil, fmt.Errorf("error preparing query RoleExists: %w", err)
+	}
+	if q.setTOTPSecretStmt, err = db.PrepareContext(ctx, setTOTPSecret); err != nil {
+		return nil, fmt.Errorf("error preparing query SetTOTPSecret: %w", err)
+	}
+	if q.setUserDisabledStmt, err = db.PrepareContext(ctx, setUserDisabled); err != nil {
+		return nil, fmt.Errorf("error preparing query SetUserDisabled: %w", err)
+	}
+	if q.setUserRoleStmt, err = db.PrepareContext(ctx, setUserRole); err != nil {
+		return nil, fmt.Errorf("error preparing query SetUserRole: %w", err)
+	}
+	if q.touchSessionStmt, err = db.PrepareContext(ctx, touchSession); err != nil {
+		return nil, fmt.Errorf("error preparing query TouchSession: %w", err)
+	}
 	if q.updatePasswordHashStmt, err = db.PrepareContext(ctx, updatePasswordHash); err != nil {
 		return nil, fmt.Errorf("error preparing query UpdatePasswordHash: %w", err)
 	}
+	if q.updateUserEmailStmt, err = db.PrepareContext(ctx, updateUserEmail); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateUserEmail: %w", err)
+	}
+	if q.useRecoveryCodeStmt, err = db.PrepareContext(ctx, useRecoveryCode); err != nil {
+		return nil, fmt.Errorf("error preparing query UseRecoveryCode: %w", err)
+	}
+	if q.useTOTPStepStmt, err = db.PrepareContext(ctx, useTOTPStep); err != nil {
+		return nil, fmt.Errorf("error preparing query UseTOTPStep: %w", err)
+	}
 	return &q, nil
 }
 
 func (q *Queries) Close() error {
 	var err error
+	if q.consumeEmailVerificationStmt != nil {
+		if cerr := q.consumeEmailVerificationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing consumeEmailVerificationStmt: %w", cerr)
+		}
+	}
+	if q.consumePasswordResetStmt != nil {
+		if cerr := q.consumePasswordResetStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing consumePasswordResetStmt: %w", cerr)
+		}
+	}
+	if q.createAuditEventStmt != nil {
+		if cerr := q.createAuditEventStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createAuditEventStmt: %w", cerr)
+		}
+	}
+	if q.createEmailVerificationStmt != nil {
+		if cerr := q.createEmailVerificationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createEmailVerificationStmt: %w", cerr)
+		}
+	}
+	if q.createPasswordResetStmt != nil {
+		if cerr := q.createPasswordResetStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createPasswordResetStmt: %w", cerr)
+		}
+	}
+	if q.createRecoveryCodeStmt != nil {
+		if cerr := q.createRecoveryCodeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createRecoveryCodeStmt: %w", cerr)
+		}
+	}
 	if q.createSessionStmt != nil {
 		if cerr := q.createSessionStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createSessionStmt: %w", cerr)
@@ -75,6 +237,21 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing createUserStmt: %w", cerr)
 		}
 	}
+	if q.deleteLoginFailureStmt != nil {
+		if cerr := q.deleteLoginFailureStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteLoginFailureStmt: %w", cerr)
+		}
+	}
+	if q.deleteOtherSessionsStmt != nil {
+		if cerr := q.deleteOtherSessionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteOtherSessionsStmt: %w", cerr)
+		}
+	}
+	if q.deleteRecoveryCodesStmt != nil {
+		if cerr := q.deleteRecoveryCodesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteRecoveryCodesStmt: %w", cerr)
+		}
+	}
 	if q.deleteSessionByRefreshTokenStmt != nil {
 		if cerr := q.deleteSessionByRefreshTokenStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing deleteSessionByRefreshTokenStmt: %w", cerr)
@@ -85,21 +262,71 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing deleteSessionByTokenStmt: %w", cerr)
 		}
 	}
+	if q.deleteSessionFamilyStmt != nil {
+		if cerr := q.deleteSessionFamilyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteSessionFamilyStmt: %w", cerr)
+		}
+	}
+	if q.deleteSessionsByUserStmt != nil {
+		if cerr := q.deleteSessionsByUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteSessionsByUserStmt: %w", cerr)
+		}
+	}
+	if q.deleteUnusedEmailVerificationsStmt != nil {
+		if cerr := q.deleteUnusedEmailVerificationsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteUnusedEmailVerificationsStmt: %w", cerr)
+		}
+	}
+	if q.deleteUnusedPasswordResetsStmt != nil {
+		if cerr := q.deleteUnusedPasswordResetsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteUnusedPasswordResetsStmt: %w", cerr)
+		}
+	}
 	if q.deleteUserStmt != nil {
 		if cerr := q.deleteUserStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing deleteUserStmt: %w", cerr)
 		}
 	}
+	if q.deleteUserSessionFamilyStmt != nil {
+		if cerr := q.deleteUserSessionFamilyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteUserSessionFamilyStmt: %w", cerr)
+		}
+	}
+	if q.disableTOTPStmt != nil {
+		if cerr := q.disableTOTPStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing disableTOTPStmt: %w", cerr)
+		}
+	}
+	if q.enableTOTPStmt != nil {
+		if cerr := q.enableTOTPStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing enableTOTPStmt: %w", cerr)
+		}
+	}
+	if q.getLoginFailureStmt != nil {
+		if cerr := q.getLoginFailureStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLoginFailureStmt: %w", cerr)
+		}
+	}
 	if q.getRoleStmt != nil {
 		if cerr := q.getRoleStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getRoleStmt: %w", cerr)
 		}
 	}
+	if q.getRolePermissionsStmt != nil {
+		if cerr := q.getRolePermissionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getRolePermissionsStmt: %w", cerr)
+		}
+	}
 	if q.getSessionByRefreshTokenStmt != nil {
 		if cerr := q.getSessionByRefreshTokenStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getSessionByRefreshTokenStmt: %w", cerr)
 		}
 	}
+	if q.getTOTPStmt != nil {
+		if cerr := q.getTOTPStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getTOTPStmt: %w", cerr)
+		}
+	}
 	if q.getUserByEmailStmt != nil {
 		if cerr := q.getUserByEmailStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getUserByEmailStmt: %w", cerr)
@@ -110,21 +337,146 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getUserByIDStmt: %w", cerr)
 		}
 	}
+	if q.getUserPermissionsStmt != nil {
+		if cerr := q.getUserPermissionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getUserPermissionsStmt: %w", cerr)
+		}
+	}
+	if q.incrementRateLimitStmt != nil {
+		if cerr := q.incrementRateLimitStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing incrementRateLimitStmt: %w", cerr)
+		}
+	}
 	if q.isValidSessionStmt != nil {
 		if cerr := q.isValidSessionStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing isValidSessionStmt: %w", cerr)
 		}
 	}
+	if q.listActiveSessionsStmt != nil {
+		if cerr := q.listActiveSessionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listActiveSessionsStmt: %w", cerr)
+		}
+	}
+	if q.listAuditEventsStmt != nil {
+		if cerr := q.listAuditEventsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listAuditEventsStmt: %w", cerr)
+		}
+	}
+	if q.listLoginLockoutsStmt != nil {
+		if cerr := q.listLoginLockoutsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listLoginLockoutsStmt: %w", cerr)
+		}
+	}
+	if q.listRolePermissionsStmt != nil {
+		if cerr := q.listRolePermissionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listRolePermissionsStmt: %w", cerr)
+		}
+	}
+	if q.listRolesStmt != nil {
+		if cerr := q.listRolesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listRolesStmt: %w", cerr)
+		}
+	}
 	if q.listUsersStmt != nil {
 		if cerr := q.listUsersStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing listUsersStmt: %w", cerr)
 		}
 	}
+	if q.lockLoginStmt != nil {
+		if cerr := q.lockLoginStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing lockLoginStmt: %w", cerr)
+		}
+	}
+	if q.markEmailVerifiedStmt != nil {
+		if cerr := q.markEmailVerifiedStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing markEmailVerifiedStmt: %w", cerr)
+		}
+	}
+	if q.markSessionRotatedStmt != nil {
+		if cerr := q.markSessionRotatedStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing markSessionRotatedStmt: %w", cerr)
+		}
+	}
+	if q.purgeAuditEventsStmt != nil {
+		if cerr := q.purgeAuditEventsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing purgeAuditEventsStmt: %w", cerr)
+		}
+	}
+	if q.purgeExpiredEmailVerificationsStmt != nil {
+		if cerr := q.purgeExpiredEmailVerificationsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing purgeExpiredEmailVerificationsStmt: %w", cerr)
+		}
+	}
+	if q.purgeExpiredPasswordResetsStmt != nil {
+		if cerr := q.purgeExpiredPasswordResetsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing purgeExpiredPasswordResetsStmt: %w", cerr)
+		}
+	}
+	if q.purgeExpiredRateLimitsStmt != nil {
+		if cerr := q.purgeExpiredRateLimitsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing purgeExpiredRateLimitsStmt: %w", cerr)
+		}
+	}
+	if q.purgeExpiredSessionsStmt != nil {
+		if cerr := q.purgeExpiredSessionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing purgeExpiredSessionsStmt: %w", cerr)
+		}
+	}
+	if q.purgeStaleLoginFailuresStmt != nil {
+		if cerr := q.purgeStaleLoginFailuresStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing purgeStaleLoginFailuresStmt: %w", cerr)
+		}
+	}
+	if q.recordLoginFailureStmt != nil {
+		if cerr := q.recordLoginFailureStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing recordLoginFailureStmt: %w", cerr)
+		}
+	}
+	if q.roleExistsStmt != nil {
+		if cerr := q.roleExistsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing roleExistsStmt: %w", cerr)
+		}
+	}
+	if q.setTOTPSecretStmt != nil {
+		if cerr := q.setTOTPSecretStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setTOTPSecretStmt: %w", cerr)
+		}
+	}
+	if q.setUserDisabledStmt != nil {
+		if cerr := q.setUserDisabledStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setUserDisabledStmt: %w", cerr)
+		}
+	}
+	if q.setUserRoleStmt != nil {
+		if cerr := q.setUserRoleStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setUserRoleStmt: %w", cerr)
+		}
+	}
+	if q.touchSessionStmt != nil {
+		if cerr := q.touchSessionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing touchSessionStmt: %w", cerr)
+		}
+	}
 	if q.updatePasswordHashStmt != nil {
 		if cerr := q.updatePasswordHashStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing updatePasswordHashStmt: %w", cerr)
 		}
 	}
+	if q.updateUserEmailStmt != nil {
+		if cerr := q.updateUserEmailStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateUserEmailStmt: %w", cerr)
+		}
+	}
+	if q.useRecoveryCodeStmt != nil {
+		if cerr := q.useRecoveryCodeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing useRecoveryCodeStmt: %w", cerr)
+		}
+	}
+	if q.useTOTPStepStmt != nil {
+		if cerr := q.useTOTPStepStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing useTOTPStepStmt: %w", cerr)
+		}
+	}
 	return err
 }
 
@@ -162,37 +514,125 @@ func (q *Queries) queryRow(ctx context.Context, stmt *sql.Stmt, query string, ar
 }
 
 type Queries struct {
-	db                              DBTX
-	tx                              *sql.Tx
-	createSessionStmt               *sql.Stmt
-	createUserStmt                  *sql.Stmt
-	deleteSessionByRefreshTokenStmt *sql.Stmt
-	deleteSessionByTokenStmt        *sql.Stmt
-	deleteUserStmt                  *sql.Stmt
-	getRoleStmt                     *sql.Stmt
-	getSessionByRefreshTokenStmt    *sql.Stmt
-	getUserByEmailStmt              *sql.Stmt
-	getUserByIDStmt                 *sql.Stmt
-	isValidSessionStmt              *sql.Stmt
-	listUsersStmt                   *sql.Stmt
-	updatePasswordHashStmt          *sql.Stmt
+	db                                 DBTX
+	tx                                 *sql.Tx
+	consumeEmailVerificationStmt       *sql.Stmt
+	consumePasswordResetStmt           *sql.Stmt
+	createAuditEventStmt               *sql.Stmt
+	createEmailVerificationStmt        *sql.Stmt
+	createPasswordResetStmt            *sql.Stmt
+	createRecoveryCodeStmt             *sql.Stmt
+	createSessionStmt                  *sql.Stmt
+	createUserStmt                     *sql.Stmt
+	deleteLoginFailureStmt             *sql.Stmt
+	deleteOtherSessionsStmt            *sql.Stmt
+	deleteRecoveryCodesStmt            *sql.Stmt
+	deleteSessionByRefreshTokenStmt    *sql.Stmt
+	deleteSessionByTokenStmt           *sql.Stmt
+	deleteSessionFamilyStmt            *sql.Stmt
+	deleteSessionsByUserStmt           *sql.Stmt
+	deleteUnusedEmailVerificationsStmt *sql.Stmt
+	deleteUnusedPasswordResetsStmt     *sql.Stmt
+	deleteUserStmt                     *sql.Stmt
+	deleteUserSessionFamilyStmt        *sql.Stmt
+	disableTOTPStmt                    *sql.Stmt
+	enableTOTPStmt                     *sql.Stmt
+	getLoginFailureStmt                *sql.Stmt
+	getRoleStmt                        *sql.Stmt
+	getRolePermissionsStmt             *sql.Stmt
+	getSessionByRefreshTokenStmt       *sql.Stmt
+	getTOTPStmt                        *sql.Stmt
+	getUserByEmailStmt                 *sql.Stmt
+	getUserByIDStmt                    *sql.Stmt
+	getUserPermissionsStmt             *sql.Stmt
+	incrementRateLimitStmt             *sql.Stmt
+	isValidSessionStmt                 *sql.Stmt
+	listActiveSessionsStmt             *sql.Stmt
+	listAuditEventsStmt                *sql.Stmt
+	listLoginLockoutsStmt              *sql.Stmt
+	listRolePermissionsStmt            *sql.Stmt
+	listRolesStmt                      *sql.Stmt
+	listUsersStmt                      *sql.Stmt
+	lockLoginStmt                      *sql.Stmt
+	markEmailVerifiedStmt              *sql.Stmt
+	markSessionRotatedStmt             *sql.Stmt
+	purgeAuditEventsStmt               *sql.Stmt
+	purgeExpiredEmailVerificationsStmt *sql.Stmt
+	purgeExpiredPasswordResetsStmt     *sql.Stmt
+	purgeExpiredRateLimitsStmt         *sql.Stmt
+	purgeExpiredSessionsStmt           *sql.Stmt
+	purgeStaleLoginFailuresStmt        *sql.Stmt
+	recordLoginFailureStmt             *sql.Stmt
+	roleExistsStmt                     *sql.Stmt
+	setTOTPSecretStmt                  *sql.Stmt
+	setUserDisabledStmt                *sql.Stmt
+	setUserRoleStmt                    *sql.Stmt
+	touchSessionStmt                   *sql.Stmt
+	updatePasswordHashStmt             *sql.Stmt
+	updateUserEmailStmt                *sql.Stmt
+	useRecoveryCodeStmt                *sql.Stmt
+	useTOTPStepStmt                    *sql.Stmt
 }
 
 func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 	return &Queries{
-		db:                              tx,
-		tx:                              tx,
-		createSessionStmt:               q.createSessionStmt,
-		createUserStmt:                  q.createUserStmt,
-		deleteSessionByRefreshTokenStmt: q.deleteSessionByRefreshTokenStmt,
-		deleteSessionByTokenStmt:        q.deleteSessionByTokenStmt,
-		deleteUserStmt:                  q.deleteUserStmt,
-		getRoleStmt:                     q.getRoleStmt,
-		getSessionByRefreshTokenStmt:    q.getSessionByRefreshTokenStmt,
-		getUserByEmailStmt:              q.getUserByEmailStmt,
-		getUserByIDStmt:                 q.getUserByIDStmt,
-		isValidSessionStmt:              q.isValidSessionStmt,
-		listUsersStmt:                   q.listUsersStmt,
-		updatePasswordHashStmt:          q.updatePasswordHashStmt,
+		db:                                 tx,
+		tx:                                 tx,
+		consumeEmailVerificationStmt:       q.consumeEmailVerificationStmt,
+		consumePasswordResetStmt:           q.consumePasswordResetStmt,
+		createAuditEventStmt:               q.createAuditEventStmt,
+		createEmailVerificationStmt:        q.createEmailVerificationStmt,
+		createPasswordResetStmt:            q.createPasswordResetStmt,
+		createRecoveryCodeStmt:             q.createRecoveryCodeStmt,
+		createSessionStmt:                  q.createSessionStmt,
+		createUserStmt:                     q.createUserStmt,
+		deleteLoginFailureStmt:             q.deleteLoginFailureStmt,
+		deleteOtherSessionsStmt:            q.deleteOtherSessionsStmt,
+		deleteRecoveryCodesStmt:            q.deleteRecoveryCodesStmt,
+		deleteSessionByRefreshTokenStmt:    q.deleteSessionByRefreshTokenStmt,
+		deleteSessionByTokenStmt:           q.deleteSessionByTokenStmt,
+		deleteSessionFamilyStmt:            q.deleteSessionFamilyStmt,
+		deleteSessionsByUserStmt:           q.deleteSessionsByUserStmt,
+		deleteUnusedEmailVerificationsStmt: q.deleteUnusedEmailVerificationsStmt,
+		deleteUnusedPasswordResetsStmt:     q.deleteUnusedPasswordResetsStmt,
+		deleteUserStmt:                     q.deleteUserStmt,
+		deleteUserSessionFamilyStmt:        q.deleteUserSessionFamilyStmt,
+		disableTOTPStmt:                    q.disableTOTPStmt,
+		enableTOTPStmt:                     q.enableTOTPStmt,
+		getLoginFailureStmt:                q.getLoginFailureStmt,
+		getRoleStmt:                        q.getRoleStmt,
+		getRolePermissionsStmt:             q.getRolePermissionsStmt,
+		getSessionByRefreshTokenStmt:       q.getSessionByRefreshTokenStmt,
+		getTOTPStmt:                        q.getTOTPStmt,
+		getUserByEmailStmt:                 q.getUserByEmailStmt,
+		getUserByIDStmt:                    q.getUserByIDStmt,
+		getUserPermissionsStmt:             q.getUserPermissionsStmt,
+		incrementRateLimitStmt:             q.incrementRateLimitStmt,
+		isValidSessionStmt:                 q.isValidSessionStmt,
+		listActiveSessionsStmt:             q.listActiveSessionsStmt,
+		listAuditEventsStmt:                q.listAuditEventsStmt,
+		listLoginLockoutsStmt:              q.listLoginLockoutsStmt,
+		listRolePermissionsStmt:            q.listRolePermissionsStmt,
+		listRolesStmt:                      q.listRolesStmt,
+		listUsersStmt:                      q.listUsersStmt,
+		lockLoginStmt:                      q.lockLoginStmt,
+		markEmailVerifiedStmt:              q.markEmailVerifiedStmt,
+		markSessionRotatedStmt:             q.markSessionRotatedStmt,
+		purgeAuditEventsStmt:               q.purgeAuditEventsStmt,
+		purgeExpiredEmailVerificationsStmt: q.purgeExpiredEmailVerificationsStmt,
+		purgeExpiredPasswordResetsStmt:     q.purgeExpiredPasswordResetsStmt,
+		purgeExpiredRateLimitsStmt:         q.purgeExpiredRateLimitsStmt,
+		purgeExpiredSessionsStmt:           q.purgeExpiredSessionsStmt,
+		purgeStaleLoginFailuresStmt:        q.purgeStaleLoginFailuresStmt,
+		recordLoginFailureStmt:             q.recordLoginFailureStmt,
+		roleExistsStmt:                     q.roleExistsStmt,
+		setTOTPSecretStmt:                  q.setTOTPSecretStmt,
+		setUserDisabledStmt:                q.setUserDisabledStmt,
+		setUserRoleStmt:                    q.setUserRoleStmt,
+		touchSessionStmt:                   q.touchSessionStmt,
+		updatePasswordHashStmt:             q.updatePasswordHashStmt,
+		updateUserEmailStmt:                q.updateUserEmailStmt,
+		useRecoveryCodeStmt:                q.useRecoveryCodeStmt,
+		useTOTPStepStmt:                    q.useTOTPStepStmt,
 	}
 }
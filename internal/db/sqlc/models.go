@@ -5,23 +5,104 @@
 package sqlc
 
 import (
+	"database/sql"
 	"time"
 )
 
+type AuditEvent struct {
+	ID        int64         `json:"id"`
+	CreatedAt time.Time     `json:"created_at"`
+	ActorID   sql.NullInt64 `json:"actor_id"`
+	TargetID  sql.NullInt64 `json:"target_id"`
+	Action    string        `json:"action"`
+	Ip        string        `json:"ip"`
+	UserAgent string        `json:"user_agent"`
+	Metadata  string        `json:"metadata"`
+}
+
+type EmailVerification struct {
+	ID        int64        `json:"id"`
+	UserID    int64        `json:"user_id"`
+	TokenHash string       `json:"token_hash"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	UsedAt    sql.NullTime `json:"used_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type LoginFailure struct {
+	ID            int64        `json:"id"`
+	Scope         string       `json:"scope"`
+	Subject       string       `json:"subject"`
+	Failures      int64        `json:"failures"`
+	LastFailureAt time.Time    `json:"last_failure_at"`
+	LockedUntil   sql.NullTime `json:"locked_until"`
+}
+
+type PasswordReset struct {
+	ID        int64        `json:"id"`
+	UserID    int64        `json:"user_id"`
+	TokenHash string       `json:"token_hash"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	UsedAt    sql.NullTime `json:"used_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type Permission struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type RateLimit struct {
+	LimiterKey string    `json:"limiter_key"`
+	Bucket     int64     `json:"bucket"`
+	Hits       int64     `json:"hits"`
+	PrevHits   int64     `json:"prev_hits"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+type RecoveryCode struct {
+	ID        int64        `json:"id"`
+	UserID    int64        `json:"user_id"`
+	CodeHash  string       `json:"code_hash"`
+	UsedAt    sql.NullTime `json:"used_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type Role struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type RolePermission struct {
+	Role       string `json:"role"`
+	Permission string `json:"permission"`
+}
+
 type Session struct {
-	ID               int64     `json:"id"`
-	UserID           int64     `json:"user_id"`
-	Token            string    `json:"token"`
-	ExpiresAt        time.Time `json:"expires_at"`
-	RefreshToken     string    `json:"refresh_token"`
-	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
-	CreatedAt        time.Time `json:"created_at"`
+	ID               int64        `json:"id"`
+	UserID           int64        `json:"user_id"`
+	TokenHash        string       `json:"token_hash"`
+	ExpiresAt        time.Time    `json:"expires_at"`
+	RefreshTokenHash string       `json:"refresh_token_hash"`
+	RefreshExpiresAt time.Time    `json:"refresh_expires_at"`
+	CreatedAt        time.Time    `json:"created_at"`
+	FamilyID         string       `json:"family_id"`
+	RotatedAt        sql.NullTime `json:"rotated_at"`
+	UserAgent        string       `json:"user_agent"`
+	Ip               string       `json:"ip"`
+	LastUsedAt       sql.NullTime `json:"last_used_at"`
 }
 
 type User struct {
-	ID           int64     `json:"id"`
-	Email        string    `json:"email"`
-	PasswordHash string    `json:"password_hash"`
-	Role         string    `json:"role"`
-	CreatedAt    time.Time `json:"created_at"`
+	ID              int64          `json:"id"`
+	Email           string         `json:"email"`
+	PasswordHash    string         `json:"password_hash"`
+	Role            string         `json:"role"`
+	CreatedAt       time.Time      `json:"created_at"`
+	EmailVerifiedAt sql.NullTime   `json:"email_verified_at"`
+	TotpSecret      sql.NullString `json:"totp_secret"`
+	TotpEnabledAt   sql.NullTime   `json:"totp_enabled_at"`
+	TotpLastStep    int64          `json:"totp_last_step"`
+	DisabledAt      sql.NullTime   `json:"disabled_at"`
 }
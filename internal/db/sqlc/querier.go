@@ -9,6 +9,29 @@ import (
 )
 
 type Querier interface {
+	// Use up a token, matches at most once and only before it expires ---------------
+	ConsumeEmailVerification(ctx context.Context, tokenHash string) (int64, error)
+	// Use up a token, matches at most once and only before it expires ---------------
+	ConsumePasswordReset(ctx context.Context, tokenHash string) (int64, error)
+	// audit/query.sql
+	// ------------------------------------------------------------
+	// Audit log for sqlc (SQLite engine)
+	// Rows are only ever inserted, and deleted once past retention
+	// ------------------------------------------------------------
+	CreateAuditEvent(ctx context.Context, arg CreateAuditEventParams) error
+	// emailverification/query.sql
+	// ------------------------------------------------------------
+	// Email verification tokens for sqlc (SQLite engine)
+	// Only hashes of the emailed tokens are stored
+	// ------------------------------------------------------------
+	CreateEmailVerification(ctx context.Context, arg CreateEmailVerificationParams) error
+	// passwordreset/query.sql
+	// ------------------------------------------------------------
+	// Password reset tokens for sqlc (SQLite engine)
+	// Only hashes of the emailed tokens are stored
+	// ------------------------------------------------------------
+	CreatePasswordReset(ctx context.Context, arg CreatePasswordResetParams) error
+	CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) error
 	CreateSession(ctx context.Context, arg CreateSessionParams) error
 	// user/query.sql
 	// ------------------------------------------------------------
@@ -17,22 +40,99 @@ type Querier interface {
 	// ------------------------------------------------------------
 	// Create a new user and return the generated row --------------------------------
 	CreateUser(ctx context.Context, arg CreateUserParams) (CreateUserRow, error)
-	DeleteSessionByRefreshToken(ctx context.Context, refreshToken string) error
-	DeleteSessionByToken(ctx context.Context, token string) error
+	DeleteLoginFailure(ctx context.Context, arg DeleteLoginFailureParams) (int64, error)
+	// Sign a user out everywhere except the given login -------------------------------
+	DeleteOtherSessions(ctx context.Context, arg DeleteOtherSessionsParams) error
+	DeleteRecoveryCodes(ctx context.Context, userID int64) error
+	DeleteSessionByRefreshToken(ctx context.Context, refreshTokenHash string) error
+	DeleteSessionByToken(ctx context.Context, tokenHash string) error
+	DeleteSessionFamily(ctx context.Context, familyID string) error
+	DeleteSessionsByUser(ctx context.Context, userID int64) error
+	// Drop the tokens of a user that were never used ---------------------------------
+	DeleteUnusedEmailVerifications(ctx context.Context, userID int64) error
+	// Drop the tokens of a user that were never used ---------------------------------
+	DeleteUnusedPasswordResets(ctx context.Context, userID int64) error
 	// Delete a user -----------------------------------------------------------------
-	DeleteUser(ctx context.Context, id int64) error
+	DeleteUser(ctx context.Context, id int64) (int64, error)
+	DeleteUserSessionFamily(ctx context.Context, arg DeleteUserSessionFamilyParams) (int64, error)
+	DisableTOTP(ctx context.Context, id int64) error
+	EnableTOTP(ctx context.Context, arg EnableTOTPParams) (int64, error)
+	// loginfailure/query.sql
+	// ------------------------------------------------------------
+	// Failed login tracking for sqlc (SQLite engine)
+	// ------------------------------------------------------------
+	GetLoginFailure(ctx context.Context, arg GetLoginFailureParams) (LoginFailure, error)
 	// Get user role -----------------------------------------------------------------
 	GetRole(ctx context.Context, id int64) (string, error)
-	GetSessionByRefreshToken(ctx context.Context, refreshToken string) (Session, error)
+	GetRolePermissions(ctx context.Context, role string) ([]string, error)
+	// Rotated sessions are returned too, the caller must check rotated_at ------------
+	GetSessionByRefreshToken(ctx context.Context, refreshTokenHash string) (Session, error)
+	// twofactor/query.sql
+	// ------------------------------------------------------------
+	// TOTP settings (columns of users) and recovery codes for sqlc (SQLite engine)
+	// ------------------------------------------------------------
+	GetTOTP(ctx context.Context, id int64) (GetTOTPRow, error)
 	// Fetch a user by unique email ---------------------------------------------------
 	GetUserByEmail(ctx context.Context, email string) (GetUserByEmailRow, error)
 	// Fetch a user by primary key ----------------------------------------------------
 	GetUserByID(ctx context.Context, id int64) (User, error)
+	// The user's role and one row per permission, permission is NULL for a role
+	// without any ----------------------------------------------------------------
+	GetUserPermissions(ctx context.Context, id int64) ([]GetUserPermissionsRow, error)
+	// ratelimit/query.sql
+	// ------------------------------------------------------------
+	// Rate limit counters for sqlc (SQLite engine)
+	// ------------------------------------------------------------
+	// Count a hit, moving the counters along when a new bucket started ----------
+	IncrementRateLimit(ctx context.Context, arg IncrementRateLimitParams) (IncrementRateLimitRow, error)
 	IsValidSession(ctx context.Context, arg IsValidSessionParams) (int64, error)
+	// The live session of every login of a user, rotated ones left out ---------------
+	ListActiveSessions(ctx context.Context, userID int64) ([]ListActiveSessionsRow, error)
+	// Newest first, every filter is optional, before_id is the pagination cursor ------
+	ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]AuditEvent, error)
+	ListLoginLockouts(ctx context.Context, arg ListLoginLockoutsParams) ([]LoginFailure, error)
+	ListRolePermissions(ctx context.Context) ([]RolePermission, error)
+	// role/query.sql
+	// ------------------------------------------------------------
+	// Roles, their permissions and role assignment for sqlc (SQLite engine)
+	// ------------------------------------------------------------
+	ListRoles(ctx context.Context) ([]ListRolesRow, error)
 	// List active users (simple pagination) -----------------------------------------
 	ListUsers(ctx context.Context, arg ListUsersParams) ([]ListUsersRow, error)
+	LockLogin(ctx context.Context, arg LockLoginParams) error
+	// Mark the email address as verified, keeps the first verification time ---------
+	MarkEmailVerified(ctx context.Context, id int64) error
+	// Affects no rows when a concurrent refresh already rotated the session ---------
+	MarkSessionRotated(ctx context.Context, id int64) (int64, error)
+	// Drop events past retention ------------------------------------------------------
+	PurgeAuditEvents(ctx context.Context, arg PurgeAuditEventsParams) (int64, error)
+	// Drop expired verification tokens, used or not ----------------------------------
+	PurgeExpiredEmailVerifications(ctx context.Context, arg PurgeExpiredEmailVerificationsParams) (int64, error)
+	// Drop expired reset tokens, used or not -----------------------------------------
+	PurgeExpiredPasswordResets(ctx context.Context, arg PurgeExpiredPasswordResetsParams) (int64, error)
+	// Drop counters of clients that went quiet ---------------------------------------
+	PurgeExpiredRateLimits(ctx context.Context, arg PurgeExpiredRateLimitsParams) (int64, error)
+	// Drop sessions whose refresh token expired, rotated ones included ---------------
+	PurgeExpiredSessions(ctx context.Context, arg PurgeExpiredSessionsParams) (int64, error)
+	// Drop failure counts that would start over anyway -------------------------------
+	PurgeStaleLoginFailures(ctx context.Context, arg PurgeStaleLoginFailuresParams) (int64, error)
+	// Count a failure, starting over when the last one is older than reset_before -
+	RecordLoginFailure(ctx context.Context, arg RecordLoginFailureParams) (int64, error)
+	RoleExists(ctx context.Context, name string) (int64, error)
+	// Store a fresh secret, only while 2FA is not enabled ----------------------------
+	SetTOTPSecret(ctx context.Context, arg SetTOTPSecretParams) (int64, error)
+	// NULL enables the account again ------------------------------------------------
+	SetUserDisabled(ctx context.Context, arg SetUserDisabledParams) (int64, error)
+	SetUserRole(ctx context.Context, arg SetUserRoleParams) (int64, error)
+	// Record a use of the access token, at most once per interval -------------------
+	TouchSession(ctx context.Context, arg TouchSessionParams) error
 	// Update only the password hash --------------------------------------------------
 	UpdatePasswordHash(ctx context.Context, arg UpdatePasswordHashParams) error
+	// A new address has to be verified again ---------------------------------------
+	UpdateUserEmail(ctx context.Context, arg UpdateUserEmailParams) (int64, error)
+	UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (int64, error)
+	// Accept a time step only when it is newer than the last one used ---------------
+	UseTOTPStep(ctx context.Context, arg UseTOTPStepParams) (int64, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -7,29 +7,160 @@ package sqlc
 
 import (
 	"context"
+	"database/sql"
 	"time"
 )
 
+const consumeEmailVerification = `-- name: ConsumeEmailVerification :one
+UPDATE email_verifications
+SET    used_at = CURRENT_TIMESTAMP
+WHERE  token_hash = ? AND used_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+RETURNING user_id
+`
+
+// Use up a token, matches at most once and only before it expires ---------------
+func (q *Queries) ConsumeEmailVerification(ctx context.Context, tokenHash string) (int64, error) {
+	row := q.queryRow(ctx, q.consumeEmailVerificationStmt, consumeEmailVerification, tokenHash)
+	var user_id int64
+	err := row.Scan(&user_id)
+	return user_id, err
+}
+
+const consumePasswordReset = `-- name: ConsumePasswordReset :one
+UPDATE password_resets
+SET    used_at = CURRENT_TIMESTAMP
+WHERE  token_hash = ? AND used_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+RETURNING user_id
+`
+
+// Use up a token, matches at most once and only before it expires ---------------
+func (q *Queries) ConsumePasswordReset(ctx context.Context, tokenHash string) (int64, error) {
+	row := q.queryRow(ctx, q.consumePasswordResetStmt, consumePasswordReset, tokenHash)
+	var user_id int64
+	err := row.Scan(&user_id)
+	return user_id, err
+}
+
+const createAuditEvent = `-- name: CreateAuditEvent :exec
+
+INSERT INTO audit_events (created_at, actor_id, target_id, action, ip, user_agent, metadata)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+`
+
+type CreateAuditEventParams struct {
+	CreatedAt time.Time     `json:"created_at"`
+	ActorID   sql.NullInt64 `json:"actor_id"`
+	TargetID  sql.NullInt64 `json:"target_id"`
+	Action    string        `json:"action"`
+	Ip        string        `json:"ip"`
+	UserAgent string        `json:"user_agent"`
+	Metadata  string        `json:"metadata"`
+}
+
+// audit/query.sql
+// ------------------------------------------------------------
+// Audit log for sqlc (SQLite engine)
+// Rows are only ever inserted, and deleted once past retention
+// ------------------------------------------------------------
+func (q *Queries) CreateAuditEvent(ctx context.Context, arg CreateAuditEventParams) error {
+	_, err := q.exec(ctx, q.createAuditEventStmt, createAuditEvent,
+		arg.CreatedAt,
+		arg.ActorID,
+		arg.TargetID,
+		arg.Action,
+		arg.Ip,
+		arg.UserAgent,
+		arg.Metadata,
+	)
+	return err
+}
+
+const createEmailVerification = `-- name: CreateEmailVerification :exec
+
+INSERT INTO email_verifications (user_id, token_hash, expires_at)
+VALUES (?, ?, ?)
+`
+
+type CreateEmailVerificationParams struct {
+	UserID    int64     `json:"user_id"`
+	TokenHash string    `json:"token_hash"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// emailverification/query.sql
+// ------------------------------------------------------------
+// Email verification tokens for sqlc (SQLite engine)
+// Only hashes of the emailed tokens are stored
+// ------------------------------------------------------------
+func (q *Queries) CreateEmailVerification(ctx context.Context, arg CreateEmailVerificationParams) error {
+	_, err := q.exec(ctx, q.createEmailVerificationStmt, createEmailVerification, arg.UserID, arg.TokenHash, arg.ExpiresAt)
+	return err
+}
+
+const createPasswordReset = `-- name: CreatePasswordReset :exec
+
+INSERT INTO password_resets (user_id, token_hash, expires_at)
+VALUES (?, ?, ?)
+`
+
+type CreatePasswordResetParams struct {
+	UserID    int64     `json:"user_id"`
+	TokenHash string    `json:"token_hash"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// passwordreset/query.sql
+// ------------------------------------------------------------
+// Password reset tokens for sqlc (SQLite engine)
+// Only hashes of the emailed tokens are stored
+// ------------------------------------------------------------
+func (q *Queries) CreatePasswordReset(ctx context.Context, arg CreatePasswordResetParams) error {
+	_, err := q.exec(ctx, q.createPasswordResetStmt, createPasswordReset, arg.UserID, arg.TokenHash, arg.ExpiresAt)
+	return err
+}
+
+const createRecoveryCode = `-- name: CreateRecoveryCode :exec
+INSERT INTO recovery_codes (user_id, code_hash)
+VALUES (?, ?)
+`
+
+type CreateRecoveryCodeParams struct {
+	UserID   int64  `json:"user_id"`
+	CodeHash string `json:"code_hash"`
+}
+
+func (q *Queries) CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) error {
+	_, err := q.exec(ctx, q.createRecoveryCodeStmt, createRecoveryCode, arg.UserID, arg.CodeHash)
+	return err
+}
+
 const createSession = `-- name: CreateSession :exec
-INSERT INTO sessions (user_id, token, expires_at, refresh_token, refresh_expires_at)
-VALUES (?, ?, ?, ?, ?)
+INSERT INTO sessions (user_id, token_hash, expires_at, refresh_token_hash, refresh_expires_at, family_id,
+                      user_agent, ip, last_used_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
 `
 
 type CreateSessionParams struct {
 	UserID           int64     `json:"user_id"`
-	Token            string    `json:"token"`
+	TokenHash        string    `json:"token_hash"`
 	ExpiresAt        time.Time `json:"expires_at"`
-	RefreshToken     string    `json:"refresh_token"`
+	RefreshTokenHash string    `json:"refresh_token_hash"`
 	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+	FamilyID         string    `json:"family_id"`
+	UserAgent        string    `json:"user_agent"`
+	Ip               string    `json:"ip"`
 }
 
 func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) error {
 	_, err := q.exec(ctx, q.createSessionStmt, createSession,
 		arg.UserID,
-		arg.Token,
+		arg.TokenHash,
 		arg.ExpiresAt,
-		arg.RefreshToken,
+		arg.RefreshTokenHash,
 		arg.RefreshExpiresAt,
+		arg.FamilyID,
+		arg.UserAgent,
+		arg.Ip,
 	)
 	return err
 }
@@ -65,37 +196,203 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (CreateU
 	return i, err
 }
 
+const deleteLoginFailure = `-- name: DeleteLoginFailure :execrows
+DELETE FROM login_failures
+WHERE scope = ? AND subject = ?
+`
+
+type DeleteLoginFailureParams struct {
+	Scope   string `json:"scope"`
+	Subject string `json:"subject"`
+}
+
+func (q *Queries) DeleteLoginFailure(ctx context.Context, arg DeleteLoginFailureParams) (int64, error) {
+	result, err := q.exec(ctx, q.deleteLoginFailureStmt, deleteLoginFailure, arg.Scope, arg.Subject)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteOtherSessions = `-- name: DeleteOtherSessions :exec
+DELETE FROM sessions
+WHERE user_id = ? AND family_id <> ?
+`
+
+type DeleteOtherSessionsParams struct {
+	UserID   int64  `json:"user_id"`
+	FamilyID string `json:"family_id"`
+}
+
+// Sign a user out everywhere except the given login -------------------------------
+func (q *Queries) DeleteOtherSessions(ctx context.Context, arg DeleteOtherSessionsParams) error {
+	_, err := q.exec(ctx, q.deleteOtherSessionsStmt, deleteOtherSessions, arg.UserID, arg.FamilyID)
+	return err
+}
+
+const deleteRecoveryCodes = `-- name: DeleteRecoveryCodes :exec
+DELETE FROM recovery_codes
+WHERE user_id = ?
+`
+
+func (q *Queries) DeleteRecoveryCodes(ctx context.Context, userID int64) error {
+	_, err := q.exec(ctx, q.deleteRecoveryCodesStmt, deleteRecoveryCodes, userID)
+	return err
+}
+
 const deleteSessionByRefreshToken = `-- name: DeleteSessionByRefreshToken :exec
 DELETE FROM sessions
-WHERE refresh_token = ?
+WHERE refresh_token_hash = ?
 `
 
-func (q *Queries) DeleteSessionByRefreshToken(ctx context.Context, refreshToken string) error {
-	_, err := q.exec(ctx, q.deleteSessionByRefreshTokenStmt, deleteSessionByRefreshToken, refreshToken)
+func (q *Queries) DeleteSessionByRefreshToken(ctx context.Context, refreshTokenHash string) error {
+	_, err := q.exec(ctx, q.deleteSessionByRefreshTokenStmt, deleteSessionByRefreshToken, refreshTokenHash)
 	return err
 }
 
 const deleteSessionByToken = `-- name: DeleteSessionByToken :exec
 DELETE FROM sessions
-WHERE token = ?
+WHERE token_hash = ?
+`
+
+func (q *Queries) DeleteSessionByToken(ctx context.Context, tokenHash string) error {
+	_, err := q.exec(ctx, q.deleteSessionByTokenStmt, deleteSessionByToken, tokenHash)
+	return err
+}
+
+const deleteSessionFamily = `-- name: DeleteSessionFamily :exec
+DELETE FROM sessions
+WHERE family_id = ?
+`
+
+func (q *Queries) DeleteSessionFamily(ctx context.Context, familyID string) error {
+	_, err := q.exec(ctx, q.deleteSessionFamilyStmt, deleteSessionFamily, familyID)
+	return err
+}
+
+const deleteSessionsByUser = `-- name: DeleteSessionsByUser :exec
+DELETE FROM sessions
+WHERE user_id = ?
+`
+
+func (q *Queries) DeleteSessionsByUser(ctx context.Context, userID int64) error {
+	_, err := q.exec(ctx, q.deleteSessionsByUserStmt, deleteSessionsByUser, userID)
+	return err
+}
+
+const deleteUnusedEmailVerifications = `-- name: DeleteUnusedEmailVerifications :exec
+DELETE FROM email_verifications
+WHERE user_id = ? AND used_at IS NULL
+`
+
+// Drop the tokens of a user that were never used ---------------------------------
+func (q *Queries) DeleteUnusedEmailVerifications(ctx context.Context, userID int64) error {
+	_, err := q.exec(ctx, q.deleteUnusedEmailVerificationsStmt, deleteUnusedEmailVerifications, userID)
+	return err
+}
+
+const deleteUnusedPasswordResets = `-- name: DeleteUnusedPasswordResets :exec
+DELETE FROM password_resets
+WHERE user_id = ? AND used_at IS NULL
 `
 
-func (q *Queries) DeleteSessionByToken(ctx context.Context, token string) error {
-	_, err := q.exec(ctx, q.deleteSessionByTokenStmt, deleteSessionByToken, token)
+// Drop the tokens of a user that were never used ---------------------------------
+func (q *Queries) DeleteUnusedPasswordResets(ctx context.Context, userID int64) error {
+	_, err := q.exec(ctx, q.deleteUnusedPasswordResetsStmt, deleteUnusedPasswordResets, userID)
 	return err
 }
 
-const deleteUser = `-- name: DeleteUser :exec
+const deleteUser = `-- name: DeleteUser :execrows
 DELETE FROM users
 WHERE  id = ?
 `
 
 // Delete a user -----------------------------------------------------------------
-func (q *Queries) DeleteUser(ctx context.Context, id int64) error {
-	_, err := q.exec(ctx, q.deleteUserStmt, deleteUser, id)
+func (q *Queries) DeleteUser(ctx context.Context, id int64) (int64, error) {
+	result, err := q.exec(ctx, q.deleteUserStmt, deleteUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteUserSessionFamily = `-- name: DeleteUserSessionFamily :execrows
+DELETE FROM sessions
+WHERE user_id = ? AND family_id = ?
+`
+
+type DeleteUserSessionFamilyParams struct {
+	UserID   int64  `json:"user_id"`
+	FamilyID string `json:"family_id"`
+}
+
+func (q *Queries) DeleteUserSessionFamily(ctx context.Context, arg DeleteUserSessionFamilyParams) (int64, error) {
+	result, err := q.exec(ctx, q.deleteUserSessionFamilyStmt, deleteUserSessionFamily, arg.UserID, arg.FamilyID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const disableTOTP = `-- name: DisableTOTP :exec
+UPDATE users
+SET    totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = 0
+WHERE  id = ?
+`
+
+func (q *Queries) DisableTOTP(ctx context.Context, id int64) error {
+	_, err := q.exec(ctx, q.disableTOTPStmt, disableTOTP, id)
 	return err
 }
 
+const enableTOTP = `-- name: EnableTOTP :execrows
+UPDATE users
+SET    totp_enabled_at = CURRENT_TIMESTAMP, totp_last_step = ?
+WHERE  id = ? AND totp_secret IS NOT NULL AND totp_enabled_at IS NULL
+`
+
+type EnableTOTPParams struct {
+	TotpLastStep int64 `json:"totp_last_step"`
+	ID           int64 `json:"id"`
+}
+
+func (q *Queries) EnableTOTP(ctx context.Context, arg EnableTOTPParams) (int64, error) {
+	result, err := q.exec(ctx, q.enableTOTPStmt, enableTOTP, arg.TotpLastStep, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getLoginFailure = `-- name: GetLoginFailure :one
+
+SELECT id, scope, subject, failures, last_failure_at, locked_until FROM login_failures
+WHERE scope = ? AND subject = ?
+`
+
+type GetLoginFailureParams struct {
+	Scope   string `json:"scope"`
+	Subject string `json:"subject"`
+}
+
+// loginfailure/query.sql
+// ------------------------------------------------------------
+// Failed login tracking for sqlc (SQLite engine)
+// ------------------------------------------------------------
+func (q *Queries) GetLoginFailure(ctx context.Context, arg GetLoginFailureParams) (LoginFailure, error) {
+	row := q.queryRow(ctx, q.getLoginFailureStmt, getLoginFailure, arg.Scope, arg.Subject)
+	var i LoginFailure
+	err := row.Scan(
+		&i.ID,
+		&i.Scope,
+		&i.Subject,
+		&i.Failures,
+		&i.LastFailureAt,
+		&i.LockedUntil,
+	)
+	return i, err
+}
+
 const getRole = `-- name: GetRole :one
 SELECT role FROM users
 WHERE id = ?
@@ -109,37 +406,101 @@ func (q *Queries) GetRole(ctx context.Context, id int64) (string, error) {
 	return role, err
 }
 
+const getRolePermissions = `-- name: GetRolePermissions :many
+SELECT permission FROM role_permissions
+WHERE  role = ?
+ORDER  BY permission
+`
+
+func (q *Queries) GetRolePermissions(ctx context.Context, role string) ([]string, error) {
+	rows, err := q.query(ctx, q.getRolePermissionsStmt, getRolePermissions, role)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var permission string
+		if err := rows.Scan(&permission); err != nil {
+			return nil, err
+		}
+		items = append(items, permission)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getSessionByRefreshToken = `-- name: GetSessionByRefreshToken :one
-SELECT id, user_id, token, expires_at, refresh_token, refresh_expires_at, created_at FROM sessions
-WHERE refresh_token = ? AND refresh_expires_at > CURRENT_TIMESTAMP
+SELECT id, user_id, token_hash, expires_at, refresh_token_hash, refresh_expires_at, created_at, family_id, rotated_at, user_agent, ip, last_used_at FROM sessions
+WHERE refresh_token_hash = ? AND refresh_expires_at > CURRENT_TIMESTAMP
 `
 
-func (q *Queries) GetSessionByRefreshToken(ctx context.Context, refreshToken string) (Session, error) {
-	row := q.queryRow(ctx, q.getSessionByRefreshTokenStmt, getSessionByRefreshToken, refreshToken)
+// Rotated sessions are returned too, the caller must check rotated_at ------------
+func (q *Queries) GetSessionByRefreshToken(ctx context.Context, refreshTokenHash string) (Session, error) {
+	row := q.queryRow(ctx, q.getSessionByRefreshTokenStmt, getSessionByRefreshToken, refreshTokenHash)
 	var i Session
 	err := row.Scan(
 		&i.ID,
 		&i.UserID,
-		&i.Token,
+		&i.TokenHash,
 		&i.ExpiresAt,
-		&i.RefreshToken,
+		&i.RefreshTokenHash,
 		&i.RefreshExpiresAt,
 		&i.CreatedAt,
+		&i.FamilyID,
+		&i.RotatedAt,
+		&i.UserAgent,
+		&i.Ip,
+		&i.LastUsedAt,
 	)
 	return i, err
 }
 
+const getTOTP = `-- name: GetTOTP :one
+
+SELECT totp_secret, totp_enabled_at, totp_last_step
+FROM   users
+WHERE  id = ?
+`
+
+type GetTOTPRow struct {
+	TotpSecret    sql.NullString `json:"totp_secret"`
+	TotpEnabledAt sql.NullTime   `json:"totp_enabled_at"`
+	TotpLastStep  int64          `json:"totp_last_step"`
+}
+
+// twofactor/query.sql
+// ------------------------------------------------------------
+// TOTP settings (columns of users) and recovery codes for sqlc (SQLite engine)
+// ------------------------------------------------------------
+func (q *Queries) GetTOTP(ctx context.Context, id int64) (GetTOTPRow, error) {
+	row := q.queryRow(ctx, q.getTOTPStmt, getTOTP, id)
+	var i GetTOTPRow
+	err := row.Scan(&i.TotpSecret, &i.TotpEnabledAt, &i.TotpLastStep)
+	return i, err
+}
+
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, email, password_hash, created_at
+SELECT id, email, password_hash, role, created_at, email_verified_at, totp_enabled_at,
+       disabled_at
 FROM   users
 WHERE  email = ?
 `
 
 type GetUserByEmailRow struct {
-	ID           int64     `json:"id"`
-	Email        string    `json:"email"`
-	PasswordHash string    `json:"password_hash"`
-	CreatedAt    time.Time `json:"created_at"`
+	ID              int64        `json:"id"`
+	Email           string       `json:"email"`
+	PasswordHash    string       `json:"password_hash"`
+	Role            string       `json:"role"`
+	CreatedAt       time.Time    `json:"created_at"`
+	EmailVerifiedAt sql.NullTime `json:"email_verified_at"`
+	TotpEnabledAt   sql.NullTime `json:"totp_enabled_at"`
+	DisabledAt      sql.NullTime `json:"disabled_at"`
 }
 
 // Fetch a user by unique email ---------------------------------------------------
@@ -150,13 +511,18 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (GetUserByEm
 		&i.ID,
 		&i.Email,
 		&i.PasswordHash,
+		&i.Role,
 		&i.CreatedAt,
+		&i.EmailVerifiedAt,
+		&i.TotpEnabledAt,
+		&i.DisabledAt,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, email, password_hash, role, created_at
+SELECT id, email, password_hash, role, created_at, email_verified_at,
+       totp_secret, totp_enabled_at, totp_last_step, disabled_at
 FROM   users
 WHERE  id = ?
 `
@@ -171,29 +537,333 @@ func (q *Queries) GetUserByID(ctx context.Context, id int64) (User, error) {
 		&i.PasswordHash,
 		&i.Role,
 		&i.CreatedAt,
+		&i.EmailVerifiedAt,
+		&i.TotpSecret,
+		&i.TotpEnabledAt,
+		&i.TotpLastStep,
+		&i.DisabledAt,
 	)
 	return i, err
 }
 
+const getUserPermissions = `-- name: GetUserPermissions :many
+SELECT u.role, rp.permission
+FROM   users u
+LEFT   JOIN role_permissions rp ON rp.role = u.role
+WHERE  u.id = ?
+`
+
+type GetUserPermissionsRow struct {
+	Role       string         `json:"role"`
+	Permission sql.NullString `json:"permission"`
+}
+
+// The user's role and one row per permission, permission is NULL for a role
+// without any ----------------------------------------------------------------
+func (q *Queries) GetUserPermissions(ctx context.Context, id int64) ([]GetUserPermissionsRow, error) {
+	rows, err := q.query(ctx, q.getUserPermissionsStmt, getUserPermissions, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUserPermissionsRow
+	for rows.Next() {
+		var i GetUserPermissionsRow
+		if err := rows.Scan(&i.Role, &i.Permission); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const incrementRateLimit = `-- name: IncrementRateLimit :one
+
+INSERT INTO rate_limits (limiter_key, bucket, hits, prev_hits, expires_at)
+VALUES (?, ?, 1, 0, ?)
+ON CONFLICT (limiter_key) DO UPDATE
+SET    prev_hits = CASE WHEN rate_limits.bucket = excluded.bucket THEN rate_limits.prev_hits
+                        WHEN rate_limits.bucket = excluded.bucket - 1 THEN rate_limits.hits
+                        ELSE 0 END,
+       hits = CASE WHEN rate_limits.bucket = excluded.bucket THEN rate_limits.hits + 1
+                   ELSE 1 END,
+       bucket = excluded.bucket,
+       expires_at = excluded.expires_at
+RETURNING hits, prev_hits
+`
+
+type IncrementRateLimitParams struct {
+	LimiterKey string    `json:"limiter_key"`
+	Bucket     int64     `json:"bucket"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+type IncrementRateLimitRow struct {
+	Hits     int64 `json:"hits"`
+	PrevHits int64 `json:"prev_hits"`
+}
+
+// ratelimit/query.sql
+// ------------------------------------------------------------
+// Rate limit counters for sqlc (SQLite engine)
+// ------------------------------------------------------------
+// Count a hit, moving the counters along when a new bucket started ----------
+func (q *Queries) IncrementRateLimit(ctx context.Context, arg IncrementRateLimitParams) (IncrementRateLimitRow, error) {
+	row := q.queryRow(ctx, q.incrementRateLimitStmt, incrementRateLimit, arg.LimiterKey, arg.Bucket, arg.ExpiresAt)
+	var i IncrementRateLimitRow
+	err := row.Scan(&i.Hits, &i.PrevHits)
+	return i, err
+}
+
 const isValidSession = `-- name: IsValidSession :one
 SELECT COUNT(*) FROM sessions
-WHERE user_id = ? AND token = ? AND expires_at > CURRENT_TIMESTAMP
+WHERE user_id = ? AND token_hash = ? AND expires_at > CURRENT_TIMESTAMP AND rotated_at IS NULL
 `
 
 type IsValidSessionParams struct {
-	UserID int64  `json:"user_id"`
-	Token  string `json:"token"`
+	UserID    int64  `json:"user_id"`
+	TokenHash string `json:"token_hash"`
 }
 
 func (q *Queries) IsValidSession(ctx context.Context, arg IsValidSessionParams) (int64, error) {
-	row := q.queryRow(ctx, q.isValidSessionStmt, isValidSession, arg.UserID, arg.Token)
+	row := q.queryRow(ctx, q.isValidSessionStmt, isValidSession, arg.UserID, arg.TokenHash)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
 }
 
+const listActiveSessions = `-- name: ListActiveSessions :many
+SELECT family_id, user_agent, ip, last_used_at, refresh_expires_at
+FROM   sessions
+WHERE  user_id = ? AND rotated_at IS NULL AND refresh_expires_at > CURRENT_TIMESTAMP
+ORDER  BY last_used_at DESC
+`
+
+type ListActiveSessionsRow struct {
+	FamilyID         string       `json:"family_id"`
+	UserAgent        string       `json:"user_agent"`
+	Ip               string       `json:"ip"`
+	LastUsedAt       sql.NullTime `json:"last_used_at"`
+	RefreshExpiresAt time.Time    `json:"refresh_expires_at"`
+}
+
+// The live session of every login of a user, rotated ones left out ---------------
+func (q *Queries) ListActiveSessions(ctx context.Context, userID int64) ([]ListActiveSessionsRow, error) {
+	rows, err := q.query(ctx, q.listActiveSessionsStmt, listActiveSessions, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListActiveSessionsRow
+	for rows.Next() {
+		var i ListActiveSessionsRow
+		if err := rows.Scan(
+			&i.FamilyID,
+			&i.UserAgent,
+			&i.Ip,
+			&i.LastUsedAt,
+			&i.RefreshExpiresAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAuditEvents = `-- name: ListAuditEvents :many
+SELECT id, created_at, actor_id, target_id, action, ip, user_agent, metadata FROM audit_events
+WHERE  (? IS NULL OR actor_id = ?)
+  AND  (? IS NULL OR target_id = ?)
+  AND  (? IS NULL OR action = ?)
+  AND  (? IS NULL OR created_at >= ?)
+  AND  (? IS NULL OR created_at < ?)
+  AND  (? IS NULL OR id < ?)
+ORDER  BY id DESC
+LIMIT  ?
+`
+
+type ListAuditEventsParams struct {
+	ActorID  sql.NullInt64  `json:"actor_id"`
+	TargetID sql.NullInt64  `json:"target_id"`
+	Action   sql.NullString `json:"action"`
+	Since    sql.NullTime   `json:"since"`
+	Until    sql.NullTime   `json:"until"`
+	BeforeID sql.NullInt64  `json:"before_id"`
+	Limit    int64          `json:"limit"`
+}
+
+// Newest first, every filter is optional, before_id is the pagination cursor ------
+func (q *Queries) ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]AuditEvent, error) {
+	rows, err := q.query(ctx, q.listAuditEventsStmt, listAuditEvents,
+		arg.ActorID,
+		arg.ActorID,
+		arg.TargetID,
+		arg.TargetID,
+		arg.Action,
+		arg.Action,
+		arg.Since,
+		arg.Since,
+		arg.Until,
+		arg.Until,
+		arg.BeforeID,
+		arg.BeforeID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditEvent
+	for rows.Next() {
+		var i AuditEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.ActorID,
+			&i.TargetID,
+			&i.Action,
+			&i.Ip,
+			&i.UserAgent,
+			&i.Metadata,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLoginLockouts = `-- name: ListLoginLockouts :many
+SELECT id, scope, subject, failures, last_failure_at, locked_until FROM login_failures
+WHERE  locked_until > ?
+ORDER  BY locked_until DESC
+LIMIT  ? OFFSET ?
+`
+
+type ListLoginLockoutsParams struct {
+	LockedUntil sql.NullTime `json:"locked_until"`
+	Limit       int64        `json:"limit"`
+	Offset      int64        `json:"offset"`
+}
+
+func (q *Queries) ListLoginLockouts(ctx context.Context, arg ListLoginLockoutsParams) ([]LoginFailure, error) {
+	rows, err := q.query(ctx, q.listLoginLockoutsStmt, listLoginLockouts, arg.LockedUntil, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LoginFailure
+	for rows.Next() {
+		var i LoginFailure
+		if err := rows.Scan(
+			&i.ID,
+			&i.Scope,
+			&i.Subject,
+			&i.Failures,
+			&i.LastFailureAt,
+			&i.LockedUntil,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRolePermissions = `-- name: ListRolePermissions :many
+SELECT role, permission FROM role_permissions
+ORDER  BY role, permission
+`
+
+func (q *Queries) ListRolePermissions(ctx context.Context) ([]RolePermission, error) {
+	rows, err := q.query(ctx, q.listRolePermissionsStmt, listRolePermissions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RolePermission
+	for rows.Next() {
+		var i RolePermission
+		if err := rows.Scan(&i.Role, &i.Permission); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRoles = `-- name: ListRoles :many
+
+SELECT name, description
+FROM   roles
+ORDER  BY name
+`
+
+type ListRolesRow struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// role/query.sql
+// ------------------------------------------------------------
+// Roles, their permissions and role assignment for sqlc (SQLite engine)
+// ------------------------------------------------------------
+func (q *Queries) ListRoles(ctx context.Context) ([]ListRolesRow, error) {
+	rows, err := q.query(ctx, q.listRolesStmt, listRoles)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListRolesRow
+	for rows.Next() {
+		var i ListRolesRow
+		if err := rows.Scan(&i.Name, &i.Description); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listUsers = `-- name: ListUsers :many
-SELECT id, email, role, created_at
+SELECT id, email, role, created_at, email_verified_at, totp_enabled_at, disabled_at
 FROM   users
 ORDER  BY id
 LIMIT  ?  OFFSET ?
@@ -205,10 +875,13 @@ type ListUsersParams struct {
 }
 
 type ListUsersRow struct {
-	ID        int64     `json:"id"`
-	Email     string    `json:"email"`
-	Role      string    `json:"role"`
-	CreatedAt time.Time `json:"created_at"`
+	ID              int64        `json:"id"`
+	Email           string       `json:"email"`
+	Role            string       `json:"role"`
+	CreatedAt       time.Time    `json:"created_at"`
+	EmailVerifiedAt sql.NullTime `json:"email_verified_at"`
+	TotpEnabledAt   sql.NullTime `json:"totp_enabled_at"`
+	DisabledAt      sql.NullTime `json:"disabled_at"`
 }
 
 // List active users (simple pagination) -----------------------------------------
@@ -226,6 +899,9 @@ func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]ListUse
 			&i.Email,
 			&i.Role,
 			&i.CreatedAt,
+			&i.EmailVerifiedAt,
+			&i.TotpEnabledAt,
+			&i.DisabledAt,
 		); err != nil {
 			return nil, err
 		}
@@ -240,6 +916,283 @@ func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]ListUse
 	return items, nil
 }
 
+const lockLogin = `-- name: LockLogin :exec
+UPDATE login_failures
+SET    locked_until = ?
+WHERE  scope = ? AND subject = ?
+`
+
+type LockLoginParams struct {
+	LockedUntil sql.NullTime `json:"locked_until"`
+	Scope       string       `json:"scope"`
+	Subject     string       `json:"subject"`
+}
+
+func (q *Queries) LockLogin(ctx context.Context, arg LockLoginParams) error {
+	_, err := q.exec(ctx, q.lockLoginStmt, lockLogin, arg.LockedUntil, arg.Scope, arg.Subject)
+	return err
+}
+
+const markEmailVerified = `-- name: MarkEmailVerified :exec
+UPDATE users
+SET    email_verified_at = CURRENT_TIMESTAMP
+WHERE  id = ? AND email_verified_at IS NULL
+`
+
+// Mark the email address as verified, keeps the first verification time ---------
+func (q *Queries) MarkEmailVerified(ctx context.Context, id int64) error {
+	_, err := q.exec(ctx, q.markEmailVerifiedStmt, markEmailVerified, id)
+	return err
+}
+
+const markSessionRotated = `-- name: MarkSessionRotated :execrows
+UPDATE sessions
+SET    rotated_at = CURRENT_TIMESTAMP
+WHERE  id = ? AND rotated_at IS NULL
+`
+
+// Affects no rows when a concurrent refresh already rotated the session ---------
+func (q *Queries) MarkSessionRotated(ctx context.Context, id int64) (int64, error) {
+	result, err := q.exec(ctx, q.markSessionRotatedStmt, markSessionRotated, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const purgeAuditEvents = `-- name: PurgeAuditEvents :execrows
+DELETE FROM audit_events
+WHERE  id IN (SELECT id FROM audit_events WHERE created_at < ? LIMIT ?)
+`
+
+type PurgeAuditEventsParams struct {
+	Before    time.Time `json:"before"`
+	BatchSize int64     `json:"batch_size"`
+}
+
+// Drop events past retention ------------------------------------------------------
+func (q *Queries) PurgeAuditEvents(ctx context.Context, arg PurgeAuditEventsParams) (int64, error) {
+	result, err := q.exec(ctx, q.purgeAuditEventsStmt, purgeAuditEvents, arg.Before, arg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const purgeExpiredEmailVerifications = `-- name: PurgeExpiredEmailVerifications :execrows
+DELETE FROM email_verifications
+WHERE  id IN (SELECT id FROM email_verifications WHERE expires_at < ? LIMIT ?)
+`
+
+type PurgeExpiredEmailVerificationsParams struct {
+	Before    time.Time `json:"before"`
+	BatchSize int64     `json:"batch_size"`
+}
+
+// Drop expired verification tokens, used or not ----------------------------------
+func (q *Queries) PurgeExpiredEmailVerifications(ctx context.Context, arg PurgeExpiredEmailVerificationsParams) (int64, error) {
+	result, err := q.exec(ctx, q.purgeExpiredEmailVerificationsStmt, purgeExpiredEmailVerifications, arg.Before, arg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const purgeExpiredPasswordResets = `-- name: PurgeExpiredPasswordResets :execrows
+DELETE FROM password_resets
+WHERE  id IN (SELECT id FROM password_resets WHERE expires_at < ? LIMIT ?)
+`
+
+type PurgeExpiredPasswordResetsParams struct {
+	Before    time.Time `json:"before"`
+	BatchSize int64     `json:"batch_size"`
+}
+
+// Drop expired reset tokens, used or not -----------------------------------------
+func (q *Queries) PurgeExpiredPasswordResets(ctx context.Context, arg PurgeExpiredPasswordResetsParams) (int64, error) {
+	result, err := q.exec(ctx, q.purgeExpiredPasswordResetsStmt, purgeExpiredPasswordResets, arg.Before, arg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const purgeExpiredRateLimits = `-- name: PurgeExpiredRateLimits :execrows
+DELETE FROM rate_limits
+WHERE  limiter_key IN (SELECT limiter_key FROM rate_limits WHERE expires_at < ? LIMIT ?)
+`
+
+type PurgeExpiredRateLimitsParams struct {
+	Before    time.Time `json:"before"`
+	BatchSize int64     `json:"batch_size"`
+}
+
+// Drop counters of clients that went quiet ---------------------------------------
+func (q *Queries) PurgeExpiredRateLimits(ctx context.Context, arg PurgeExpiredRateLimitsParams) (int64, error) {
+	result, err := q.exec(ctx, q.purgeExpiredRateLimitsStmt, purgeExpiredRateLimits, arg.Before, arg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const purgeExpiredSessions = `-- name: PurgeExpiredSessions :execrows
+DELETE FROM sessions
+WHERE  id IN (SELECT id FROM sessions WHERE refresh_expires_at < ? LIMIT ?)
+`
+
+type PurgeExpiredSessionsParams struct {
+	Before    time.Time `json:"before"`
+	BatchSize int64     `json:"batch_size"`
+}
+
+// Drop sessions whose refresh token expired, rotated ones included ---------------
+func (q *Queries) PurgeExpiredSessions(ctx context.Context, arg PurgeExpiredSessionsParams) (int64, error) {
+	result, err := q.exec(ctx, q.purgeExpiredSessionsStmt, purgeExpiredSessions, arg.Before, arg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const purgeStaleLoginFailures = `-- name: PurgeStaleLoginFailures :execrows
+DELETE FROM login_failures
+WHERE  id IN (SELECT id FROM login_failures WHERE last_failure_at < ? LIMIT ?)
+`
+
+type PurgeStaleLoginFailuresParams struct {
+	Before    time.Time `json:"before"`
+	BatchSize int64     `json:"batch_size"`
+}
+
+// Drop failure counts that would start over anyway -------------------------------
+func (q *Queries) PurgeStaleLoginFailures(ctx context.Context, arg PurgeStaleLoginFailuresParams) (int64, error) {
+	result, err := q.exec(ctx, q.purgeStaleLoginFailuresStmt, purgeStaleLoginFailures, arg.Before, arg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const recordLoginFailure = `-- name: RecordLoginFailure :one
+INSERT INTO login_failures (scope, subject, failures, last_failure_at)
+VALUES (?, ?, 1, ?)
+ON CONFLICT (scope, subject) DO UPDATE
+SET    failures = CASE WHEN login_failures.last_failure_at < ? THEN 1
+                       ELSE login_failures.failures + 1 END,
+       last_failure_at = excluded.last_failure_at
+RETURNING failures
+`
+
+type RecordLoginFailureParams struct {
+	Scope       string    `json:"scope"`
+	Subject     string    `json:"subject"`
+	Now         time.Time `json:"now"`
+	ResetBefore time.Time `json:"reset_before"`
+}
+
+// Count a failure, starting over when the last one is older than reset_before -
+func (q *Queries) RecordLoginFailure(ctx context.Context, arg RecordLoginFailureParams) (int64, error) {
+	row := q.queryRow(ctx, q.recordLoginFailureStmt, recordLoginFailure,
+		arg.Scope,
+		arg.Subject,
+		arg.Now,
+		arg.ResetBefore,
+	)
+	var failures int64
+	err := row.Scan(&failures)
+	return failures, err
+}
+
+const roleExists = `-- name: RoleExists :one
+SELECT COUNT(*) FROM roles
+WHERE  name = ?
+`
+
+func (q *Queries) RoleExists(ctx context.Context, name string) (int64, error) {
+	row := q.queryRow(ctx, q.roleExistsStmt, roleExists, name)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const setTOTPSecret = `-- name: SetTOTPSecret :execrows
+UPDATE users
+SET    totp_secret = ?
+WHERE  id = ? AND totp_enabled_at IS NULL
+`
+
+type SetTOTPSecretParams struct {
+	TotpSecret sql.NullString `json:"totp_secret"`
+	ID         int64          `json:"id"`
+}
+
+// Store a fresh secret, only while 2FA is not enabled ----------------------------
+func (q *Queries) SetTOTPSecret(ctx context.Context, arg SetTOTPSecretParams) (int64, error) {
+	result, err := q.exec(ctx, q.setTOTPSecretStmt, setTOTPSecret, arg.TotpSecret, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setUserDisabled = `-- name: SetUserDisabled :execrows
+UPDATE users
+SET    disabled_at = ?
+WHERE  id = ?
+`
+
+type SetUserDisabledParams struct {
+	DisabledAt sql.NullTime `json:"disabled_at"`
+	ID         int64        `json:"id"`
+}
+
+// NULL enables the account again ------------------------------------------------
+func (q *Queries) SetUserDisabled(ctx context.Context, arg SetUserDisabledParams) (int64, error) {
+	result, err := q.exec(ctx, q.setUserDisabledStmt, setUserDisabled, arg.DisabledAt, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setUserRole = `-- name: SetUserRole :execrows
+UPDATE users
+SET    role = ?
+WHERE  id = ?
+`
+
+type SetUserRoleParams struct {
+	Role string `json:"role"`
+	ID   int64  `json:"id"`
+}
+
+func (q *Queries) SetUserRole(ctx context.Context, arg SetUserRoleParams) (int64, error) {
+	result, err := q.exec(ctx, q.setUserRoleStmt, setUserRole, arg.Role, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const touchSession = `-- name: TouchSession :exec
+UPDATE sessions
+SET    last_used_at = CURRENT_TIMESTAMP, ip = ?
+WHERE  token_hash = ? AND (last_used_at IS NULL OR last_used_at < ?)
+`
+
+type TouchSessionParams struct {
+	Ip         string       `json:"ip"`
+	TokenHash  string       `json:"token_hash"`
+	LastUsedAt sql.NullTime `json:"last_used_at"`
+}
+
+// Record a use of the access token, at most once per interval -------------------
+func (q *Queries) TouchSession(ctx context.Context, arg TouchSessionParams) error {
+	_, err := q.exec(ctx, q.touchSessionStmt, touchSession, arg.Ip, arg.TokenHash, arg.LastUsedAt)
+	return err
+}
+
 const updatePasswordHash = `-- name: UpdatePasswordHash :exec
 UPDATE users
 SET    password_hash = ?
@@ -256,3 +1209,62 @@ func (q *Queries) UpdatePasswordHash(ctx context.Context, arg UpdatePasswordHash
 	_, err := q.exec(ctx, q.updatePasswordHashStmt, updatePasswordHash, arg.PasswordHash, arg.ID)
 	return err
 }
+
+const updateUserEmail = `-- name: UpdateUserEmail :execrows
+UPDATE users
+SET    email = ?, email_verified_at = NULL
+WHERE  id = ?
+`
+
+type UpdateUserEmailParams struct {
+	Email string `json:"email"`
+	ID    int64  `json:"id"`
+}
+
+// A new address has to be verified again ---------------------------------------
+func (q *Queries) UpdateUserEmail(ctx context.Context, arg UpdateUserEmailParams) (int64, error) {
+	result, err := q.exec(ctx, q.updateUserEmailStmt, updateUserEmail, arg.Email, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const useRecoveryCode = `-- name: UseRecoveryCode :execrows
+UPDATE recovery_codes
+SET    used_at = CURRENT_TIMESTAMP
+WHERE  user_id = ? AND code_hash = ? AND used_at IS NULL
+`
+
+type UseRecoveryCodeParams struct {
+	UserID   int64  `json:"user_id"`
+	CodeHash string `json:"code_hash"`
+}
+
+func (q *Queries) UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (int64, error) {
+	result, err := q.exec(ctx, q.useRecoveryCodeStmt, useRecoveryCode, arg.UserID, arg.CodeHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const useTOTPStep = `-- name: UseTOTPStep :execrows
+UPDATE users
+SET    totp_last_step = ?
+WHERE  id = ? AND totp_last_step < ?
+`
+
+type UseTOTPStepParams struct {
+	Step int64 `json:"step"`
+	ID   int64 `json:"id"`
+}
+
+// Accept a time step only when it is newer than the last one used ---------------
+func (q *Queries) UseTOTPStep(ctx context.Context, arg UseTOTPStepParams) (int64, error) {
+	result, err := q.exec(ctx, q.useTOTPStepStmt, useTOTPStep, arg.Step, arg.ID, arg.Step)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
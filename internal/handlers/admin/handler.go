@@ -0,0 +1,128 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/bercivarga/go-basic-server/internal/app"
+	"github.com/bercivarga/go-basic-server/internal/apperr"
+	"github.com/bercivarga/go-basic-server/internal/audit"
+	"github.com/bercivarga/go-basic-server/internal/metrics"
+	"github.com/bercivarga/go-basic-server/internal/middleware"
+	"github.com/bercivarga/go-basic-server/internal/router"
+	authService "github.com/bercivarga/go-basic-server/internal/services/auth"
+	"github.com/bercivarga/go-basic-server/internal/utils"
+)
+
+type Handler struct {
+	app *app.App
+}
+
+func New(a *app.App) *Handler {
+	return &Handler{app: a}
+}
+
+func (h *Handler) Register(r *router.Router) {
+	withPermission := func(permission string) func(router.HandleFuncWithApp) router.HandleFuncWithApp {
+		return router.ComposeMiddleware(middleware.Auth, middleware.RequirePermission(permission))
+	}
+
+	r.HandleFunc(http.MethodGet, "/admin/jobs", withPermission(authService.PermJobsRead)(h.jobs))
+	r.HandleFunc(http.MethodGet, "/admin/roles", withPermission(authService.PermRolesRead)(h.roles))
+	r.HandleFunc(http.MethodGet, "/admin/audit", withPermission(authService.PermAuditRead)(h.audit))
+	r.HandleFunc(http.MethodGet, "/admin/metrics", withPermission(authService.PermMetricsRead)(h.metrics))
+}
+
+// jobs reports the last run of every background job.
+func (h *Handler) jobs(a *app.App, w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(a.Jobs.Status())
+}
+
+// metrics reports the server's counters, such as recovered panics.
+func (h *Handler) metrics(a *app.App, w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(metrics.Snapshot())
+}
+
+// roles lists the roles users can be given and their permissions.
+func (h *Handler) roles(a *app.App, w http.ResponseWriter, r *http.Request) {
+	roles, err := a.AuthService.ListRoles(r.Context())
+	if err != nil {
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(roles)
+}
+
+// audit pages through the audit log, newest first. Filters are the query
+// parameters actor_id, target_id, action, since and until (RFC 3339);
+// next_cursor of a response is passed back as cursor for the next page.
+func (h *Handler) audit(a *app.App, w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter, err := auditFilter(q)
+	if err != nil {
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	page, err := a.Audit.List(r.Context(), filter, q.Get("cursor"))
+	if err != nil {
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(page)
+}
+
+func auditFilter(q url.Values) (audit.Filter, error) {
+	f := audit.Filter{Action: q.Get("action")}
+
+	ints := []struct {
+		name string
+		dst  *int64
+	}{
+		{"actor_id", &f.ActorID},
+		{"target_id", &f.TargetID},
+		{"limit", &f.Limit},
+	}
+	for _, p := range ints {
+		v := q.Get(p.name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 1 {
+			return f, invalidFilter("%s must be a positive integer", p.name)
+		}
+		*p.dst = n
+	}
+
+	times := []struct {
+		name string
+		dst  *time.Time
+	}{
+		{"since", &f.Since},
+		{"until", &f.Until},
+	}
+	for _, p := range times {
+		v := q.Get(p.name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return f, invalidFilter("%s must be an RFC 3339 time", p.name)
+		}
+		*p.dst = t
+	}
+
+	return f, nil
+}
+
+func invalidFilter(format string, args ...any) error {
+	return apperr.New(apperr.ErrInvalid, "invalid_filter", fmt.Sprintf(format, args...))
+}
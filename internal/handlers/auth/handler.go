@@ -2,11 +2,17 @@ package auth
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/bercivarga/go-basic-server/internal/app"
+	"github.com/bercivarga/go-basic-server/internal/apperr"
 	"github.com/bercivarga/go-basic-server/internal/middleware"
 	"github.com/bercivarga/go-basic-server/internal/router"
+	authService "github.com/bercivarga/go-basic-server/internal/services/auth"
 	"github.com/bercivarga/go-basic-server/internal/services/user"
 	"github.com/bercivarga/go-basic-server/internal/utils"
 )
@@ -21,12 +27,29 @@ func New(a *app.App) *Handler {
 
 func (h *Handler) Register(r *router.Router) {
 	withAuthMiddleware := router.ComposeMiddleware(middleware.Auth)
+	withPermission := func(permission string) func(router.HandleFuncWithApp) router.HandleFuncWithApp {
+		return router.ComposeMiddleware(middleware.Auth, middleware.RequirePermission(permission))
+	}
+	// endpoints that guess credentials or send email get a tighter limit
+	withAuthRateLimit := router.ComposeMiddleware(
+		middleware.RateLimitRoute(h.app.AuthRateLimiter, middleware.ByIP),
+	)
 
-	r.HandleFunc(http.MethodPost, "/auth/signup", h.signup)
-	r.HandleFunc(http.MethodPost, "/auth/login", h.login)
+	r.HandleFunc(http.MethodPost, "/auth/signup", withAuthRateLimit(h.signup))
+	r.HandleFunc(http.MethodPost, "/auth/login", withAuthRateLimit(h.login))
+	r.HandleFunc(http.MethodPost, "/auth/login/2fa", withAuthRateLimit(h.loginMFA))
 	r.HandleFunc(http.MethodPost, "/auth/refresh", h.refresh)
+	r.HandleFunc(http.MethodGet, "/auth/verify", h.verifyEmail)
+	r.HandleFunc(http.MethodPost, "/auth/verify/resend", withAuthRateLimit(h.resendVerification))
+	r.HandleFunc(http.MethodPost, "/auth/password/forgot", withAuthRateLimit(h.forgotPassword))
+	r.HandleFunc(http.MethodPost, "/auth/password/reset", withAuthRateLimit(h.resetPassword))
+	r.HandleFunc(http.MethodGet, "/.well-known/jwks.json", h.jwks)
 
 	r.HandleFunc(http.MethodPost, "/auth/logout", withAuthMiddleware(h.logout))
+	r.HandleFunc(http.MethodPost, "/auth/logout-all", withAuthMiddleware(h.logoutAll))
+
+	r.HandleFunc(http.MethodGet, "/admin/lockouts", withPermission(authService.PermLockoutsManage)(h.listLockouts))
+	r.HandleFunc(http.MethodDelete, "/admin/lockouts/{scope}/{subject}", withPermission(authService.PermLockoutsManage)(h.clearLockout))
 }
 
 type SignupRequest struct {
@@ -37,7 +60,7 @@ type SignupRequest struct {
 func (h *Handler) signup(a *app.App, w http.ResponseWriter, r *http.Request) {
 	var creds SignupRequest
 	if err := utils.BindAndValidate(r, &creds); err != nil {
-		utils.RespondWithValidationErrors(w, err)
+		utils.RespondError(w, r, err)
 		return
 	}
 
@@ -46,10 +69,15 @@ func (h *Handler) signup(a *app.App, w http.ResponseWriter, r *http.Request) {
 		Password: creds.Password,
 	})
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		utils.RespondError(w, r, err)
 		return
 	}
 
+	// the account exists either way, a failed email can be resent
+	if err := a.AuthService.SendVerificationEmail(r.Context(), creds.Email); err != nil {
+		a.Logger.ErrorContext(r.Context(), "sending verification email failed", "error", err)
+	}
+
 	w.WriteHeader(http.StatusCreated)
 }
 
@@ -61,13 +89,58 @@ type LoginRequest struct {
 func (h *Handler) login(a *app.App, w http.ResponseWriter, r *http.Request) {
 	var creds LoginRequest
 	if err := utils.BindAndValidate(r, &creds); err != nil {
-		utils.RespondWithValidationErrors(w, err)
+		utils.RespondError(w, r, err)
 		return
 	}
 
-	tokens, err := a.AuthService.Login(r.Context(), creds.Email, creds.Password)
+	result, err := a.AuthService.Login(r.Context(), creds.Email, creds.Password, clientFrom(r))
+	if respondLocked(w, r, err) {
+		return
+	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	// either the token pair or {"mfa_required": true, "mfa_token": ...}
+	json.NewEncoder(w).Encode(result)
+}
+
+// clientFrom describes the caller for the session it starts or refreshes.
+func clientFrom(r *http.Request) authService.Client {
+	return authService.Client{UserAgent: r.UserAgent(), IP: utils.ClientIP(r)}
+}
+
+// respondLocked answers 429 with a Retry-After when err is a lockout.
+func respondLocked(w http.ResponseWriter, r *http.Request, err error) bool {
+	var locked *authService.LockedOutError
+	if !errors.As(err, &locked) {
+		return false
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+	utils.RespondError(w, r, err)
+	return true
+}
+
+type LoginMFARequest struct {
+	MFAToken string `json:"mfa_token" validate:"required,jwt"`
+	Code     string `json:"code" validate:"required"`
+}
+
+// loginMFA is the second login step for accounts with 2FA enabled.
+func (h *Handler) loginMFA(a *app.App, w http.ResponseWriter, r *http.Request) {
+	var body LoginMFARequest
+	if err := utils.BindAndValidate(r, &body); err != nil {
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	tokens, err := a.AuthService.LoginMFA(r.Context(), body.MFAToken, body.Code, clientFrom(r))
+	if respondLocked(w, r, err) {
+		return
+	}
+	if err != nil {
+		utils.RespondError(w, r, err)
 		return
 	}
 
@@ -85,13 +158,29 @@ func (h *Handler) logout(a *app.App, w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := utils.Validate(validationData); err != nil {
-		utils.RespondWithValidationErrors(w, err)
+		utils.RespondError(w, r, err)
 		return
 	}
 
 	err := a.AuthService.Logout(r.Context(), token)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// logoutAll ends every session of the user, on all devices.
+func (h *Handler) logoutAll(a *app.App, w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIdFromContext(r.Context())
+	if !ok {
+		utils.RespondError(w, r, middleware.ErrUnauthenticated)
+		return
+	}
+
+	if err := a.AuthService.LogoutAll(r.Context(), userID); err != nil {
+		utils.RespondError(w, r, err)
 		return
 	}
 
@@ -105,15 +194,145 @@ type RefreshRequest struct {
 func (h *Handler) refresh(a *app.App, w http.ResponseWriter, r *http.Request) {
 	var body RefreshRequest
 	if err := utils.BindAndValidate(r, &body); err != nil {
-		utils.RespondWithValidationErrors(w, err)
+		utils.RespondError(w, r, err)
 		return
 	}
 
-	tokens, err := a.AuthService.RefreshToken(r.Context(), body.RefreshToken)
+	tokens, err := a.AuthService.RefreshToken(r.Context(), body.RefreshToken, clientFrom(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
+		utils.RespondError(w, r, err)
 		return
 	}
 
 	json.NewEncoder(w).Encode(tokens)
 }
+
+var errMissingToken = apperr.New(apperr.ErrInvalid, "missing_token", "missing token")
+
+func (h *Handler) verifyEmail(a *app.App, w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		utils.RespondError(w, r, errMissingToken)
+		return
+	}
+
+	err := a.AuthService.VerifyEmail(r.Context(), token)
+	if err != nil {
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	// usually opened from the email in a browser
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "Email address verified")
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+func (h *Handler) resendVerification(a *app.App, w http.ResponseWriter, r *http.Request) {
+	var body ResendVerificationRequest
+	if err := utils.BindAndValidate(r, &body); err != nil {
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	err := a.AuthService.SendVerificationEmail(r.Context(), body.Email)
+	if err != nil {
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	// same answer for unknown and already verified emails
+	w.WriteHeader(http.StatusAccepted)
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+func (h *Handler) forgotPassword(a *app.App, w http.ResponseWriter, r *http.Request) {
+	var body ForgotPasswordRequest
+	if err := utils.BindAndValidate(r, &body); err != nil {
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	err := a.AuthService.ForgotPassword(r.Context(), body.Email)
+	if err != nil {
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	// same answer whether or not the email has an account
+	w.WriteHeader(http.StatusAccepted)
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=8"`
+}
+
+func (h *Handler) resetPassword(a *app.App, w http.ResponseWriter, r *http.Request) {
+	var body ResetPasswordRequest
+	if err := utils.BindAndValidate(r, &body); err != nil {
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	err := a.AuthService.ResetPassword(r.Context(), body.Token, body.Password)
+	if err != nil {
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) listLockouts(a *app.App, w http.ResponseWriter, r *http.Request) {
+	limit, err := strconv.ParseInt(r.URL.Query().Get("limit"), 10, 64)
+	if err != nil {
+		limit = 10
+	}
+
+	offset, err := strconv.ParseInt(r.URL.Query().Get("offset"), 10, 64)
+	if err != nil {
+		offset = 0
+	}
+
+	lockouts, err := a.AuthService.ListLockouts(r.Context(), limit, offset)
+	if err != nil {
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(lockouts)
+}
+
+// clearLockout lifts a lockout, scope being "account" (subject the email
+// address) or "ip".
+func (h *Handler) clearLockout(a *app.App, w http.ResponseWriter, r *http.Request) {
+	err := a.AuthService.ClearLockout(r.Context(), r.PathValue("scope"), r.PathValue("subject"))
+	if err != nil {
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// jwks publishes the public signing keys so other services can verify
+// access tokens without sharing a secret.
+func (h *Handler) jwks(a *app.App, w http.ResponseWriter, r *http.Request) {
+	set, err := a.AuthService.JwtManager.Keys().JWKS()
+	if err != nil {
+		utils.RespondError(w, r, fmt.Errorf("encoding keys: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	// short enough for verifiers to pick up a rotation quickly
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(set)
+}
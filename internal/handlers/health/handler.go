@@ -21,7 +21,7 @@ func (h *Handler) Register(r *router.Router) {
 }
 
 func (h *Handler) HealthCheck(a *app.App, w http.ResponseWriter, r *http.Request) {
-	a.Logger.Info("Health check")
+	a.Logger.InfoContext(r.Context(), "Health check")
 	w.WriteHeader(http.StatusOK)
 	fmt.Fprint(w, "OK")
 }
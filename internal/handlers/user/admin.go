@@ -0,0 +1,158 @@
+package user
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/bercivarga/go-basic-server/internal/app"
+	"github.com/bercivarga/go-basic-server/internal/apperr"
+	"github.com/bercivarga/go-basic-server/internal/middleware"
+	authService "github.com/bercivarga/go-basic-server/internal/services/auth"
+	"github.com/bercivarga/go-basic-server/internal/services/user"
+	"github.com/bercivarga/go-basic-server/internal/utils"
+)
+
+var (
+	errInvalidUserID       = apperr.New(apperr.ErrInvalid, "invalid_user_id", "invalid user id")
+	errNothingToUpdate     = apperr.New(apperr.ErrInvalid, "nothing_to_update", "nothing to update")
+	errNestedImpersonation = apperr.New(apperr.ErrForbidden, "nested_impersonation", "cannot impersonate while impersonating")
+)
+
+// pathUserID reads the {id} of the admin routes, answering 400 itself
+// when it is not a number.
+func pathUserID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	userID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		utils.RespondError(w, r, errInvalidUserID)
+		return 0, false
+	}
+	return userID, true
+}
+
+func (h *Handler) get(a *app.App, w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathUserID(w, r)
+	if !ok {
+		return
+	}
+
+	u, err := a.UserService.GetUserByID(r.Context(), userID)
+	if err != nil {
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(u)
+}
+
+// UpdateUserRequest changes only the fields that are present. Roles are
+// changed with PUT /users/{id}/role.
+type UpdateUserRequest struct {
+	Email    *string `json:"email" validate:"omitempty,email"`
+	Disabled *bool   `json:"disabled"`
+}
+
+// update changes the email address or disabled flag of a user. A new
+// address has to be verified again and gets a verification mail.
+func (h *Handler) update(a *app.App, w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	actorID, ok := middleware.GetUserIdFromContext(ctx)
+	if !ok {
+		utils.RespondError(w, r, middleware.ErrUnauthenticated)
+		return
+	}
+	userID, ok := pathUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var body UpdateUserRequest
+	if err := utils.BindAndValidate(r, &body); err != nil {
+		utils.RespondError(w, r, err)
+		return
+	}
+	if body.Email == nil && body.Disabled == nil {
+		utils.RespondError(w, r, errNothingToUpdate)
+		return
+	}
+
+	before, err := a.UserService.GetUserByID(ctx, userID)
+	if err != nil {
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	updated, err := a.UserService.UpdateUser(ctx, user.UpdateUserRequest{
+		ActorID:  actorID,
+		UserID:   userID,
+		Email:    body.Email,
+		Disabled: body.Disabled,
+	})
+	if err != nil {
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	if updated.Email != before.Email {
+		// the change is saved either way, a failed email can be resent
+		if err := a.AuthService.SendVerificationEmail(ctx, updated.Email); err != nil {
+			a.Logger.ErrorContext(ctx, "sending verification email failed", "error", err)
+		}
+	}
+
+	json.NewEncoder(w).Encode(updated)
+}
+
+// delete removes a user and all of their sessions.
+func (h *Handler) delete(a *app.App, w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	actorID, ok := middleware.GetUserIdFromContext(ctx)
+	if !ok {
+		utils.RespondError(w, r, middleware.ErrUnauthenticated)
+		return
+	}
+	userID, ok := pathUserID(w, r)
+	if !ok {
+		return
+	}
+
+	err := a.UserService.DeleteUser(ctx, actorID, userID)
+	if err != nil {
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// impersonate hands out a short-lived access token for acting as a user,
+// e.g. to reproduce what they see. It cannot be refreshed and is marked
+// with an act claim naming the admin.
+func (h *Handler) impersonate(a *app.App, w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	actorID, ok := middleware.GetUserIdFromContext(ctx)
+	if !ok {
+		utils.RespondError(w, r, middleware.ErrUnauthenticated)
+		return
+	}
+	if _, impersonating := middleware.GetImpersonatorFromContext(ctx); impersonating {
+		utils.RespondError(w, r, errNestedImpersonation)
+		return
+	}
+	userID, ok := pathUserID(w, r)
+	if !ok {
+		return
+	}
+
+	token, err := a.AuthService.Impersonate(ctx, actorID, userID, authService.Client{
+		UserAgent: r.UserAgent(),
+		IP:        utils.ClientIP(r),
+	})
+	if err != nil {
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(token)
+}
@@ -8,7 +8,9 @@ import (
 	"github.com/bercivarga/go-basic-server/internal/app"
 	"github.com/bercivarga/go-basic-server/internal/middleware"
 	"github.com/bercivarga/go-basic-server/internal/router"
+	authService "github.com/bercivarga/go-basic-server/internal/services/auth"
 	"github.com/bercivarga/go-basic-server/internal/services/user"
+	"github.com/bercivarga/go-basic-server/internal/utils"
 )
 
 type Handler struct {
@@ -21,34 +23,224 @@ func New(a *app.App) *Handler {
 
 func (h *Handler) Register(r *router.Router) {
 	withAuthMiddleware := router.ComposeMiddleware(middleware.Auth)
-	withAdminMiddleware := router.ComposeMiddleware(
+	withPermission := func(permission string) func(router.HandleFuncWithApp) router.HandleFuncWithApp {
+		return router.ComposeMiddleware(middleware.Auth, middleware.RequirePermission(permission))
+	}
+	// these check a password or code, limit guessing per user
+	withAuthRateLimit := router.ComposeMiddleware(
 		middleware.Auth,
-		middleware.AdminOnly,
+		middleware.RateLimitRoute(h.app.AuthRateLimiter, middleware.ByUser),
 	)
 
 	r.HandleFunc(http.MethodGet, "/users/me", withAuthMiddleware(h.me))
-	r.HandleFunc(http.MethodGet, "/users/list", withAdminMiddleware(h.list))
+	r.HandleFunc(http.MethodPost, "/users/me/password", withAuthRateLimit(h.changePassword))
+	r.HandleFunc(http.MethodGet, "/users/me/sessions", withAuthMiddleware(h.listSessions))
+	r.HandleFunc(http.MethodDelete, "/users/me/sessions/{id}", withAuthMiddleware(h.revokeSession))
+	r.HandleFunc(http.MethodPost, "/users/me/2fa/setup", withAuthMiddleware(h.setupTOTP))
+	r.HandleFunc(http.MethodPost, "/users/me/2fa/confirm", withAuthRateLimit(h.confirmTOTP))
+	r.HandleFunc(http.MethodGet, "/users/list", withPermission(authService.PermUsersList)(h.list))
+	r.HandleFunc(http.MethodDelete, "/users/{id}/2fa", withPermission(authService.PermUsersWrite)(h.resetTOTP))
+	r.HandleFunc(http.MethodPut, "/users/{id}/role", withPermission(authService.PermRolesAssign)(h.assignRole))
+	r.HandleFunc(http.MethodGet, "/users/{id}", withPermission(authService.PermUsersRead)(h.get))
+	r.HandleFunc(http.MethodPatch, "/users/{id}", withPermission(authService.PermUsersWrite)(h.update))
+	r.HandleFunc(http.MethodDelete, "/users/{id}", withPermission(authService.PermUsersDelete)(h.delete))
+	r.HandleFunc(http.MethodPost, "/users/{id}/impersonate", withPermission(authService.PermUsersImpersonate)(h.impersonate))
 }
 
 func (h *Handler) me(a *app.App, w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	userID, ok := middleware.GetUserIdFromContext(ctx)
 	if !ok {
-		http.Error(w, "user id not found", http.StatusUnauthorized)
+		utils.RespondError(w, r, middleware.ErrUnauthenticated)
 		return
 	}
 
 	user, err := a.UserService.GetUserByID(ctx, userID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		utils.RespondError(w, r, err)
 		return
 	}
 
 	err = json.NewEncoder(w).Encode(user)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		utils.RespondError(w, r, err)
+		return
+	}
+}
+
+// ChangePasswordRequest applies the same password policy as signup.
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=8"`
+}
+
+func (h *Handler) changePassword(a *app.App, w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, ok := middleware.GetUserIdFromContext(ctx)
+	if !ok {
+		utils.RespondError(w, r, middleware.ErrUnauthenticated)
+		return
+	}
+	sessionID, _ := middleware.GetSessionIDFromContext(ctx)
+
+	var body ChangePasswordRequest
+	if err := utils.BindAndValidate(r, &body); err != nil {
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	err := a.UserService.ChangePassword(ctx, user.ChangePasswordRequest{
+		UserID:          userID,
+		SessionID:       sessionID,
+		CurrentPassword: body.CurrentPassword,
+		NewPassword:     body.NewPassword,
+	})
+	if err != nil {
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) listSessions(a *app.App, w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, ok := middleware.GetUserIdFromContext(ctx)
+	if !ok {
+		utils.RespondError(w, r, middleware.ErrUnauthenticated)
+		return
+	}
+	sessionID, _ := middleware.GetSessionIDFromContext(ctx)
+
+	sessions, err := a.AuthService.ListSessions(ctx, userID, sessionID)
+	if err != nil {
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(sessions)
+}
+
+// revokeSession signs out one of the user's sessions, {id} being the id
+// from listSessions. Revoking the current one works like logout.
+func (h *Handler) revokeSession(a *app.App, w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, ok := middleware.GetUserIdFromContext(ctx)
+	if !ok {
+		utils.RespondError(w, r, middleware.ErrUnauthenticated)
+		return
+	}
+
+	err := a.AuthService.RevokeSession(ctx, userID, r.PathValue("id"))
+	if err != nil {
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) setupTOTP(a *app.App, w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, ok := middleware.GetUserIdFromContext(ctx)
+	if !ok {
+		utils.RespondError(w, r, middleware.ErrUnauthenticated)
+		return
+	}
+
+	setup, err := a.AuthService.SetupTOTP(ctx, userID)
+	if err != nil {
+		utils.RespondError(w, r, err)
 		return
 	}
+
+	json.NewEncoder(w).Encode(setup)
+}
+
+type ConfirmTOTPRequest struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+type ConfirmTOTPResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+func (h *Handler) confirmTOTP(a *app.App, w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, ok := middleware.GetUserIdFromContext(ctx)
+	if !ok {
+		utils.RespondError(w, r, middleware.ErrUnauthenticated)
+		return
+	}
+
+	var body ConfirmTOTPRequest
+	if err := utils.BindAndValidate(r, &body); err != nil {
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	codes, err := a.AuthService.ConfirmTOTP(ctx, userID, body.Code)
+	if err != nil {
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(ConfirmTOTPResponse{RecoveryCodes: codes})
+}
+
+// resetTOTP lets an admin turn off 2FA for a user who is locked out.
+func (h *Handler) resetTOTP(a *app.App, w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	actorID, ok := middleware.GetUserIdFromContext(ctx)
+	if !ok {
+		utils.RespondError(w, r, middleware.ErrUnauthenticated)
+		return
+	}
+	userID, ok := pathUserID(w, r)
+	if !ok {
+		return
+	}
+
+	err := a.AuthService.ResetTOTP(ctx, actorID, userID)
+	if err != nil {
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type AssignRoleRequest struct {
+	Role string `json:"role" validate:"required"`
+}
+
+// assignRole replaces the role of a user, see GET /admin/roles for the
+// roles there are.
+func (h *Handler) assignRole(a *app.App, w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	actorID, ok := middleware.GetUserIdFromContext(ctx)
+	if !ok {
+		utils.RespondError(w, r, middleware.ErrUnauthenticated)
+		return
+	}
+	userID, ok := pathUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var body AssignRoleRequest
+	if err := utils.BindAndValidate(r, &body); err != nil {
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	err := a.AuthService.AssignRole(ctx, actorID, userID, body.Role)
+	if err != nil {
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
 }
 
 func (h *Handler) list(a *app.App, w http.ResponseWriter, r *http.Request) {
@@ -69,13 +261,13 @@ func (h *Handler) list(a *app.App, w http.ResponseWriter, r *http.Request) {
 		Offset: offset,
 	})
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		utils.RespondError(w, r, err)
 		return
 	}
 
 	err = json.NewEncoder(w).Encode(users)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		utils.RespondError(w, r, err)
 		return
 	}
 }
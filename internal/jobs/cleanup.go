@@ -0,0 +1,74 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/bercivarga/go-basic-server/internal/config"
+	"github.com/bercivarga/go-basic-server/internal/db/querier"
+	"github.com/bercivarga/go-basic-server/internal/stores/audit"
+	"github.com/bercivarga/go-basic-server/internal/stores/emailverification"
+	"github.com/bercivarga/go-basic-server/internal/stores/loginfailure"
+	"github.com/bercivarga/go-basic-server/internal/stores/passwordreset"
+	"github.com/bercivarga/go-basic-server/internal/stores/ratelimit"
+	"github.com/bercivarga/go-basic-server/internal/stores/session"
+)
+
+// purgeFunc deletes up to batchSize rows older than before.
+type purgeFunc func(ctx context.Context, before time.Time, batchSize int64) (int64, error)
+
+// Cleanup returns the jobs deleting rows nothing will read again:
+// expired sessions and one-time tokens, stale login failure counts and
+// rate limit counters, and audit events past their retention. It returns
+// none when c.Interval is 0.
+func Cleanup(db *querier.DB, cfg *config.Config) []Job {
+	c := cfg.Cleanup
+	if c.Interval == 0 {
+		return nil
+	}
+
+	pepper := cfg.Security.TokenPepper
+	// failure counts start over after LoginLockoutMax without one
+	failureCutoff := func() time.Time { return time.Now().Add(-cfg.Security.LoginLockoutMax) }
+
+	job := func(name string, cutoff func() time.Time, purge purgeFunc) Job {
+		return Job{
+			Name:     name,
+			Interval: c.Interval,
+			Run: func(ctx context.Context) (int64, error) {
+				return purgeInBatches(ctx, cutoff(), int64(c.BatchSize), purge)
+			},
+		}
+	}
+
+	jobs := []Job{
+		job("purge_sessions", time.Now, session.NewStore(db, pepper).PurgeExpired),
+		job("purge_password_resets", time.Now, passwordreset.NewStore(db, pepper).PurgeExpired),
+		job("purge_email_verifications", time.Now, emailverification.NewStore(db, pepper).PurgeExpired),
+		job("purge_login_failures", failureCutoff, loginfailure.NewStore(db).PurgeStale),
+		job("purge_rate_limits", time.Now, ratelimit.NewStore(db).PurgeExpired),
+	}
+	if retention := cfg.Audit.Retention; retention > 0 {
+		auditCutoff := func() time.Time { return time.Now().Add(-retention) }
+		jobs = append(jobs, job("purge_audit_events", auditCutoff, audit.NewStore(db).PurgeBefore))
+	}
+	return jobs
+}
+
+// purgeInBatches deletes in statements of batchSize rows, so a large
+// backlog never holds a write lock for long, until a batch comes back
+// short or ctx is cancelled.
+func purgeInBatches(ctx context.Context, before time.Time, batchSize int64, purge purgeFunc) (int64, error) {
+	var total int64
+	for ctx.Err() == nil {
+		n, err := purge(ctx, before, batchSize)
+		total += n
+		if err != nil {
+			return total, err
+		}
+		if n < batchSize {
+			return total, nil
+		}
+	}
+	return total, ctx.Err()
+}
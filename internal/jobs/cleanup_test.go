@@ -0,0 +1,113 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bercivarga/go-basic-server/internal/config"
+	"github.com/bercivarga/go-basic-server/internal/db/dbtest"
+)
+
+// table is a purgeFunc over rows rows, failing the batch numbered failAt
+// (counting from 1) when it is set.
+type table struct {
+	rows    int64
+	failAt  int
+	batches []int64
+}
+
+func (tb *table) purge(_ context.Context, _ time.Time, batchSize int64) (int64, error) {
+	tb.batches = append(tb.batches, batchSize)
+	if len(tb.batches) == tb.failAt {
+		return 0, errors.New("boom")
+	}
+	n := min(tb.rows, batchSize)
+	tb.rows -= n
+	return n, nil
+}
+
+func TestPurgeInBatches(t *testing.T) {
+	tests := []struct {
+		name    string
+		rows    int64
+		failAt  int
+		total   int64
+		batches int
+		err     bool
+	}{
+		{"nothing to delete", 0, 0, 0, 1, false},
+		{"less than a batch", 10, 0, 10, 1, false},
+		{"short last batch", 250, 0, 250, 3, false},
+		{"exact batches", 200, 0, 200, 3, false},
+		{"failing batch", 250, 2, 100, 2, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tb := &table{rows: tt.rows, failAt: tt.failAt}
+			total, err := purgeInBatches(context.Background(), time.Now(), 100, tb.purge)
+			if (err != nil) != tt.err {
+				t.Errorf("err = %v, want error %v", err, tt.err)
+			}
+			if total != tt.total || len(tb.batches) != tt.batches {
+				t.Errorf("deleted %d in %d batches, want %d in %d", total, len(tb.batches), tt.total, tt.batches)
+			}
+			for _, size := range tb.batches {
+				if size != 100 {
+					t.Errorf("batch of %d, want 100", size)
+				}
+			}
+		})
+	}
+}
+
+func TestPurgeInBatchesCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	batches := 0
+	total, err := purgeInBatches(ctx, time.Now(), 100, func(context.Context, time.Time, int64) (int64, error) {
+		batches++
+		if batches == 2 {
+			cancel()
+		}
+		return 100, nil
+	})
+	if !errors.Is(err, context.Canceled) || total != 200 || batches != 2 {
+		t.Errorf("got %d in %d batches, %v, want 200 in 2, %v", total, batches, err, context.Canceled)
+	}
+}
+
+func TestCleanupJobs(t *testing.T) {
+	db := dbtest.OpenMemory(t)
+
+	tests := []struct {
+		name      string
+		interval  time.Duration
+		retention time.Duration
+		jobs      int
+	}{
+		{"disabled", 0, time.Hour, 0},
+		{"audit events kept forever", time.Hour, 0, 5},
+		{"everything", time.Hour, time.Hour, 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Default()
+			cfg.Security.TokenPepper = dbtest.TokenPepper
+			cfg.Cleanup.Interval = tt.interval
+			cfg.Audit.Retention = tt.retention
+
+			jobs := Cleanup(db, cfg)
+			if len(jobs) != tt.jobs {
+				t.Fatalf("got %d jobs, want %d", len(jobs), tt.jobs)
+			}
+			for _, j := range jobs {
+				if n, err := j.Run(context.Background()); err != nil || n != 0 {
+					t.Errorf("%s on an empty database = %d, %v, want 0, nil", j.Name, n, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+// Package jobs runs periodic background work next to the HTTP server.
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Job is run every Interval. Run returns how many items it handled, e.g.
+// deleted rows, which is reported in its Status.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) (int64, error)
+}
+
+// Status is the outcome of the last run of a job.
+type Status struct {
+	Name         string     `json:"name"`
+	Interval     string     `json:"interval"`
+	Running      bool       `json:"running"`
+	Runs         int64      `json:"runs"`
+	LastRunAt    *time.Time `json:"last_run_at"`
+	LastDuration string     `json:"last_duration,omitempty"`
+	LastCount    int64      `json:"last_count"`
+	TotalCount   int64      `json:"total_count"`
+	LastError    string     `json:"last_error,omitempty"`
+}
+
+// Runner runs jobs on their own goroutines until stopped.
+type Runner struct {
+	jobs []Job
+
+	mu     sync.Mutex
+	status map[string]*Status
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewRunner(jobs ...Job) *Runner {
+	r := &Runner{jobs: jobs, status: map[string]*Status{}}
+	for _, j := range jobs {
+		r.status[j.Name] = &Status{Name: j.Name, Interval: j.Interval.String()}
+	}
+	return r
+}
+
+// Start runs every job once right away and then on its interval. Jobs
+// stop when ctx is cancelled or Stop is called.
+func (r *Runner) Start(ctx context.Context) {
+	ctx, r.cancel = context.WithCancel(ctx)
+	for _, j := range r.jobs {
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			r.loop(ctx, j)
+		}()
+	}
+}
+
+// Stop cancels the jobs and waits for running ones to return, so it is
+// safe to close the database afterwards.
+func (r *Runner) Stop() {
+	if r.cancel != nil {
+		r.cancel()
+	}
+	r.wg.Wait()
+}
+
+// Status returns the status of every job, in the order they were given.
+func (r *Runner) Status() []Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := make([]Status, len(r.jobs))
+	for i, j := range r.jobs {
+		out[i] = *r.status[j.Name]
+	}
+	return out
+}
+
+func (r *Runner) loop(ctx context.Context, j Job) {
+	ticker := time.NewTicker(j.Interval)
+	defer ticker.Stop()
+
+	for {
+		r.run(ctx, j)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Runner) run(ctx context.Context, j Job) {
+	r.update(j.Name, func(s *Status) { s.Running = true })
+
+	start := time.Now()
+	count, err := j.Run(ctx)
+	elapsed := time.Since(start)
+
+	r.update(j.Name, func(s *Status) {
+		s.Running = false
+		s.Runs++
+		s.LastRunAt = &start
+		s.LastDuration = elapsed.String()
+		s.LastCount = count
+		s.TotalCount += count
+		s.LastError = ""
+		if err != nil {
+			s.LastError = err.Error()
+		}
+	})
+
+	switch {
+	case err != nil && ctx.Err() == nil:
+		slog.Error("job failed", "job", j.Name, "count", count, "error", err)
+	case count > 0:
+		slog.Info("job finished", "job", j.Name, "count", count, "duration", elapsed)
+	}
+}
+
+func (r *Runner) update(name string, fn func(*Status)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fn(r.status[name])
+}
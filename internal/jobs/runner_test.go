@@ -0,0 +1,138 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitFor polls cond until it holds, failing the test after a second.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// stop calls r.Stop, failing the test if it does not return in time.
+func stop(t *testing.T, r *Runner) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		r.Stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Stop did not return")
+	}
+}
+
+func TestRunnerRunsOnInterval(t *testing.T) {
+	var runs atomic.Int64
+	r := NewRunner(Job{Name: "count", Interval: 5 * time.Millisecond, Run: func(ctx context.Context) (int64, error) {
+		runs.Add(1)
+		return 2, nil
+	}})
+
+	r.Start(context.Background())
+	waitFor(t, "three runs", func() bool { return r.Status()[0].Runs >= 3 })
+	stop(t, r)
+
+	after := runs.Load()
+	time.Sleep(20 * time.Millisecond)
+	if runs.Load() != after {
+		t.Error("job ran after Stop")
+	}
+
+	s := r.Status()[0]
+	if s.Name != "count" || s.Interval != "5ms" || s.Running {
+		t.Errorf("status = %+v, want count, 5ms, not running", s)
+	}
+	if s.Runs != after || s.LastCount != 2 || s.TotalCount != 2*after {
+		t.Errorf("status = %+v, want %d runs of 2", s, after)
+	}
+	if s.LastRunAt == nil || s.LastDuration == "" || s.LastError != "" {
+		t.Errorf("status = %+v, want a successful last run", s)
+	}
+}
+
+func TestRunnerStopMidRun(t *testing.T) {
+	started := make(chan struct{})
+	r := NewRunner(Job{Name: "slow", Interval: time.Hour, Run: func(ctx context.Context) (int64, error) {
+		close(started)
+		<-ctx.Done()
+		return 1, ctx.Err()
+	}})
+
+	r.Start(context.Background())
+	<-started
+	if s := r.Status()[0]; !s.Running || s.Runs != 0 {
+		t.Errorf("status during the run = %+v, want running and no runs finished", s)
+	}
+
+	stop(t, r)
+	s := r.Status()[0]
+	if s.Running || s.Runs != 1 || s.LastCount != 1 {
+		t.Errorf("status = %+v, want one finished run of 1", s)
+	}
+	if s.LastError != context.Canceled.Error() {
+		t.Errorf("LastError = %q, want %q", s.LastError, context.Canceled)
+	}
+}
+
+func TestRunnerStopsWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := NewRunner(Job{Name: "noop", Interval: time.Hour, Run: func(ctx context.Context) (int64, error) {
+		return 0, nil
+	}})
+
+	r.Start(ctx)
+	cancel()
+	stop(t, r)
+}
+
+func TestRunnerStopWithoutStart(t *testing.T) {
+	stop(t, NewRunner(Job{Name: "never", Interval: time.Hour}))
+}
+
+func TestRunnerReportsErrors(t *testing.T) {
+	var fail atomic.Bool
+	fail.Store(true)
+	r := NewRunner(Job{Name: "flaky", Interval: 5 * time.Millisecond, Run: func(ctx context.Context) (int64, error) {
+		if fail.Load() {
+			return 3, errors.New("boom")
+		}
+		return 0, nil
+	}})
+
+	r.Start(context.Background())
+	defer stop(t, r)
+
+	waitFor(t, "a failed run", func() bool { return r.Status()[0].Runs >= 1 })
+	if s := r.Status()[0]; s.LastError != "boom" || s.LastCount != 3 {
+		t.Errorf("status = %+v, want error boom and count 3", s)
+	}
+
+	fail.Store(false)
+	waitFor(t, "the error to clear", func() bool { return r.Status()[0].LastError == "" })
+}
+
+func TestRunnerStatusOrder(t *testing.T) {
+	r := NewRunner(Job{Name: "b", Interval: time.Minute}, Job{Name: "a", Interval: time.Hour})
+
+	s := r.Status()
+	if len(s) != 2 || s[0].Name != "b" || s[1].Name != "a" {
+		t.Fatalf("Status = %+v, want b then a", s)
+	}
+	if s[0].Runs != 0 || s[0].LastRunAt != nil || s[1].Interval != "1h0m0s" {
+		t.Errorf("Status = %+v, want jobs that never ran", s)
+	}
+}
@@ -0,0 +1,65 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+type contextKey struct{}
+
+// attrs collects what AddAttrs attached to a request. It is shared by
+// every context derived from the one NewContext returned, so attributes
+// added deep in the handler chain also reach records logged further out,
+// like the access log line.
+type attrs struct {
+	mu    sync.Mutex
+	attrs []slog.Attr
+}
+
+// NewContext returns ctx with room for attributes that are added to every
+// record logged with it, see AddAttrs.
+func NewContext(ctx context.Context, as ...slog.Attr) context.Context {
+	return context.WithValue(ctx, contextKey{}, &attrs{attrs: as})
+}
+
+// AddAttrs attaches as to the records logged with ctx from now on. It does
+// nothing for a ctx not derived from NewContext.
+func AddAttrs(ctx context.Context, as ...slog.Attr) {
+	if a, ok := ctx.Value(contextKey{}).(*attrs); ok {
+		a.mu.Lock()
+		a.attrs = append(a.attrs, as...)
+		a.mu.Unlock()
+	}
+}
+
+func attrsFrom(ctx context.Context) []slog.Attr {
+	a, ok := ctx.Value(contextKey{}).(*attrs)
+	if !ok {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]slog.Attr(nil), a.attrs...)
+}
+
+// contextHandler adds the attributes of the context a record is logged
+// with, so InfoContext and friends need not repeat the request ID.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		r.AddAttrs(attrsFrom(ctx)...)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(as []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(as)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
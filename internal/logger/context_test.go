@@ -0,0 +1,73 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// records decodes the JSON records written to buf.
+func records(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var out []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var rec map[string]any
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("decoding %q: %v", line, err)
+		}
+		out = append(out, rec)
+	}
+	return out
+}
+
+func TestContextAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(NewHandler(slog.NewJSONHandler(&buf, nil)))
+
+	ctx := NewContext(context.Background(), slog.String("request_id", "req-1"))
+	inner := context.WithValue(ctx, struct{}{}, "deeper in the chain")
+	AddAttrs(inner, slog.Int64("user_id", 42))
+
+	log.InfoContext(inner, "inner")
+	log.InfoContext(ctx, "outer")
+	log.With("component", "test").WithGroup("g").InfoContext(ctx, "derived")
+	log.InfoContext(context.Background(), "no request")
+	log.Info("no context")
+
+	recs := records(t, &buf)
+	if len(recs) != 5 {
+		t.Fatalf("got %d records, want 5", len(recs))
+	}
+	for _, rec := range recs[:2] {
+		if rec["request_id"] != "req-1" || rec["user_id"] != float64(42) {
+			t.Errorf("%s record = %v, want request_id req-1 and user_id 42", rec["msg"], rec)
+		}
+	}
+	if g, ok := recs[2]["g"].(map[string]any); !ok || g["request_id"] != "req-1" || recs[2]["component"] != "test" {
+		t.Errorf("derived logger record = %v, want request_id in group g", recs[2])
+	}
+	for _, rec := range recs[3:] {
+		if _, ok := rec["request_id"]; ok {
+			t.Errorf("%s record = %v, want no request_id", rec["msg"], rec)
+		}
+	}
+}
+
+func TestAddAttrsWithoutNewContext(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(NewHandler(slog.NewJSONHandler(&buf, nil)))
+
+	ctx := context.Background()
+	AddAttrs(ctx, slog.Int64("user_id", 42))
+	log.InfoContext(ctx, "plain")
+
+	if rec := records(t, &buf)[0]; rec["user_id"] != nil {
+		t.Errorf("record = %v, want no user_id", rec)
+	}
+}
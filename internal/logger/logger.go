@@ -7,7 +7,8 @@ import (
 )
 
 // New returns a configured slog.Logger and also sets it as the
-// process-wide default (slog.SetDefault).
+// process-wide default (slog.SetDefault). Records logged with a context
+// carry the attributes added to it with AddAttrs.
 func New() *slog.Logger {
 	opts := &slog.HandlerOptions{
 		Level:       levelFromEnv(), // DEBUG | INFO | WARN | ERROR
@@ -22,11 +23,24 @@ func New() *slog.Logger {
 		h = slog.NewJSONHandler(os.Stdout, opts)
 	}
 
-	l := slog.New(h)
+	l := slog.New(NewHandler(h))
 	slog.SetDefault(l)
 	return l
 }
 
+// NewHandler wraps h so records logged with a context carry the
+// attributes of NewContext and AddAttrs.
+func NewHandler(h slog.Handler) slog.Handler {
+	return contextHandler{h}
+}
+
+// Flush commits any buffered log output to stdout. Call it once on
+// shutdown, after the last request has been served.
+func Flush() {
+	// Sync fails on pipes and terminals, nothing to flush there
+	_ = os.Stdout.Sync()
+}
+
 /* ---------- helpers ---------------------------------------------------- */
 
 func levelFromEnv() slog.Level {
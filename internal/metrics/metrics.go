@@ -0,0 +1,21 @@
+// Package metrics holds process-wide counters. They are expvar variables,
+// so they also show up wherever expvar is published; the server serves
+// them to admins at GET /admin/metrics.
+package metrics
+
+import "expvar"
+
+// Errors counts failures by kind, e.g. "panic" for recovered handler
+// panics.
+var Errors = expvar.NewMap("errors")
+
+// Snapshot returns the current value of every counter.
+func Snapshot() map[string]map[string]int64 {
+	errors := map[string]int64{}
+	Errors.Do(func(kv expvar.KeyValue) {
+		if v, ok := kv.Value.(*expvar.Int); ok {
+			errors[kv.Key] = v.Value()
+		}
+	})
+	return map[string]map[string]int64{"errors": errors}
+}
@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/bercivarga/go-basic-server/internal/audit"
+	"github.com/bercivarga/go-basic-server/internal/utils"
+)
+
+// AuditClient puts the client IP and user agent in the request context,
+// where the audit log picks them up.
+func AuditClient(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := audit.WithClient(r.Context(), utils.ClientIP(r), r.UserAgent())
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
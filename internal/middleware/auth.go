@@ -2,49 +2,115 @@ package middleware
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"strings"
 
 	"github.com/bercivarga/go-basic-server/internal/app"
+	"github.com/bercivarga/go-basic-server/internal/apperr"
+	"github.com/bercivarga/go-basic-server/internal/audit"
+	"github.com/bercivarga/go-basic-server/internal/logger"
 	"github.com/bercivarga/go-basic-server/internal/router"
+	authService "github.com/bercivarga/go-basic-server/internal/services/auth"
+	"github.com/bercivarga/go-basic-server/internal/utils"
 )
 
+// ErrUnauthenticated answers requests without an access token, and
+// handlers that find no user in the context.
+var ErrUnauthenticated = apperr.New(apperr.ErrUnauthorized, "unauthorized", "missing access token")
+
+// MissingPermission is the error for a user whose role lacks permission.
+func MissingPermission(permission string) error {
+	return apperr.New(apperr.ErrForbidden, "missing_permission", fmt.Sprintf("missing permission %q", permission))
+}
+
 func Auth(next router.HandleFuncWithApp) router.HandleFuncWithApp {
 	return func(a *app.App, w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
 		token := strings.TrimPrefix(authHeader, "Bearer ")
 		if token == "" {
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			utils.RespondError(w, r, ErrUnauthenticated)
 			return
 		}
 
 		claims, err := a.AuthService.JwtManager.Verify(token)
-		if err != nil || !a.AuthService.SessionStore.IsValid(r.Context(), claims.UserID, token) {
-			http.Error(w, "invalid or expired session", http.StatusUnauthorized)
+		if err != nil {
+			// err is one of the auth.ErrToken* sentinels and safe to show
+			unauthorized(w, r, err.Error())
+			return
+		}
+		if !a.AuthService.SessionStore.IsValid(r.Context(), claims.UserID, token) {
+			unauthorized(w, r, "invalid or expired session")
 			return
 		}
+		if err := a.AuthService.SessionStore.Touch(r.Context(), token, utils.ClientIP(r)); err != nil {
+			a.Logger.ErrorContext(r.Context(), "recording session use failed", "error", err)
+		}
 
 		ctx := r.Context()
 		ctx = context.WithValue(ctx, userIDKey, claims.UserID)
+		ctx = context.WithValue(ctx, sessionIDKey, claims.SessionID)
+		logger.AddAttrs(ctx, slog.Int64("user_id", claims.UserID))
+		actorID, impersonated := claims.ActorID()
+		if impersonated {
+			ctx = context.WithValue(ctx, impersonatorKey, actorID)
+			logger.AddAttrs(ctx, slog.Int64("impersonated_by", actorID))
+		}
+		ctx = audit.WithActor(ctx, claims.UserID, actorID)
 
 		next(a, w, r.WithContext(ctx))
 	}
 }
 
-func AdminOnly(next router.HandleFuncWithApp) router.HandleFuncWithApp {
-	return func(a *app.App, w http.ResponseWriter, r *http.Request) {
-		userId, ok := GetUserIdFromContext(r.Context())
-		if !ok {
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
-			return
-		}
+// unauthorized answers 401 with an RFC 6750 challenge.
+func unauthorized(w http.ResponseWriter, r *http.Request, reason string) {
+	w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="invalid_token", error_description=%q`, reason))
+	utils.RespondError(w, r, apperr.New(apperr.ErrUnauthorized, "invalid_token", reason))
+}
 
-		err := a.AuthService.CheckRole(r.Context(), userId, "admin")
-		if err != nil {
-			http.Error(w, "forbidden", http.StatusForbidden)
-			return
-		}
+// RequirePermission answers 403 unless the user's role grants
+// permission. It has to run after Auth. The permission set is loaded once
+// per request and kept in the context for further checks.
+func RequirePermission(permission string) func(router.HandleFuncWithApp) router.HandleFuncWithApp {
+	return func(next router.HandleFuncWithApp) router.HandleFuncWithApp {
+		return func(a *app.App, w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			perms, ok := GetPermissionsFromContext(ctx)
+			if !ok {
+				userID, ok := GetUserIdFromContext(ctx)
+				if !ok {
+					utils.RespondError(w, r, ErrUnauthenticated)
+					return
+				}
 
-		next(a, w, r)
+				var err error
+				perms, err = a.AuthService.Permissions(ctx, userID)
+				if errors.Is(err, authService.ErrUserNotFound) {
+					unauthorized(w, r, "user no longer exists")
+					return
+				}
+				if err != nil {
+					utils.RespondError(w, r, fmt.Errorf("loading permissions: %w", err))
+					return
+				}
+
+				ctx = context.WithValue(ctx, userRoleKey, perms.Role)
+				ctx = context.WithValue(ctx, permissionsKey, perms)
+				r = r.WithContext(ctx)
+			}
+
+			if !perms.Has(permission) {
+				a.Audit.Record(ctx, audit.Event{
+					Action:   audit.ActionPermissionDenied,
+					Metadata: map[string]any{"permission": permission, "role": perms.Role, "path": r.URL.Path},
+				})
+				utils.RespondError(w, r, MissingPermission(permission))
+				return
+			}
+
+			next(a, w, r)
+		}
 	}
 }
@@ -1,12 +1,22 @@
 package middleware
 
-import "context"
+import (
+	"context"
+
+	authService "github.com/bercivarga/go-basic-server/internal/services/auth"
+)
 
 type contextKey string
 
 const (
 	userIDKey   contextKey = "user_id"
 	userRoleKey contextKey = "user_role"
+	// permissionsKey caches the permission set of the user's role
+	permissionsKey contextKey = "permissions"
+	// sessionIDKey holds the sid claim, the token family of the login
+	sessionIDKey contextKey = "session_id"
+	// impersonatorKey holds the admin behind an impersonation token
+	impersonatorKey contextKey = "impersonator_id"
 )
 
 func GetUserIdFromContext(ctx context.Context) (int64, bool) {
@@ -20,3 +30,23 @@ func GetUserRoleFromContext(ctx context.Context) (string, bool) {
 	role, ok := v.(string)
 	return role, ok
 }
+
+func GetSessionIDFromContext(ctx context.Context) (string, bool) {
+	v := ctx.Value(sessionIDKey)
+	id, ok := v.(string)
+	return id, ok && id != ""
+}
+
+// GetImpersonatorFromContext returns the admin acting as the user when
+// the request was made with an impersonation token.
+func GetImpersonatorFromContext(ctx context.Context) (int64, bool) {
+	v := ctx.Value(impersonatorKey)
+	id, ok := v.(int64)
+	return id, ok
+}
+
+func GetPermissionsFromContext(ctx context.Context) (*authService.Permissions, bool) {
+	v := ctx.Value(permissionsKey)
+	perms, ok := v.(*authService.Permissions)
+	return perms, ok
+}
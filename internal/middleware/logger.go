@@ -2,9 +2,10 @@ package middleware
 
 import (
 	"log/slog"
-	"net"
 	"net/http"
 	"time"
+
+	"github.com/bercivarga/go-basic-server/internal/utils"
 )
 
 type statusWriter struct {
@@ -28,14 +29,14 @@ func Logger(next http.Handler) http.Handler {
 		next.ServeHTTP(sw, r)
 		elapsed := time.Since(start)
 
-		ip, _, _ := net.SplitHostPort(r.RemoteAddr)
-
-		l.Info("request",
+		// InfoContext picks up the request ID, and the user once Auth
+		// has run further in
+		l.InfoContext(r.Context(), "request",
 			"method", r.Method,
 			"path", r.URL.Path,
 			"status", sw.status,
 			"duration", elapsed,
-			"remote", ip,
+			"remote", utils.ClientIP(r),
 		)
 	})
 }
@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bercivarga/go-basic-server/internal/app"
+	"github.com/bercivarga/go-basic-server/internal/apperr"
+	"github.com/bercivarga/go-basic-server/internal/ratelimit"
+	"github.com/bercivarga/go-basic-server/internal/router"
+	"github.com/bercivarga/go-basic-server/internal/utils"
+)
+
+var ErrRateLimited = apperr.New(apperr.ErrTooManyRequests, "rate_limited", "rate limit exceeded")
+
+// KeyFunc picks what a rate limit counts by.
+type KeyFunc func(r *http.Request) string
+
+// ByIP counts per client IP.
+func ByIP(r *http.Request) string {
+	return "ip:" + utils.ClientIP(r)
+}
+
+// ByUser counts per authenticated user, so it has to run after Auth.
+// Anonymous requests fall back to their IP.
+func ByUser(r *http.Request) string {
+	if id, ok := GetUserIdFromContext(r.Context()); ok {
+		return "user:" + strconv.FormatInt(id, 10)
+	}
+	return ByIP(r)
+}
+
+// ByAPIKey counts per value of header, falling back to the IP without
+// one. Keys are hashed so they never end up in a shared store.
+func ByAPIKey(header string) KeyFunc {
+	return func(r *http.Request) string {
+		if k := r.Header.Get(header); k != "" {
+			return "key:" + utils.HashToken(k, "")
+		}
+		return ByIP(r)
+	}
+}
+
+// RateLimit wraps a whole http.Handler, like Logger. A nil limiter
+// disables it.
+func RateLimit(l *ratelimit.Limiter, key KeyFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if l == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if allow(l, key, w, r) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// RateLimitRoute is RateLimit for single routes, to be used with
+// router.ComposeMiddleware.
+func RateLimitRoute(l *ratelimit.Limiter, key KeyFunc) func(router.HandleFuncWithApp) router.HandleFuncWithApp {
+	return func(next router.HandleFuncWithApp) router.HandleFuncWithApp {
+		if l == nil {
+			return next
+		}
+		return func(a *app.App, w http.ResponseWriter, r *http.Request) {
+			if allow(l, key, w, r) {
+				next(a, w, r)
+			}
+		}
+	}
+}
+
+// allow counts the request and sets the RateLimit headers (IETF draft
+// "RateLimit header fields for HTTP"). It answers 429 itself and returns
+// false when the limit is exceeded. A failing store lets requests through
+// rather than taking the API down.
+func allow(l *ratelimit.Limiter, key KeyFunc, w http.ResponseWriter, r *http.Request) bool {
+	res, err := l.Allow(r.Context(), key(r))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "rate limit store failed", "limiter", l.Name, "error", err)
+		return true
+	}
+
+	h := w.Header()
+	h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", l.Limit, seconds(l.Window)))
+	h.Set("RateLimit-Limit", strconv.FormatInt(res.Limit, 10))
+	h.Set("RateLimit-Remaining", strconv.FormatInt(res.Remaining, 10))
+	h.Set("RateLimit-Reset", strconv.FormatInt(seconds(res.Reset), 10))
+
+	if !res.Allowed {
+		h.Set("Retry-After", strconv.FormatInt(max(seconds(res.RetryAfter), 1), 10))
+		utils.RespondError(w, r, ErrRateLimited)
+		return false
+	}
+	return true
+}
+
+func seconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
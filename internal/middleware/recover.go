@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/bercivarga/go-basic-server/internal/app"
+	"github.com/bercivarga/go-basic-server/internal/metrics"
+	"github.com/bercivarga/go-basic-server/internal/utils"
+)
+
+// headerWriter remembers whether the response has started, after which a
+// problem can no longer be sent.
+type headerWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *headerWriter) WriteHeader(code int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *headerWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the Flusher, Hijacker and
+// deadlines of the underlying writer.
+func (w *headerWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Recover turns a panic further down the chain into a 500 problem,
+// logging the panic value and stack through a.Logger and counting it as
+// the "panic" error metric. With Server.Repanic set it panics again once
+// the response is written, so tests fail loudly. http.ErrAbortHandler is
+// left to net/http, it is how handlers abort a response on purpose.
+func Recover(a *app.App) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hw := &headerWriter{ResponseWriter: w}
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+				if v == http.ErrAbortHandler {
+					panic(v)
+				}
+
+				metrics.Errors.Add("panic", 1)
+				a.Logger.ErrorContext(r.Context(), "panic serving request",
+					"method", r.Method,
+					"path", r.URL.Path,
+					"panic", fmt.Sprint(v),
+					"stack", string(debug.Stack()),
+				)
+				if !hw.wroteHeader {
+					utils.WriteProblem(hw, r, fmt.Errorf("panic: %v", v))
+				}
+
+				if a.Config.Server.Repanic {
+					panic(v)
+				}
+			}()
+
+			next.ServeHTTP(hw, r)
+		})
+	}
+}
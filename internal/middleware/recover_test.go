@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"expvar"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bercivarga/go-basic-server/internal/app"
+	"github.com/bercivarga/go-basic-server/internal/config"
+	"github.com/bercivarga/go-basic-server/internal/metrics"
+	"github.com/bercivarga/go-basic-server/internal/requestid"
+	"github.com/bercivarga/go-basic-server/internal/utils"
+)
+
+// newRecoverApp returns an app logging to log, with Server.Repanic set
+// to repanic.
+func newRecoverApp(log io.Writer, repanic bool) *app.App {
+	return &app.App{
+		Logger: slog.New(slog.NewTextHandler(log, nil)),
+		Config: &config.Config{Server: config.ServerConfig{Repanic: repanic}},
+	}
+}
+
+func panicking(v any) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(v)
+	})
+}
+
+func panics() int64 {
+	if v, ok := metrics.Errors.Get("panic").(*expvar.Int); ok {
+		return v.Value()
+	}
+	return 0
+}
+
+// serve runs h for a GET with X-Request-ID set to id and returns the
+// response together with whatever h panicked with in the end.
+func serve(h http.Handler, id string) (rec *httptest.ResponseRecorder, panicked any) {
+	rec = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/boom", nil)
+	r.Header.Set(requestid.Header, id)
+	defer func() { panicked = recover() }()
+	RequestID(h).ServeHTTP(rec, r)
+	return rec, nil
+}
+
+func TestRecoverAnswersProblem(t *testing.T) {
+	var log bytes.Buffer
+	before := panics()
+
+	rec, panicked := serve(Recover(newRecoverApp(&log, false))(panicking("boom")), "req-1")
+	if panicked != nil {
+		t.Fatalf("panic escaped: %v", panicked)
+	}
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Errorf("Content-Type = %q, want application/problem+json", ct)
+	}
+	var p utils.Problem
+	if err := json.NewDecoder(rec.Body).Decode(&p); err != nil {
+		t.Fatalf("decoding problem: %v", err)
+	}
+	if p.Status != http.StatusInternalServerError || p.RequestID != "req-1" {
+		t.Errorf("problem = %+v, want status 500 and request_id req-1", p)
+	}
+	if strings.Contains(p.Detail, "boom") {
+		t.Errorf("problem leaks the panic value: %q", p.Detail)
+	}
+
+	if got := panics() - before; got != 1 {
+		t.Errorf("panic metric went up by %d, want 1", got)
+	}
+	if out := log.String(); !strings.Contains(out, "panic serving request") || !strings.Contains(out, "goroutine") {
+		t.Errorf("panic and stack not logged:\n%s", out)
+	}
+}
+
+func TestRecoverAfterResponseStarted(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		io.WriteString(w, "partial")
+		panic("boom")
+	})
+
+	rec, panicked := serve(Recover(newRecoverApp(io.Discard, false))(h), "req-1")
+	if panicked != nil {
+		t.Fatalf("panic escaped: %v", panicked)
+	}
+	if rec.Code != http.StatusAccepted || rec.Body.String() != "partial" {
+		t.Errorf("got %d %q, want the started response untouched", rec.Code, rec.Body.String())
+	}
+}
+
+func TestRecoverPassesErrAbortHandler(t *testing.T) {
+	var log bytes.Buffer
+	before := panics()
+
+	rec, panicked := serve(Recover(newRecoverApp(&log, false))(panicking(http.ErrAbortHandler)), "req-1")
+	if panicked != http.ErrAbortHandler {
+		t.Fatalf("panicked with %v, want http.ErrAbortHandler", panicked)
+	}
+	if rec.Body.Len() != 0 {
+		t.Errorf("wrote %q, want nothing", rec.Body.String())
+	}
+	if got := panics() - before; got != 0 {
+		t.Errorf("panic metric went up by %d, want 0", got)
+	}
+	if log.Len() != 0 {
+		t.Errorf("logged an aborted request:\n%s", log.String())
+	}
+}
+
+func TestRecoverRepanic(t *testing.T) {
+	rec, panicked := serve(Recover(newRecoverApp(io.Discard, true))(panicking("boom")), "req-1")
+	if panicked != "boom" {
+		t.Fatalf("panicked with %v, want the handler's panic again", panicked)
+	}
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want the 500 written before panicking again", rec.Code)
+	}
+}
+
+func TestRecoverKeepsResponseController(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Errorf("Flush through the recover writer: %v", err)
+		}
+	})
+
+	rec, _ := serve(Recover(newRecoverApp(io.Discard, false))(h), "req-1")
+	if !rec.Flushed {
+		t.Error("response was not flushed")
+	}
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/bercivarga/go-basic-server/internal/logger"
+	"github.com/bercivarga/go-basic-server/internal/requestid"
+)
+
+// RequestID gives every request an ID, reusing a valid X-Request-ID from
+// the client so a request can be followed across services. The ID is
+// echoed in the response and added to every record logged with the
+// request context.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+		w.Header().Set(requestid.Header, id)
+
+		ctx := requestid.NewContext(r.Context(), id)
+		ctx = logger.NewContext(ctx, slog.String("request_id", id))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bercivarga/go-basic-server/internal/app"
+	"github.com/bercivarga/go-basic-server/internal/logger"
+	"github.com/bercivarga/go-basic-server/internal/requestid"
+)
+
+// tagRequest runs a request with X-Request-ID set to id, if not empty,
+// through RequestID. The handler marks the request as user 42 the way
+// Authenticate does and logs through a.Logger. It returns the response
+// header, the ID the handler saw and the record it logged.
+func tagRequest(t *testing.T, id string) (header, seen string, record map[string]any) {
+	t.Helper()
+	var log bytes.Buffer
+	a := &app.App{Logger: slog.New(logger.NewHandler(slog.NewJSONHandler(&log, nil)))}
+
+	h := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = requestid.FromContext(r.Context())
+		logger.AddAttrs(r.Context(), slog.Int64("user_id", 42))
+		a.Logger.InfoContext(r.Context(), "handled")
+	}))
+
+	rec := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if id != "" {
+		r.Header.Set(requestid.Header, id)
+	}
+	h.ServeHTTP(rec, r)
+
+	if err := json.Unmarshal(log.Bytes(), &record); err != nil {
+		t.Fatalf("decoding log record %q: %v", log.String(), err)
+	}
+	return rec.Header().Get(requestid.Header), seen, record
+}
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+		keep bool
+	}{
+		{"none", "", false},
+		{"valid", "req-1_a.b:c", true},
+		{"longest", strings.Repeat("a", 128), true},
+		{"too long", strings.Repeat("a", 129), false},
+		{"space", "req 1", false},
+		{"control character", "req\x001", false},
+		{"quote", `req"1`, false},
+		{"non-ASCII", "réq", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header, seen, record := tagRequest(t, tt.id)
+
+			if tt.keep && header != tt.id {
+				t.Errorf("%s = %q, want %q echoed", requestid.Header, header, tt.id)
+			}
+			if !tt.keep && (header == tt.id || !requestid.Valid(header)) {
+				t.Errorf("%s = %q, want a new valid ID", requestid.Header, header)
+			}
+			if seen != header {
+				t.Errorf("handler saw ID %q, response has %q", seen, header)
+			}
+			if record["request_id"] != header || record["user_id"] != float64(42) {
+				t.Errorf("log record = %v, want request_id %s and user_id 42", record, header)
+			}
+		})
+	}
+}
+
+func TestRequestIDGeneratesDistinctIDs(t *testing.T) {
+	first, _, _ := tagRequest(t, "")
+	second, _, _ := tagRequest(t, "")
+	if first == second {
+		t.Errorf("two requests got the same ID %q", first)
+	}
+}
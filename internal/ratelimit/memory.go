@@ -0,0 +1,61 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps counters in process memory. Each instance of the
+// server limits on its own; use a shared store when running several.
+type MemoryStore struct {
+	mu        sync.Mutex
+	counters  map[string]*counter
+	nextSweep time.Time
+}
+
+type counter struct {
+	window    int64
+	current   int64
+	previous  int64
+	expiresAt time.Time
+}
+
+// sweepInterval is how often expired counters are dropped.
+const sweepInterval = time.Minute
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{counters: map[string]*counter{}}
+}
+
+func (s *MemoryStore) Increment(_ context.Context, key string, window int64, expiresAt time.Time) (int64, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.After(s.nextSweep) {
+		for k, c := range s.counters {
+			if now.After(c.expiresAt) {
+				delete(s.counters, k)
+			}
+		}
+		s.nextSweep = now.Add(sweepInterval)
+	}
+
+	c, ok := s.counters[key]
+	if !ok {
+		c = &counter{window: window}
+		s.counters[key] = c
+	}
+	switch {
+	case c.window == window:
+	case c.window == window-1:
+		c.window, c.previous, c.current = window, c.current, 0
+	default:
+		c.window, c.previous, c.current = window, 0, 0
+	}
+	c.current++
+	c.expiresAt = expiresAt
+
+	return c.current, c.previous, nil
+}
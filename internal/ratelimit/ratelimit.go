@@ -0,0 +1,91 @@
+// Package ratelimit implements a sliding window rate limiter. Hits are
+// counted in fixed windows; the estimate for the sliding window weighs the
+// previous window by how much of it still overlaps, which is smooth at
+// window boundaries and needs only two counters per key.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Store keeps the hit counters. Implementations must be safe for
+// concurrent use and apply each Increment atomically.
+type Store interface {
+	// Increment counts a hit for key in window (a window number) and
+	// returns the hits in that window and in the one before it. The
+	// counters of key may be dropped after expiresAt.
+	Increment(ctx context.Context, key string, window int64, expiresAt time.Time) (current, previous int64, err error)
+}
+
+// Result describes a limiter decision, enough for the RateLimit-* headers.
+type Result struct {
+	Allowed    bool
+	Limit      int64
+	Remaining  int64
+	Reset      time.Duration // until the current window ends
+	RetryAfter time.Duration // only set when not allowed
+}
+
+// Limiter allows Limit hits per Window and key.
+type Limiter struct {
+	Name   string // keeps keys of limiters sharing a store apart
+	Limit  int64
+	Window time.Duration
+
+	store Store
+	now   func() time.Time
+}
+
+func New(store Store, name string, limit int, window time.Duration) *Limiter {
+	return &Limiter{
+		Name:   name,
+		Limit:  int64(limit),
+		Window: window,
+		store:  store,
+		now:    time.Now,
+	}
+}
+
+// Allow counts a hit for key and reports whether it is within the limit.
+// Rejected hits are counted too, so clients that ignore Retry-After stay
+// limited.
+func (l *Limiter) Allow(ctx context.Context, key string) (Result, error) {
+	now := l.now()
+	window := now.UnixNano() / int64(l.Window)
+	windowEnd := time.Unix(0, (window+1)*int64(l.Window))
+
+	current, previous, err := l.store.Increment(ctx, l.Name+":"+key, window, windowEnd.Add(l.Window))
+	if err != nil {
+		return Result{}, err
+	}
+
+	elapsed := 1 - float64(windowEnd.Sub(now))/float64(l.Window)
+	estimate := float64(previous)*(1-elapsed) + float64(current)
+
+	res := Result{
+		Allowed:   estimate <= float64(l.Limit),
+		Limit:     l.Limit,
+		Remaining: max(0, l.Limit-int64(math.Ceil(estimate))),
+		Reset:     windowEnd.Sub(now),
+	}
+	if !res.Allowed {
+		res.RetryAfter = l.retryAfter(now, windowEnd, current, previous)
+	}
+	return res, nil
+}
+
+// retryAfter is how long until one more hit fits in the sliding window.
+func (l *Limiter) retryAfter(now, windowEnd time.Time, current, previous int64) time.Duration {
+	room := float64(l.Limit - 1)
+	if float64(current) <= room && previous > 0 {
+		// fits once enough of the previous window has slid out
+		elapsed := 1 - (room-float64(current))/float64(previous)
+		at := windowEnd.Add(-l.Window).Add(time.Duration(elapsed * float64(l.Window)))
+		return max(at.Sub(now), 0)
+	}
+	// current becomes the previous window, wait until enough of it is out
+	elapsed := 1 - room/float64(current)
+	return windowEnd.Sub(now) + time.Duration(elapsed*float64(l.Window))
+}
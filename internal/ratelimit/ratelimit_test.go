@@ -0,0 +1,116 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bercivarga/go-basic-server/internal/db/dbtest"
+	dbStore "github.com/bercivarga/go-basic-server/internal/stores/ratelimit"
+)
+
+// stores are the Store implementations every limiter test runs against.
+var stores = []struct {
+	name string
+	new  func(t *testing.T) Store
+}{
+	{"memory", func(t *testing.T) Store { return NewMemoryStore() }},
+	{"database", func(t *testing.T) Store { return dbStore.NewStore(dbtest.OpenMemory(t)) }},
+}
+
+// start returns the beginning of a window that has not started yet, so
+// counters written for it are not expired by the wall clock.
+func start(window time.Duration) time.Time {
+	return time.Unix(0, (time.Now().UnixNano()/int64(window)+2)*int64(window))
+}
+
+// replay hits key at each offset from base and returns the last result.
+func replay(t *testing.T, l *Limiter, key string, base time.Time, hits []time.Duration) Result {
+	t.Helper()
+	var res Result
+	for _, at := range hits {
+		l.now = func() time.Time { return base.Add(at) }
+		var err error
+		if res, err = l.Allow(context.Background(), key); err != nil {
+			t.Fatalf("Allow at %s: %v", at, err)
+		}
+	}
+	return res
+}
+
+func TestAllow(t *testing.T) {
+	const window = time.Minute
+	s := time.Second
+
+	tests := []struct {
+		name       string
+		limit      int
+		hits       []time.Duration
+		allowed    bool
+		remaining  int64
+		retryAfter time.Duration
+	}{
+		{"within the limit", 3, []time.Duration{0, 10 * s}, true, 1, 0},
+		{"at the limit", 3, []time.Duration{0, 10 * s, 20 * s}, true, 0, 0},
+		{"over the limit", 3, []time.Duration{0, 10 * s, 20 * s, 30 * s}, false, 0, 60 * s},
+		// 3 hits a quarter overlapping count as 0.75
+		{"previous window weighted", 3, []time.Duration{0, 10 * s, 20 * s, 105 * s}, true, 1, 0},
+		{"previous window still full", 3, []time.Duration{0, 10 * s, 20 * s, 30 * s, 60 * s}, false, 0, 45 * s},
+		{"limit of one", 1, []time.Duration{0, 10 * s}, false, 0, 110 * s},
+		{"skipped window resets", 3, []time.Duration{0, 10 * s, 20 * s, 30 * s, 150 * s}, true, 2, 0},
+	}
+
+	for _, store := range stores {
+		t.Run(store.name, func(t *testing.T) {
+			l := New(store.new(t), "test", 0, window)
+			base := start(window)
+
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					l.Limit = int64(tt.limit)
+					res := replay(t, l, tt.name, base, tt.hits)
+					if res.Allowed != tt.allowed || res.Remaining != tt.remaining || res.RetryAfter != tt.retryAfter {
+						t.Errorf("got allowed %v, remaining %d, retry after %s, want %v, %d, %s",
+							res.Allowed, res.Remaining, res.RetryAfter, tt.allowed, tt.remaining, tt.retryAfter)
+					}
+					if !tt.allowed {
+						// a second earlier is still too soon, RetryAfter is
+						// not: replayed on fresh keys since probes count
+						last := tt.hits[len(tt.hits)-1]
+						early := replay(t, l, tt.name+" early", base, append(tt.hits, last+tt.retryAfter-s))
+						if early.Allowed {
+							t.Errorf("allowed %s before RetryAfter", s)
+						}
+						retry := replay(t, l, tt.name+" retry", base, append(tt.hits, last+tt.retryAfter))
+						if !retry.Allowed {
+							t.Errorf("denied after RetryAfter, retry after %s more", retry.RetryAfter)
+						}
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestAllowSeparatesKeysAndLimiters(t *testing.T) {
+	for _, store := range stores {
+		t.Run(store.name, func(t *testing.T) {
+			st := store.new(t)
+			base := start(time.Minute)
+			a, b := New(st, "a", 1, time.Minute), New(st, "b", 1, time.Minute)
+
+			if res := replay(t, a, "key", base, []time.Duration{0}); !res.Allowed {
+				t.Fatal("first hit denied")
+			}
+			if res := replay(t, a, "other", base, []time.Duration{0}); !res.Allowed {
+				t.Error("hit on another key denied")
+			}
+			if res := replay(t, b, "key", base, []time.Duration{0}); !res.Allowed {
+				t.Error("hit on another limiter denied")
+			}
+			if res := replay(t, a, "key", base, []time.Duration{time.Second}); res.Allowed {
+				t.Error("second hit on the same key allowed")
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+// Package requestid tags each request with an ID that shows up in its log
+// records, error responses and the X-Request-ID header, so a client report
+// can be matched to the server's logs.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+)
+
+// Header carries the ID in requests and responses.
+const Header = "X-Request-ID"
+
+// maxLen bounds IDs taken from clients, they end up in every log record.
+const maxLen = 128
+
+type contextKey struct{}
+
+// New returns a fresh random ID.
+func New() string {
+	return rand.Text()
+}
+
+// Valid reports whether id, usually sent by a client or a proxy in front
+// of the server, is fit to be reused: at most 128 letters, digits and
+// "-_.:".
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLen {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the ID of the request ctx belongs to, or "".
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
@@ -4,6 +4,13 @@ import (
 	"net/http"
 
 	"github.com/bercivarga/go-basic-server/internal/app"
+	"github.com/bercivarga/go-basic-server/internal/apperr"
+	"github.com/bercivarga/go-basic-server/internal/utils"
+)
+
+var (
+	ErrRouteNotFound    = apperr.New(apperr.ErrNotFound, "route_not_found", "no such route")
+	ErrMethodNotAllowed = apperr.New(apperr.ErrMethodNotAllowed, "method_not_allowed", "method not allowed")
 )
 
 type Router struct {
@@ -22,9 +29,43 @@ func New(a *app.App) *Router {
 
 // ServeHTTP lets Router satisfy http.Handler.
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if _, pattern := r.mux.Handler(req); pattern == "" {
+		// the mux answers unmatched requests in plain text, keep its
+		// status and Allow header but answer with a problem
+		w = &unmatchedWriter{ResponseWriter: w, req: req}
+	}
 	r.mux.ServeHTTP(w, req)
 }
 
+// unmatchedWriter turns the mux's 404 and 405 into problem+json and drops
+// the text it writes after.
+type unmatchedWriter struct {
+	http.ResponseWriter
+	req      *http.Request
+	answered bool
+}
+
+func (w *unmatchedWriter) WriteHeader(status int) {
+	switch status {
+	case http.StatusNotFound:
+		utils.RespondError(w.ResponseWriter, w.req, ErrRouteNotFound)
+	case http.StatusMethodNotAllowed:
+		utils.RespondError(w.ResponseWriter, w.req, ErrMethodNotAllowed)
+	default:
+		// e.g. the mux's redirect to the path with a trailing slash
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	w.answered = true
+}
+
+func (w *unmatchedWriter) Write(b []byte) (int, error) {
+	if w.answered {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
 // Handle registers a path ➜ handler pair.
 func (r *Router) Handle(pattern string, h http.Handler) {
 	r.mux.Handle(pattern, h)
@@ -33,14 +74,11 @@ func (r *Router) Handle(pattern string, h http.Handler) {
 // HandleFuncWithApp describes a handler that captures *app.App.
 type HandleFuncWithApp func(*app.App, http.ResponseWriter, *http.Request)
 
-// HandleFunc shortcuts to http.HandlerFunc and captures *app.App.
+// HandleFunc shortcuts to http.HandlerFunc and captures *app.App. The
+// method is part of the mux pattern, so one path can have a handler per
+// method and the mux answers 405 with an Allow header for the others.
 func (r *Router) HandleFunc(method string, pattern string, fn HandleFuncWithApp) {
-	r.mux.HandleFunc(pattern, func(w http.ResponseWriter, req *http.Request) {
-		if req.Method != method {
-			w.Header().Set("Allow", method)
-			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
-			return
-		}
+	r.mux.HandleFunc(method+" "+pattern, func(w http.ResponseWriter, req *http.Request) {
 		fn(r.app, w, req)
 	})
 }
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Run serves srv until ctx is cancelled, then stops accepting new
+// connections and waits up to shutdownTimeout for in-flight requests to
+// finish. It returns nil on a clean shutdown.
+func Run(ctx context.Context, srv *http.Server, shutdownTimeout time.Duration) error {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		// the listener failed before we were asked to stop
+		return fmt.Errorf("server listen: %w", err)
+	case <-ctx.Done():
+	}
+
+	slog.Info("shutting down server", "timeout", shutdownTimeout)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		// drain deadline hit, cut whatever is still open
+		srv.Close()
+		return fmt.Errorf("server shutdown: %w", err)
+	}
+
+	if err := <-errCh; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("server listen: %w", err)
+	}
+
+	slog.Info("server stopped")
+	return nil
+}
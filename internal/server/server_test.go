@@ -0,0 +1,126 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newServer returns an unstarted server for h on a free local port.
+func newServer(t *testing.T, h http.Handler) *http.Server {
+	t.Helper()
+	ts := httptest.NewUnstartedServer(h)
+	addr := ts.Listener.Addr().String()
+	// Run listens itself, free the port for it
+	ts.Listener.Close()
+	srv := ts.Config
+	srv.Addr = addr
+	return srv
+}
+
+// waitListening blocks until srv accepts connections.
+func waitListening(t *testing.T, srv *http.Server) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		conn, err := net.Dial("tcp", srv.Addr)
+		if err == nil {
+			conn.Close()
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("server did not start: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// slowHandler answers after delay, closing started once a request is in.
+func slowHandler(started chan<- struct{}, delay time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(delay)
+		io.WriteString(w, "done")
+	})
+}
+
+type result struct {
+	status int
+	body   string
+	err    error
+}
+
+func get(url string) <-chan result {
+	ch := make(chan result, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			ch <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		ch <- result{status: resp.StatusCode, body: string(body), err: err}
+	}()
+	return ch
+}
+
+func TestRunDrainsInFlightRequests(t *testing.T) {
+	started := make(chan struct{})
+	srv := newServer(t, slowHandler(started, 200*time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runErr := make(chan error, 1)
+	go func() { runErr <- Run(ctx, srv, 5*time.Second) }()
+	waitListening(t, srv)
+
+	resp := get("http://" + srv.Addr + "/")
+	<-started
+	cancel()
+
+	r := <-resp
+	if r.err != nil {
+		t.Fatalf("in-flight request failed: %v", r.err)
+	}
+	if r.status != http.StatusOK || r.body != "done" {
+		t.Errorf("in-flight request got %d %q, want 200 \"done\"", r.status, r.body)
+	}
+	if err := <-runErr; err != nil {
+		t.Errorf("Run returned %v, want nil", err)
+	}
+	if _, err := net.Dial("tcp", srv.Addr); err == nil {
+		t.Error("server still accepts connections after shutdown")
+	}
+}
+
+func TestRunGivesUpAfterTimeout(t *testing.T) {
+	started := make(chan struct{})
+	srv := newServer(t, slowHandler(started, 2*time.Second))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runErr := make(chan error, 1)
+	go func() { runErr <- Run(ctx, srv, 50*time.Millisecond) }()
+	waitListening(t, srv)
+
+	resp := get("http://" + srv.Addr + "/")
+	<-started
+	cancel()
+
+	select {
+	case err := <-runErr:
+		if err == nil {
+			t.Error("Run returned nil, want a shutdown error")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after the shutdown timeout")
+	}
+	if r := <-resp; r.err == nil {
+		t.Errorf("request outliving the timeout got %d, want its connection cut", r.status)
+	}
+}
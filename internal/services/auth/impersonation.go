@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bercivarga/go-basic-server/internal/apperr"
+	"github.com/bercivarga/go-basic-server/internal/audit"
+	"github.com/bercivarga/go-basic-server/internal/stores/user"
+	"github.com/bercivarga/go-basic-server/internal/utils"
+)
+
+var (
+	ErrImpersonateSelf     = apperr.New(apperr.ErrInvalid, "impersonate_self", "cannot impersonate yourself")
+	ErrImpersonationDenied = apperr.New(apperr.ErrForbidden, "impersonation_denied", "cannot impersonate a user with permissions you lack")
+)
+
+// Impersonation is an access token an admin uses to act as another user.
+type Impersonation struct {
+	AccessToken    string    `json:"access_token"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	ImpersonatedBy int64     `json:"impersonated_by"`
+}
+
+// Impersonate issues actorID a token for userID. It carries actorID in its
+// act claim and comes without a refresh token, so it ends after the
+// impersonation TTL. It also shows up in the user's session list and can
+// be revoked from there. Only users whose permissions the actor holds as
+// well can be impersonated, so it never grants more than the actor has.
+func (s *Service) Impersonate(ctx context.Context, actorID, userID int64, client Client) (*Impersonation, error) {
+	if actorID == userID {
+		return nil, ErrImpersonateSelf
+	}
+
+	target, err := s.UserStore.GetByID(ctx, userID)
+	if errors.Is(err, user.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if target.DisabledAt.Valid {
+		return nil, ErrAccountDisabled
+	}
+
+	ok, err := s.covers(ctx, actorID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrImpersonationDenied
+	}
+
+	familyID, err := utils.GenerateTokenFamilyID()
+	if err != nil {
+		return nil, fmt.Errorf("generating refresh token: %w", err)
+	}
+	accessToken, err := s.JwtManager.GenerateImpersonation(userID, target.Role, familyID, actorID, s.impersonateTTL)
+	if err != nil {
+		return nil, fmt.Errorf("generating token: %w", err)
+	}
+	// sessions need a refresh token, this one is never handed out
+	refreshToken, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return nil, fmt.Errorf("generating refresh token: %w", err)
+	}
+
+	expiresAt := time.Now().Add(s.impersonateTTL)
+	err = s.SessionStore.Create(ctx, userID, accessToken, refreshToken, expiresAt, expiresAt, familyID, client)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.WarnContext(ctx, "security: impersonation started", "user_id", userID, "by", actorID, "expires_at", expiresAt)
+	s.audit.Record(ctx, audit.Event{
+		Action:   audit.ActionUserImpersonated,
+		ActorID:  actorID,
+		TargetID: userID,
+		Metadata: map[string]any{"session_id": familyID, "expires_at": expiresAt},
+	})
+	return &Impersonation{AccessToken: accessToken, ExpiresAt: expiresAt, ImpersonatedBy: actorID}, nil
+}
@@ -0,0 +1,193 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bercivarga/go-basic-server/internal/apperr"
+	"github.com/bercivarga/go-basic-server/internal/audit"
+	"github.com/bercivarga/go-basic-server/internal/config"
+	"github.com/bercivarga/go-basic-server/internal/stores/loginfailure"
+)
+
+var (
+	ErrInvalidCredentials = apperr.New(apperr.ErrUnauthorized, "invalid_credentials", "invalid credentials")
+	ErrLockoutNotFound    = apperr.New(apperr.ErrNotFound, "lockout_not_found", "lockout not found")
+	ErrLoginLocked        = apperr.New(apperr.ErrTooManyRequests, "login_locked", "too many failed login attempts, try again later")
+)
+
+// LockedOutError is returned while an account or IP is locked after too
+// many failed logins. It wraps ErrLoginLocked.
+type LockedOutError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockedOutError) Error() string {
+	return ErrLoginLocked.Message
+}
+
+func (e *LockedOutError) Unwrap() error {
+	return ErrLoginLocked
+}
+
+// lockoutPolicy decides how long to lock after failures.
+type lockoutPolicy struct {
+	maxFailures      int64
+	maxFailuresPerIP int64
+	base             time.Duration
+	max              time.Duration
+}
+
+func newLockoutPolicy(c config.SecurityConfig) lockoutPolicy {
+	return lockoutPolicy{
+		maxFailures:      int64(c.LoginMaxFailures),
+		maxFailuresPerIP: int64(c.LoginMaxFailuresPerIP),
+		base:             c.LoginLockout,
+		max:              c.LoginLockoutMax,
+	}
+}
+
+// duration is the lockout after failures in a row, zero while still
+// under the limit: base once the limit is reached, doubling with every
+// failure after.
+func (p lockoutPolicy) duration(scope string, failures int64) time.Duration {
+	limit := p.maxFailures
+	if scope == loginfailure.ScopeIP {
+		limit = p.maxFailuresPerIP
+	}
+	if failures < limit {
+		return 0
+	}
+
+	d := p.base
+	for i := limit; i < failures && d < p.max; i++ {
+		d *= 2
+	}
+	return min(d, p.max)
+}
+
+// subjects are the keys failed logins are counted under. Unknown emails
+// are counted like registered ones so lockouts don't reveal which exist.
+func subjects(email, ip string) map[string]string {
+	m := map[string]string{loginfailure.ScopeAccount: accountSubject(email)}
+	if ip != "" {
+		m[loginfailure.ScopeIP] = ip
+	}
+	return m
+}
+
+func accountSubject(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// checkLockout returns a *LockedOutError when the account or the IP is
+// locked.
+func (s *Service) checkLockout(ctx context.Context, email, ip string) error {
+	var until time.Time
+	for scope, subject := range subjects(email, ip) {
+		u, err := s.FailureStore.LockedUntil(ctx, scope, subject)
+		if err != nil {
+			return fmt.Errorf("lockout check failed: %w", err)
+		}
+		if u.After(until) {
+			until = u
+		}
+	}
+	if wait := time.Until(until); wait > 0 {
+		return &LockedOutError{RetryAfter: wait}
+	}
+	return nil
+}
+
+// recordFailure counts a failed login and locks what went over its limit.
+// Errors are only logged: the login failed either way.
+func (s *Service) recordFailure(ctx context.Context, email, ip string) {
+	for scope, subject := range subjects(email, ip) {
+		failures, err := s.FailureStore.RecordFailure(ctx, scope, subject, s.lockout.max)
+		if err != nil {
+			slog.ErrorContext(ctx, "recording failed login", "error", err)
+			continue
+		}
+
+		d := s.lockout.duration(scope, failures)
+		if d == 0 {
+			continue
+		}
+		if err := s.FailureStore.Lock(ctx, scope, subject, time.Now().Add(d)); err != nil {
+			slog.ErrorContext(ctx, "locking login", "error", err)
+			continue
+		}
+		slog.WarnContext(ctx, "security: login locked after failed attempts",
+			"scope", scope,
+			"subject", subject,
+			"failures", failures,
+			"duration", d,
+		)
+		s.audit.Record(ctx, audit.Event{
+			Action:   audit.ActionLoginLocked,
+			Metadata: map[string]any{"scope": scope, "subject": subject, "failures": failures, "duration": d.String()},
+		})
+	}
+}
+
+// clearFailures resets the account after a successful login. The IP keeps
+// its count, or logging into an own account would reset it.
+func (s *Service) clearFailures(ctx context.Context, email string) {
+	err := s.FailureStore.Clear(ctx, loginfailure.ScopeAccount, accountSubject(email))
+	if err != nil && !errors.Is(err, loginfailure.ErrNotFound) {
+		slog.ErrorContext(ctx, "clearing failed logins", "error", err)
+	}
+}
+
+// Lockout is an active lockout as shown to admins.
+type Lockout struct {
+	Scope         string    `json:"scope"`
+	Subject       string    `json:"subject"`
+	Failures      int64     `json:"failures"`
+	LastFailureAt time.Time `json:"last_failure_at"`
+	LockedUntil   time.Time `json:"locked_until"`
+}
+
+func (s *Service) ListLockouts(ctx context.Context, limit, offset int64) ([]Lockout, error) {
+	rows, err := s.FailureStore.ListLocked(ctx, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("listing lockouts: %w", err)
+	}
+	out := make([]Lockout, len(rows))
+	for i, r := range rows {
+		out[i] = Lockout{
+			Scope:         r.Scope,
+			Subject:       r.Subject,
+			Failures:      r.Failures,
+			LastFailureAt: r.LastFailureAt,
+			LockedUntil:   r.LockedUntil.Time,
+		}
+	}
+	return out, nil
+}
+
+// ClearLockout lifts the lockout of an account (by email) or IP and
+// forgets its failures.
+func (s *Service) ClearLockout(ctx context.Context, scope, subject string) error {
+	if scope == loginfailure.ScopeAccount {
+		subject = accountSubject(subject)
+	}
+	err := s.FailureStore.Clear(ctx, scope, subject)
+	if errors.Is(err, loginfailure.ErrNotFound) {
+		return ErrLockoutNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("clearing lockout: %w", err)
+	}
+
+	slog.InfoContext(ctx, "security: login lockout cleared", "scope", scope, "subject", subject)
+	s.audit.Record(ctx, audit.Event{
+		Action:   audit.ActionLockoutCleared,
+		Metadata: map[string]any{"scope": scope, "subject": subject},
+	})
+	return nil
+}
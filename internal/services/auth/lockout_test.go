@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bercivarga/go-basic-server/internal/stores/loginfailure"
+)
+
+func TestLockoutDuration(t *testing.T) {
+	p := lockoutPolicy{
+		maxFailures:      5,
+		maxFailuresPerIP: 20,
+		base:             time.Minute,
+		max:              10 * time.Minute,
+	}
+
+	tests := []struct {
+		name     string
+		scope    string
+		failures int64
+		want     time.Duration
+	}{
+		{"account under the limit", loginfailure.ScopeAccount, 4, 0},
+		{"account at the limit", loginfailure.ScopeAccount, 5, time.Minute},
+		{"account over the limit", loginfailure.ScopeAccount, 6, 2 * time.Minute},
+		{"account doubling", loginfailure.ScopeAccount, 8, 8 * time.Minute},
+		{"account capped", loginfailure.ScopeAccount, 9, 10 * time.Minute},
+		{"account far over the limit", loginfailure.ScopeAccount, 1000, 10 * time.Minute},
+		{"ip under the limit", loginfailure.ScopeIP, 19, 0},
+		{"ip at the limit", loginfailure.ScopeIP, 20, time.Minute},
+		{"ip over the limit", loginfailure.ScopeIP, 21, 2 * time.Minute},
+		{"ip with the account limit", loginfailure.ScopeIP, 6, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.duration(tt.scope, tt.failures); got != tt.want {
+				t.Errorf("duration(%s, %d) = %v, want %v", tt.scope, tt.failures, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/bercivarga/go-basic-server/internal/apperr"
+	"github.com/bercivarga/go-basic-server/internal/audit"
+	"github.com/bercivarga/go-basic-server/internal/mailer"
+	"github.com/bercivarga/go-basic-server/internal/stores/passwordreset"
+	"github.com/bercivarga/go-basic-server/internal/stores/user"
+	"github.com/bercivarga/go-basic-server/internal/utils"
+)
+
+var ErrInvalidResetToken = apperr.New(apperr.ErrInvalid, "invalid_reset_token", "invalid or expired reset token")
+
+// ForgotPassword emails a single-use reset token to email. Unknown
+// addresses are not an error, so the endpoint does not reveal which
+// emails have an account.
+func (s *Service) ForgotPassword(ctx context.Context, email string) error {
+	u, err := s.UserStore.GetByEmail(ctx, email)
+	if errors.Is(err, user.ErrNotFound) {
+		slog.InfoContext(ctx, "password reset requested for unknown email")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("looking up user: %w", err)
+	}
+
+	token, err := utils.GenerateOneTimeToken()
+	if err != nil {
+		return fmt.Errorf("generating reset token: %w", err)
+	}
+
+	err = s.ResetStore.Create(ctx, u.ID, token, time.Now().Add(s.resetTTL))
+	if err != nil {
+		return fmt.Errorf("storing reset token: %w", err)
+	}
+
+	err = s.Mailer.Send(ctx, mailer.Message{
+		To:      u.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Someone asked to reset the password of your account.\n\n"+
+			"Your reset token is:\n\n    %s\n\n"+
+			"It is valid for %s and can be used once. "+
+			"If this was not you, ignore this email; your password stays the same.\n",
+			token, s.resetTTL),
+	})
+	if err != nil {
+		return fmt.Errorf("sending reset email: %w", err)
+	}
+	return nil
+}
+
+// ResetPassword sets a new password with a token from ForgotPassword and
+// logs the user out everywhere.
+func (s *Service) ResetPassword(ctx context.Context, token, newPassword string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), s.bcryptCost)
+	if err != nil {
+		return fmt.Errorf("hashing password: %w", err)
+	}
+
+	userID, err := s.ResetStore.Redeem(ctx, token, string(hash))
+	if errors.Is(err, passwordreset.ErrInvalidToken) {
+		return ErrInvalidResetToken
+	}
+	if err != nil {
+		return fmt.Errorf("resetting password: %w", err)
+	}
+
+	slog.InfoContext(ctx, "security: password reset, all sessions revoked", "user_id", userID)
+	s.audit.Record(ctx, audit.Event{Action: audit.ActionPasswordReset, ActorID: userID, TargetID: userID})
+	return nil
+}
@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestAssignRole(t *testing.T) {
+	ctx := context.Background()
+	s, db := newService(t)
+	// everything an admin may do with users and roles, except deleting
+	createRole(t, db, "support", PermUsersList, PermUsersRead, PermUsersWrite, PermRolesRead, PermRolesAssign)
+	actor := createUser(t, s, "support@example.com", "support")
+
+	tests := []struct {
+		name string
+		role string
+		want error
+	}{
+		{"role with a permission the actor lacks", "admin", ErrOutranked},
+		{"role without permissions", "user", nil},
+		{"actor's own role", "support", nil},
+		{"unknown role", "nope", ErrRoleNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := createUser(t, s, tt.name+"@example.com", "user")
+
+			err := s.AssignRole(ctx, actor, target, tt.role)
+			if !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+				t.Fatalf("AssignRole(%q) = %v, want %v", tt.role, err, tt.want)
+			}
+
+			perms, err := s.Permissions(ctx, target)
+			if err != nil {
+				t.Fatalf("Permissions: %v", err)
+			}
+			want := tt.role
+			if tt.want != nil {
+				want = "user"
+			}
+			if perms.Role != want {
+				t.Errorf("target has role %q, want %q", perms.Role, want)
+			}
+		})
+	}
+}
@@ -2,71 +2,246 @@ package auth
 
 import (
 	"context"
-	"database/sql"
+	"crypto/rand"
 	"errors"
+	"fmt"
+	"log/slog"
+	"time"
 
+	"github.com/bercivarga/go-basic-server/internal/apperr"
+	"github.com/bercivarga/go-basic-server/internal/audit"
 	"github.com/bercivarga/go-basic-server/internal/auth"
 	"github.com/bercivarga/go-basic-server/internal/config"
+	"github.com/bercivarga/go-basic-server/internal/db/querier"
+	"github.com/bercivarga/go-basic-server/internal/db/sqlc"
+	"github.com/bercivarga/go-basic-server/internal/mailer"
+	"github.com/bercivarga/go-basic-server/internal/stores/emailverification"
+	"github.com/bercivarga/go-basic-server/internal/stores/loginfailure"
+	"github.com/bercivarga/go-basic-server/internal/stores/passwordreset"
+	"github.com/bercivarga/go-basic-server/internal/stores/role"
 	"github.com/bercivarga/go-basic-server/internal/stores/session"
+	"github.com/bercivarga/go-basic-server/internal/stores/twofactor"
 	"github.com/bercivarga/go-basic-server/internal/stores/user"
 	"github.com/bercivarga/go-basic-server/internal/utils"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrRefreshTokenReused  = apperr.New(apperr.ErrUnauthorized, "refresh_token_reused", "refresh token reuse detected, all sessions of this login were revoked")
+	ErrInvalidRefreshToken = apperr.New(apperr.ErrUnauthorized, "invalid_refresh_token", "invalid or expired refresh token")
+	ErrEmailNotVerified    = apperr.New(apperr.ErrForbidden, "email_not_verified", "email address not verified")
+	ErrAccountDisabled     = apperr.New(apperr.ErrForbidden, "account_disabled", "account disabled")
 )
 
 type Service struct {
 	UserStore    *user.Store
 	SessionStore *session.Store
+	ResetStore   *passwordreset.Store
+	VerifyStore  *emailverification.Store
+	TOTPStore    *twofactor.Store
+	FailureStore *loginfailure.Store
+	RoleStore    *role.Store
 	JwtManager   *auth.JWTManager
+	Mailer       mailer.Mailer
+
+	audit           *audit.Recorder
+	bcryptCost      int
+	resetTTL        time.Duration
+	verifyTTL       time.Duration
+	requireVerified bool
+	baseURL         string
+	mfaTTL          time.Duration
+	impersonateTTL  time.Duration
+	totpIssuer      string
+	lockout         lockoutPolicy
+	// dummyHash is compared against for unknown emails, so they take as
+	// long as a wrong password.
+	dummyHash string
 }
 
-func New(db *sql.DB, config *config.Config) *Service {
+func New(db *querier.DB, config *config.Config, mail mailer.Mailer, recorder *audit.Recorder) (*Service, error) {
+	keys, err := loadKeys(config.JWT)
+	if err != nil {
+		return nil, fmt.Errorf("jwt keys: %w", err)
+	}
+
 	userStore := user.NewStore(db)
-	sessionStore := session.NewStore(db)
-	jwtManager := auth.NewJWTManager(config.JWTSecret)
-	return &Service{UserStore: userStore, SessionStore: sessionStore, JwtManager: jwtManager}
+	sessionStore := session.NewStore(db, config.Security.TokenPepper)
+	jwtManager := auth.NewJWTManager(keys, config.JWT.Issuer, config.JWT.Audience, config.JWT.Leeway, config.JWT.Duration, config.JWT.RefreshDuration)
+	resetStore := passwordreset.NewStore(db, config.Security.TokenPepper)
+	verifyStore := emailverification.NewStore(db, config.Security.TokenPepper)
+	totpStore := twofactor.NewStore(db, config.Security.TokenPepper)
+	failureStore := loginfailure.NewStore(db)
+	roleStore := role.NewStore(db)
+
+	dummyHash, err := bcrypt.GenerateFromPassword([]byte(rand.Text()), config.Security.BcryptCost)
+	if err != nil {
+		return nil, fmt.Errorf("dummy password hash: %w", err)
+	}
+	return &Service{
+		UserStore:       userStore,
+		SessionStore:    sessionStore,
+		ResetStore:      resetStore,
+		VerifyStore:     verifyStore,
+		TOTPStore:       totpStore,
+		FailureStore:    failureStore,
+		RoleStore:       roleStore,
+		JwtManager:      jwtManager,
+		Mailer:          mail,
+		audit:           recorder,
+		bcryptCost:      config.Security.BcryptCost,
+		resetTTL:        config.Security.PasswordResetTTL,
+		verifyTTL:       config.Security.EmailVerificationTTL,
+		requireVerified: config.Security.RequireEmailVerification,
+		baseURL:         config.Server.BaseURL(),
+		mfaTTL:          config.Security.MFAChallengeTTL,
+		impersonateTTL:  config.Security.ImpersonationTTL,
+		totpIssuer:      config.Security.TOTPIssuer,
+		lockout:         newLockoutPolicy(config.Security),
+		dummyHash:       string(dummyHash),
+	}, nil
 }
 
-func (s *Service) CheckRole(ctx context.Context, userID int64, expected string) error {
-	role, err := s.UserStore.GetRole(ctx, userID)
+// loadKeys signs with the private key when one is configured. The shared
+// secret then only verifies, which lets HS256 tokens issued before the
+// switch run out instead of logging everyone out.
+func loadKeys(c config.JWTConfig) (*auth.KeySet, error) {
+	if c.PrivateKey == "" {
+		return auth.NewKeySet(auth.NewHMACKey(c.Secret), nil, 0)
+	}
+
+	signing, err := auth.ParsePrivateKeyPEM([]byte(c.PrivateKey))
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("private key: %w", err)
 	}
-	if role != expected {
-		return errors.New("forbidden")
+
+	var previous []*auth.Key
+	if c.PreviousKeys != "" {
+		previous, err = auth.ParsePublicKeysPEM([]byte(c.PreviousKeys))
+		if err != nil {
+			return nil, fmt.Errorf("previous keys: %w", err)
+		}
+	}
+	if c.Secret != "" {
+		previous = append(previous, auth.NewHMACKey(c.Secret))
 	}
-	return nil
-}
 
-func (s *Service) GetRole(ctx context.Context, userID int64) (string, error) {
-	return s.UserStore.GetRole(ctx, userID)
+	return auth.NewKeySet(signing, previous, c.RotationGracePeriod)
 }
 
+// Client describes where a request came from, recorded on sessions and
+// used for login lockouts.
+type Client = session.Client
+
 type TokenPair struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
 }
 
-func (s *Service) Login(ctx context.Context, email, password string) (TokenPair, error) {
-	user, err := s.UserStore.GetByEmail(ctx, email)
+// LoginResult is the outcome of the password step. Accounts without 2FA
+// get their tokens right away; otherwise MFAToken has to be traded for
+// them together with a code, see LoginMFA.
+type LoginResult struct {
+	*TokenPair
+	MFARequired bool   `json:"mfa_required,omitempty"`
+	MFAToken    string `json:"mfa_token,omitempty"`
+}
+
+// Login checks email and password. Unknown emails and wrong passwords
+// both give ErrInvalidCredentials and are counted towards a lockout of
+// the account and of the client IP, see LockedOutError.
+func (s *Service) Login(ctx context.Context, email, password string, client Client) (LoginResult, error) {
+	if err := s.checkLockout(ctx, email, client.IP); err != nil {
+		s.loginFailed(ctx, 0, email, "locked_out", client)
+		return LoginResult{}, err
+	}
+
+	u, err := s.UserStore.GetByEmail(ctx, email)
+	if errors.Is(err, user.ErrNotFound) {
+		utils.CheckPasswordHash(password, s.dummyHash)
+		s.recordFailure(ctx, email, client.IP)
+		s.loginFailed(ctx, 0, email, "unknown_email", client)
+		return LoginResult{}, ErrInvalidCredentials
+	}
+	if err != nil {
+		return LoginResult{}, fmt.Errorf("looking up user: %w", err)
+	}
+	if !utils.CheckPasswordHash(password, u.PasswordHash) {
+		s.recordFailure(ctx, email, client.IP)
+		s.loginFailed(ctx, u.ID, email, "wrong_password", client)
+		return LoginResult{}, ErrInvalidCredentials
+	}
+	s.clearFailures(ctx, email)
+
+	if u.DisabledAt.Valid {
+		s.loginFailed(ctx, u.ID, email, "account_disabled", client)
+		return LoginResult{}, ErrAccountDisabled
+	}
+	if s.requireVerified && !u.EmailVerifiedAt.Valid {
+		s.loginFailed(ctx, u.ID, email, "email_not_verified", client)
+		return LoginResult{}, ErrEmailNotVerified
+	}
+
+	if u.TotpEnabledAt.Valid {
+		mfaToken, err := s.JwtManager.GenerateMFAChallenge(u.ID, s.mfaTTL)
+		if err != nil {
+			return LoginResult{}, fmt.Errorf("generating token: %w", err)
+		}
+		return LoginResult{MFARequired: true, MFAToken: mfaToken}, nil
+	}
+
+	tokens, err := s.issueSession(ctx, u.ID, u.Role, client)
 	if err != nil {
-		return TokenPair{}, errors.New("user not found")
+		return LoginResult{}, err
 	}
-	if !utils.CheckPasswordHash(password, user.PasswordHash) {
-		return TokenPair{}, errors.New("invalid password")
+	s.recordLogin(ctx, u.ID, client, map[string]any{"mfa": false})
+	return LoginResult{TokenPair: &tokens}, nil
+}
+
+// recordLogin writes the audit event of a successful login.
+func (s *Service) recordLogin(ctx context.Context, userID int64, client Client, metadata map[string]any) {
+	s.audit.Record(ctx, audit.Event{
+		Action:    audit.ActionLogin,
+		ActorID:   userID,
+		TargetID:  userID,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+		Metadata:  metadata,
+	})
+}
+
+// loginFailed writes the audit event of a failed login. userID is 0 when
+// the email is unknown or was not looked up.
+func (s *Service) loginFailed(ctx context.Context, userID int64, email, reason string, client Client) {
+	s.audit.Record(ctx, audit.Event{
+		Action:    audit.ActionLoginFailed,
+		TargetID:  userID,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+		Metadata:  map[string]any{"email": email, "reason": reason},
+	})
+}
+
+// issueSession starts a new login (token family) for the user.
+func (s *Service) issueSession(ctx context.Context, userID int64, role string, client Client) (TokenPair, error) {
+	familyID, err := utils.GenerateTokenFamilyID()
+	if err != nil {
+		return TokenPair{}, fmt.Errorf("generating refresh token: %w", err)
 	}
 
-	accessToken, err := s.JwtManager.Generate(user.ID)
+	accessToken, err := s.JwtManager.Generate(userID, role, familyID)
 	if err != nil {
-		return TokenPair{}, errors.New("token generation failed")
+		return TokenPair{}, fmt.Errorf("generating token: %w", err)
 	}
 
 	refreshToken, err := utils.GenerateRefreshToken()
 	if err != nil {
-		return TokenPair{}, errors.New("refresh token generation failed")
+		return TokenPair{}, fmt.Errorf("generating refresh token: %w", err)
 	}
 
 	accessExp, refreshExp := s.JwtManager.CreateExpiry()
 
-	err = s.SessionStore.Create(ctx, user.ID, accessToken, refreshToken, accessExp, refreshExp)
+	err = s.SessionStore.Create(ctx, userID, accessToken, refreshToken, accessExp, refreshExp, familyID, client)
 	if err != nil {
 		return TokenPair{}, err
 	}
@@ -77,43 +252,90 @@ func (s *Service) Login(ctx context.Context, email, password string) (TokenPair,
 	}, nil
 }
 
-func (s *Service) RefreshToken(ctx context.Context, refreshToken string) (TokenPair, error) {
-	session, err := s.SessionStore.GetByRefreshToken(ctx, refreshToken)
+// RefreshToken rotates the session behind refreshToken. A refresh token
+// can only be used once: presenting one that was already rotated means it
+// leaked, so the whole token family is revoked and ErrRefreshTokenReused
+// returned.
+func (s *Service) RefreshToken(ctx context.Context, refreshToken string, client Client) (TokenPair, error) {
+	current, err := s.SessionStore.GetByRefreshToken(ctx, refreshToken)
+	if errors.Is(err, session.ErrSessionNotFound) || errors.Is(err, session.ErrSessionExpired) {
+		return TokenPair{}, ErrInvalidRefreshToken
+	}
 	if err != nil {
-		return TokenPair{}, errors.New("invalid or expired refresh token")
+		return TokenPair{}, fmt.Errorf("looking up session: %w", err)
+	}
+
+	if current.RotatedAt.Valid {
+		return TokenPair{}, s.revokeFamily(ctx, current)
 	}
 
-	accessToken, err := s.JwtManager.Generate(session.UserID)
+	role, err := s.UserStore.GetRole(ctx, current.UserID)
+	if errors.Is(err, user.ErrNotFound) {
+		return TokenPair{}, ErrInvalidRefreshToken
+	}
 	if err != nil {
-		return TokenPair{}, errors.New("token generation failed")
+		return TokenPair{}, fmt.Errorf("looking up role: %w", err)
 	}
 
-	newRefreshToken, err := utils.GenerateRefreshToken()
+	accessToken, err := s.JwtManager.Generate(current.UserID, role, current.FamilyID)
 	if err != nil {
-		return TokenPair{}, errors.New("refresh token generation failed")
+		return TokenPair{}, fmt.Errorf("generating token: %w", err)
 	}
 
-	if err := s.SessionStore.DeleteByRefreshToken(ctx, refreshToken); err != nil {
-		return TokenPair{}, errors.New("session cleanup failed")
+	newRefreshToken, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return TokenPair{}, fmt.Errorf("generating refresh token: %w", err)
 	}
 
 	accessTokenExpireAt, refreshTokenExpireAt := s.JwtManager.CreateExpiry()
 
-	err = s.SessionStore.Create(ctx, session.UserID, accessToken, newRefreshToken, accessTokenExpireAt, refreshTokenExpireAt)
+	err = s.SessionStore.Rotate(ctx, current, accessToken, newRefreshToken, accessTokenExpireAt, refreshTokenExpireAt, client)
+	if errors.Is(err, session.ErrSessionRotated) {
+		// lost the race against another refresh with the same token
+		return TokenPair{}, s.revokeFamily(ctx, current)
+	}
 	if err != nil {
-		return TokenPair{}, errors.New("session creation failed")
+		return TokenPair{}, fmt.Errorf("rotating session: %w", err)
 	}
 
+	s.audit.Record(ctx, audit.Event{
+		Action:    audit.ActionRefresh,
+		ActorID:   current.UserID,
+		TargetID:  current.UserID,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+		Metadata:  map[string]any{"session_id": current.FamilyID},
+	})
+
 	return TokenPair{
 		AccessToken:  accessToken,
 		RefreshToken: newRefreshToken,
 	}, nil
 }
 
+func (s *Service) revokeFamily(ctx context.Context, reused *sqlc.Session) error {
+	slog.WarnContext(ctx, "security: refresh token reuse detected, revoking token family",
+		"user_id", reused.UserID,
+		"session_id", reused.ID,
+		"family_id", reused.FamilyID,
+	)
+	if err := s.SessionStore.RevokeFamily(ctx, reused.FamilyID); err != nil {
+		return fmt.Errorf("revoking session family: %w", err)
+	}
+	s.audit.Record(ctx, audit.Event{
+		Action:   audit.ActionRefreshReused,
+		ActorID:  reused.UserID,
+		TargetID: reused.UserID,
+		Metadata: map[string]any{"session_id": reused.FamilyID},
+	})
+	return ErrRefreshTokenReused
+}
+
 func (s *Service) Logout(ctx context.Context, accessToken string) error {
 	err := s.SessionStore.DeleteByToken(ctx, accessToken)
 	if err != nil {
-		return errors.New("could not delete session")
+		return fmt.Errorf("deleting session: %w", err)
 	}
+	s.audit.Record(ctx, audit.Event{Action: audit.ActionLogout})
 	return nil
 }
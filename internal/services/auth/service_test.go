@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/bercivarga/go-basic-server/internal/audit"
+	"github.com/bercivarga/go-basic-server/internal/config"
+	"github.com/bercivarga/go-basic-server/internal/db/dbtest"
+	"github.com/bercivarga/go-basic-server/internal/db/querier"
+	auditStore "github.com/bercivarga/go-basic-server/internal/stores/audit"
+	"github.com/bercivarga/go-basic-server/internal/utils"
+)
+
+// newService returns a service on a fresh database with the default
+// configuration, signing with a shared secret and mailing nothing.
+func newService(t *testing.T) (*Service, *querier.DB) {
+	t.Helper()
+	db := dbtest.Open(t)
+
+	cfg := config.Default()
+	cfg.JWT.Secret = "test-secret"
+	cfg.Security.BcryptCost = bcrypt.MinCost
+	cfg.Security.TokenPepper = dbtest.TokenPepper
+
+	s, err := New(db, cfg, nil, audit.NewRecorder(auditStore.NewStore(db)))
+	if err != nil {
+		t.Fatalf("creating service: %v", err)
+	}
+	return s, db
+}
+
+// createUser adds a user with the given role and returns their ID.
+func createUser(t *testing.T, s *Service, email, role string) int64 {
+	t.Helper()
+	ctx := context.Background()
+	u, err := s.UserStore.Create(ctx, email, "hash")
+	if err != nil {
+		t.Fatalf("creating %s: %v", email, err)
+	}
+	if err := s.RoleStore.Assign(ctx, u.ID, role); err != nil {
+		t.Fatalf("assigning %s to %s: %v", role, email, err)
+	}
+	return u.ID
+}
+
+// createRole adds a role granting perms.
+func createRole(t *testing.T, db *querier.DB, name string, perms ...string) {
+	t.Helper()
+	if _, err := db.Exec("INSERT INTO roles (name, description) VALUES (?, '')", name); err != nil {
+		t.Fatalf("creating role %s: %v", name, err)
+	}
+	for _, perm := range perms {
+		_, err := db.Exec("INSERT INTO role_permissions (role, permission) VALUES (?, ?)", name, perm)
+		if err != nil {
+			t.Fatalf("granting %s to %s: %v", perm, name, err)
+		}
+	}
+}
+
+// lockDatabase takes an exclusive lock on db until the test ends, so
+// every query fails as busy.
+func lockDatabase(t *testing.T, db *querier.DB) {
+	t.Helper()
+	ctx := context.Background()
+	conn, err := db.DB.Conn(ctx)
+	if err != nil {
+		t.Fatalf("getting connection: %v", err)
+	}
+	if _, err := conn.ExecContext(ctx, "BEGIN EXCLUSIVE"); err != nil {
+		t.Fatalf("locking database: %v", err)
+	}
+	t.Cleanup(func() {
+		conn.ExecContext(ctx, "ROLLBACK")
+		conn.Close()
+	})
+}
+
+// problem returns the status and code a handler answers err with.
+func problem(t *testing.T, err error) (int, string) {
+	t.Helper()
+	if err == nil {
+		t.Fatal("got no error")
+	}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	p := utils.WriteProblem(httptest.NewRecorder(), r, err)
+	return p.Status, p.Code
+}
+
+func TestRefreshTokenErrors(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("unknown token is 401", func(t *testing.T) {
+		s, _ := newService(t)
+		_, err := s.RefreshToken(ctx, "unknown", Client{})
+		if status, code := problem(t, err); status != http.StatusUnauthorized || code != "invalid_refresh_token" {
+			t.Errorf("got %d %s, want 401 invalid_refresh_token", status, code)
+		}
+	})
+
+	t.Run("locked database is 503", func(t *testing.T) {
+		s, db := newService(t)
+		lockDatabase(t, db)
+		_, err := s.RefreshToken(ctx, "unknown", Client{})
+		if status, code := problem(t, err); status != http.StatusServiceUnavailable || code != "database_unavailable" {
+			t.Errorf("got %d %s, want 503 database_unavailable", status, code)
+		}
+	})
+}
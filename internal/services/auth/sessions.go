@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bercivarga/go-basic-server/internal/apperr"
+	"github.com/bercivarga/go-basic-server/internal/audit"
+	"github.com/bercivarga/go-basic-server/internal/stores/session"
+)
+
+var ErrSessionNotFound = apperr.New(apperr.ErrNotFound, "session_not_found", "session not found")
+
+// SessionInfo is one login of a user as shown to them. ID is the sid
+// claim of its access tokens and stays the same across refreshes.
+type SessionInfo struct {
+	ID         string     `json:"id"`
+	UserAgent  string     `json:"user_agent"`
+	IP         string     `json:"ip"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	Current    bool       `json:"current"`
+}
+
+// ListSessions returns the active logins of userID, flagging currentID,
+// the login the request was made with.
+func (s *Service) ListSessions(ctx context.Context, userID int64, currentID string) ([]SessionInfo, error) {
+	rows, err := s.SessionStore.ListActive(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("listing sessions: %w", err)
+	}
+
+	out := make([]SessionInfo, len(rows))
+	for i, r := range rows {
+		out[i] = SessionInfo{
+			ID:        r.FamilyID,
+			UserAgent: r.UserAgent,
+			IP:        r.Ip,
+			ExpiresAt: r.RefreshExpiresAt,
+			Current:   r.FamilyID == currentID,
+		}
+		if r.LastUsedAt.Valid {
+			out[i].LastUsedAt = &r.LastUsedAt.Time
+		}
+	}
+	return out, nil
+}
+
+// RevokeSession signs one login of userID out, on whatever device it is.
+func (s *Service) RevokeSession(ctx context.Context, userID int64, sessionID string) error {
+	err := s.SessionStore.RevokeUserFamily(ctx, userID, sessionID)
+	if errors.Is(err, session.ErrSessionNotFound) {
+		return ErrSessionNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("revoking session: %w", err)
+	}
+
+	slog.InfoContext(ctx, "session revoked", "user_id", userID, "family_id", sessionID)
+	s.audit.Record(ctx, audit.Event{
+		Action:   audit.ActionSessionRevoked,
+		TargetID: userID,
+		Metadata: map[string]any{"session_id": sessionID},
+	})
+	return nil
+}
+
+// LogoutAll signs userID out of every session, the current one included.
+func (s *Service) LogoutAll(ctx context.Context, userID int64) error {
+	if err := s.SessionStore.DeleteByUser(ctx, userID); err != nil {
+		return fmt.Errorf("deleting sessions: %w", err)
+	}
+
+	slog.InfoContext(ctx, "all sessions revoked", "user_id", userID)
+	s.audit.Record(ctx, audit.Event{Action: audit.ActionLogoutAll, TargetID: userID})
+	return nil
+}
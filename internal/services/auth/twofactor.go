@@ -0,0 +1,226 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bercivarga/go-basic-server/internal/apperr"
+	"github.com/bercivarga/go-basic-server/internal/audit"
+	"github.com/bercivarga/go-basic-server/internal/auth"
+	"github.com/bercivarga/go-basic-server/internal/stores/twofactor"
+	"github.com/bercivarga/go-basic-server/internal/stores/user"
+)
+
+const recoveryCodeCount = 10
+
+var (
+	ErrTOTPAlreadyEnabled = apperr.New(apperr.ErrConflict, "2fa_already_enabled", "two-factor authentication is already enabled")
+	ErrTOTPNotPending     = apperr.New(apperr.ErrConflict, "2fa_not_pending", "no two-factor setup in progress")
+	ErrInvalidMFACode     = apperr.New(apperr.ErrUnauthorized, "invalid_mfa_code", "invalid two-factor code")
+	ErrInvalidMFAToken    = apperr.New(apperr.ErrUnauthorized, "invalid_mfa_token", "invalid or expired mfa token")
+	// ErrInvalidSetupCode is a wrong code while confirming setup, where
+	// the caller is already logged in.
+	ErrInvalidSetupCode = apperr.New(apperr.ErrInvalid, "invalid_setup_code", "invalid two-factor code")
+	ErrUserNotFound     = apperr.New(apperr.ErrNotFound, "user_not_found", "user not found")
+)
+
+// TOTPSetup is handed to the user once, to add the account to an
+// authenticator app.
+type TOTPSetup struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// SetupTOTP creates a new secret for the user. 2FA only turns on once a
+// code from it is confirmed, until then setup can be repeated.
+func (s *Service) SetupTOTP(ctx context.Context, userID int64) (*TOTPSetup, error) {
+	u, err := s.UserStore.GetByID(ctx, userID)
+	if errors.Is(err, user.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fetching user %d: %w", userID, err)
+	}
+	if u.TotpEnabledAt.Valid {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return nil, fmt.Errorf("generating secret: %w", err)
+	}
+
+	err = s.TOTPStore.SetSecret(ctx, userID, secret)
+	if errors.Is(err, twofactor.ErrAlreadyEnabled) {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+	if err != nil {
+		return nil, fmt.Errorf("storing secret: %w", err)
+	}
+
+	return &TOTPSetup{
+		Secret: secret,
+		URI:    auth.TOTPURI(s.totpIssuer, u.Email, secret),
+	}, nil
+}
+
+// ConfirmTOTP turns 2FA on when code matches the secret from SetupTOTP and
+// returns the recovery codes. The store keeps only peppered hashes of them
+// (utils.HashToken of the normalized code), so this is the only time they
+// can be shown.
+func (s *Service) ConfirmTOTP(ctx context.Context, userID int64, code string) ([]string, error) {
+	settings, err := s.TOTPStore.Get(ctx, userID)
+	if errors.Is(err, twofactor.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fetching two-factor settings: %w", err)
+	}
+	if settings.Enabled {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+	if settings.Secret == "" {
+		return nil, ErrTOTPNotPending
+	}
+
+	step, ok := auth.ValidateTOTP(settings.Secret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidSetupCode
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	normalized := make([]string, recoveryCodeCount)
+	for i := range codes {
+		codes[i] = generateRecoveryCode()
+		normalized[i] = normalizeRecoveryCode(codes[i])
+	}
+
+	err = s.TOTPStore.Enable(ctx, userID, step, normalized)
+	if errors.Is(err, twofactor.ErrNotPending) {
+		// enabled or reset concurrently
+		return nil, ErrTOTPNotPending
+	}
+	if err != nil {
+		return nil, fmt.Errorf("enabling two-factor authentication: %w", err)
+	}
+
+	slog.InfoContext(ctx, "security: two-factor authentication enabled", "user_id", userID)
+	s.audit.Record(ctx, audit.Event{Action: audit.ActionTwoFactorEnabled, ActorID: userID, TargetID: userID})
+	return codes, nil
+}
+
+// LoginMFA finishes a login started by Login. code is either the current
+// TOTP code or one of the recovery codes, each of which works once. Wrong
+// codes count towards the same lockout as wrong passwords.
+func (s *Service) LoginMFA(ctx context.Context, mfaToken, code string, client Client) (TokenPair, error) {
+	userID, err := s.JwtManager.VerifyMFAChallenge(mfaToken)
+	if err != nil {
+		return TokenPair{}, ErrInvalidMFAToken
+	}
+
+	u, err := s.UserStore.GetByID(ctx, userID)
+	if err != nil && !errors.Is(err, user.ErrNotFound) {
+		return TokenPair{}, fmt.Errorf("fetching user %d: %w", userID, err)
+	}
+	if err != nil || !u.TotpEnabledAt.Valid {
+		// 2FA was reset since the password step, log in again
+		return TokenPair{}, ErrInvalidMFAToken
+	}
+	if u.DisabledAt.Valid {
+		return TokenPair{}, ErrAccountDisabled
+	}
+	if err := s.checkLockout(ctx, u.Email, client.IP); err != nil {
+		s.loginFailed(ctx, userID, u.Email, "locked_out", client)
+		return TokenPair{}, err
+	}
+
+	code = strings.TrimSpace(code)
+	recoveryCode := !isTOTPCode(code)
+	if !recoveryCode {
+		step, ok := auth.ValidateTOTP(u.TotpSecret.String, code, time.Now())
+		if !ok {
+			s.recordFailure(ctx, u.Email, client.IP)
+			s.loginFailed(ctx, userID, u.Email, "wrong_code", client)
+			return TokenPair{}, ErrInvalidMFACode
+		}
+		err = s.TOTPStore.UseStep(ctx, userID, step)
+	} else {
+		err = s.TOTPStore.UseRecoveryCode(ctx, userID, normalizeRecoveryCode(code))
+		if err == nil {
+			slog.WarnContext(ctx, "security: recovery code used", "user_id", userID)
+		}
+	}
+	if errors.Is(err, twofactor.ErrCodeUsed) {
+		s.recordFailure(ctx, u.Email, client.IP)
+		s.loginFailed(ctx, userID, u.Email, "wrong_code", client)
+		return TokenPair{}, ErrInvalidMFACode
+	}
+	if err != nil {
+		return TokenPair{}, fmt.Errorf("verifying code: %w", err)
+	}
+	s.clearFailures(ctx, u.Email)
+
+	tokens, err := s.issueSession(ctx, userID, u.Role, client)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	s.recordLogin(ctx, userID, client, map[string]any{"mfa": true, "recovery_code": recoveryCode})
+	return tokens, nil
+}
+
+// ResetTOTP turns 2FA off for a user who lost their device and their
+// recovery codes. Meant for admins; users set it up again afterwards.
+// actorID has to hold every permission userID holds, a reset must not
+// open up an account that may do more than the admin.
+func (s *Service) ResetTOTP(ctx context.Context, actorID, userID int64) error {
+	_, err := s.UserStore.GetByID(ctx, userID)
+	if errors.Is(err, user.ErrNotFound) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("fetching user %d: %w", userID, err)
+	}
+	ok, err := s.covers(ctx, actorID, userID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrOutranked
+	}
+	if err := s.TOTPStore.Reset(ctx, userID); err != nil {
+		return fmt.Errorf("resetting two-factor authentication: %w", err)
+	}
+
+	slog.WarnContext(ctx, "security: two-factor authentication reset", "user_id", userID, "by", actorID)
+	s.audit.Record(ctx, audit.Event{Action: audit.ActionTwoFactorReset, ActorID: actorID, TargetID: userID})
+	return nil
+}
+
+func isTOTPCode(code string) bool {
+	if len(code) != 6 {
+		return false
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// generateRecoveryCode returns a code like "k3jd7-q2mxa" (50 bits).
+func generateRecoveryCode() string {
+	t := strings.ToLower(rand.Text()[:10])
+	return t[:5] + "-" + t[5:]
+}
+
+// normalizeRecoveryCode makes the dash and case optional when typing a
+// code in.
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(code, "-", ""))
+}
@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestConfirmTOTPErrors(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("unknown user is 404", func(t *testing.T) {
+		s, _ := newService(t)
+		_, err := s.ConfirmTOTP(ctx, 99, "123456")
+		if status, code := problem(t, err); status != http.StatusNotFound || code != "user_not_found" {
+			t.Errorf("got %d %s, want 404 user_not_found", status, code)
+		}
+	})
+
+	t.Run("no setup is 409", func(t *testing.T) {
+		s, _ := newService(t)
+		userID := createUser(t, s, "user@example.com", "user")
+		_, err := s.ConfirmTOTP(ctx, userID, "123456")
+		if status, code := problem(t, err); status != http.StatusConflict || code != "2fa_not_pending" {
+			t.Errorf("got %d %s, want 409 2fa_not_pending", status, code)
+		}
+	})
+
+	t.Run("locked database is 503", func(t *testing.T) {
+		s, db := newService(t)
+		userID := createUser(t, s, "user@example.com", "user")
+		lockDatabase(t, db)
+		_, err := s.ConfirmTOTP(ctx, userID, "123456")
+		if status, code := problem(t, err); status != http.StatusServiceUnavailable || code != "database_unavailable" {
+			t.Errorf("got %d %s, want 503 database_unavailable", status, code)
+		}
+	})
+}
@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"github.com/bercivarga/go-basic-server/internal/apperr"
+	"github.com/bercivarga/go-basic-server/internal/audit"
+	"github.com/bercivarga/go-basic-server/internal/mailer"
+	"github.com/bercivarga/go-basic-server/internal/stores/emailverification"
+	"github.com/bercivarga/go-basic-server/internal/stores/user"
+	"github.com/bercivarga/go-basic-server/internal/utils"
+)
+
+var ErrInvalidVerificationToken = apperr.New(apperr.ErrInvalid, "invalid_verification_token", "invalid or expired verification token")
+
+// SendVerificationEmail emails a verification link to email. Unknown and
+// already verified addresses are silently skipped, so the resend endpoint
+// does not reveal which emails have an account.
+func (s *Service) SendVerificationEmail(ctx context.Context, email string) error {
+	u, err := s.UserStore.GetByEmail(ctx, email)
+	if errors.Is(err, user.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("looking up user: %w", err)
+	}
+	if u.EmailVerifiedAt.Valid {
+		return nil
+	}
+
+	token, err := utils.GenerateOneTimeToken()
+	if err != nil {
+		return fmt.Errorf("generating verification token: %w", err)
+	}
+
+	err = s.VerifyStore.Create(ctx, u.ID, token, time.Now().Add(s.verifyTTL))
+	if err != nil {
+		return fmt.Errorf("storing verification token: %w", err)
+	}
+
+	link := s.baseURL + "/auth/verify?token=" + url.QueryEscape(token)
+	err = s.Mailer.Send(ctx, mailer.Message{
+		To:      u.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Please confirm this email address by opening the link below:\n\n    %s\n\n"+
+			"The link is valid for %s. If you did not sign up, ignore this email.\n",
+			link, s.verifyTTL),
+	})
+	if err != nil {
+		return fmt.Errorf("sending verification email: %w", err)
+	}
+	return nil
+}
+
+// VerifyEmail marks the address behind token as verified.
+func (s *Service) VerifyEmail(ctx context.Context, token string) error {
+	userID, err := s.VerifyStore.Redeem(ctx, token)
+	if errors.Is(err, emailverification.ErrInvalidToken) {
+		return ErrInvalidVerificationToken
+	}
+	if err != nil {
+		return fmt.Errorf("verifying email: %w", err)
+	}
+
+	slog.InfoContext(ctx, "email verified", "user_id", userID)
+	s.audit.Record(ctx, audit.Event{Action: audit.ActionEmailVerified, ActorID: userID, TargetID: userID})
+	return nil
+}
@@ -0,0 +1,123 @@
+package user
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/bercivarga/go-basic-server/internal/audit"
+	"github.com/bercivarga/go-basic-server/internal/config"
+	"github.com/bercivarga/go-basic-server/internal/db/dbtest"
+	"github.com/bercivarga/go-basic-server/internal/db/querier"
+	auditStore "github.com/bercivarga/go-basic-server/internal/stores/audit"
+	"github.com/bercivarga/go-basic-server/internal/utils"
+)
+
+func newService(t *testing.T) (*Service, *querier.DB) {
+	t.Helper()
+	db := dbtest.Open(t)
+	cfg := &config.Config{Security: config.SecurityConfig{BcryptCost: bcrypt.MinCost}}
+	return New(db, cfg, audit.NewRecorder(auditStore.NewStore(db))), db
+}
+
+// createUser adds a user with the given role and returns their ID.
+func createUser(t *testing.T, s *Service, email, role string) int64 {
+	t.Helper()
+	ctx := context.Background()
+	u, err := s.store.Create(ctx, email, "hash")
+	if err != nil {
+		t.Fatalf("creating %s: %v", email, err)
+	}
+	if err := s.roles.Assign(ctx, u.ID, role); err != nil {
+		t.Fatalf("assigning %s to %s: %v", role, email, err)
+	}
+	return u.ID
+}
+
+// hold starts a transaction on the read pool that runs stmt and keeps its
+// lock until the test ends. A SELECT blocks commits, a write blocks other
+// writes.
+func hold(t *testing.T, db *querier.DB, stmt string) {
+	t.Helper()
+	tx, err := db.DB.BeginTx(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("beginning lock transaction: %v", err)
+	}
+	t.Cleanup(func() { tx.Rollback() })
+	if _, err := tx.Exec(stmt); err != nil {
+		t.Fatalf("taking lock: %v", err)
+	}
+}
+
+// problem returns the status and code a handler answers err with.
+func problem(t *testing.T, err error) (int, string) {
+	t.Helper()
+	if err == nil {
+		t.Fatal("got no error")
+	}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	p := utils.WriteProblem(httptest.NewRecorder(), r, err)
+	return p.Status, p.Code
+}
+
+func TestErrorStatuses(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("unknown user is 404", func(t *testing.T) {
+		s, _ := newService(t)
+		admin := createUser(t, s, "admin@example.com", "admin")
+
+		_, err := s.GetUserByID(ctx, 99)
+		if status, code := problem(t, err); status != http.StatusNotFound || code != "user_not_found" {
+			t.Errorf("GetUserByID: got %d %s, want 404 user_not_found", status, code)
+		}
+		err = s.DeleteUser(ctx, admin, 99)
+		if status, code := problem(t, err); status != http.StatusNotFound || code != "user_not_found" {
+			t.Errorf("DeleteUser: got %d %s, want 404 user_not_found", status, code)
+		}
+	})
+
+	t.Run("taken email is 409", func(t *testing.T) {
+		s, _ := newService(t)
+		admin := createUser(t, s, "admin@example.com", "admin")
+		target := createUser(t, s, "user@example.com", "user")
+
+		taken := "admin@example.com"
+		_, err := s.UpdateUser(ctx, UpdateUserRequest{ActorID: admin, UserID: target, Email: &taken})
+		if status, code := problem(t, err); status != http.StatusConflict || code != "email_taken" {
+			t.Errorf("UpdateUser: got %d %s, want 409 email_taken", status, code)
+		}
+		err = s.CreateUser(ctx, CreateUserRequest{Email: taken, Password: "password1"})
+		if status, code := problem(t, err); status != http.StatusConflict || code != "email_taken" {
+			t.Errorf("CreateUser: got %d %s, want 409 email_taken", status, code)
+		}
+	})
+
+	t.Run("locked database is 503", func(t *testing.T) {
+		s, db := newService(t)
+		hold(t, db, "DELETE FROM users WHERE id = 0")
+
+		err := s.CreateUser(ctx, CreateUserRequest{Email: "user@example.com", Password: "password1"})
+		if status, code := problem(t, err); status != http.StatusServiceUnavailable || code != "database_unavailable" {
+			t.Errorf("CreateUser: got %d %s, want 503 database_unavailable", status, code)
+		}
+	})
+
+	t.Run("busy commit is 503", func(t *testing.T) {
+		s, db := newService(t)
+		admin := createUser(t, s, "admin@example.com", "admin")
+		target := createUser(t, s, "user@example.com", "user")
+		hold(t, db, "SELECT count(*) FROM users")
+
+		err := s.DeleteUser(ctx, admin, target)
+		if status, code := problem(t, err); status != http.StatusServiceUnavailable || code != "database_unavailable" {
+			t.Errorf("DeleteUser: got %d %s, want 503 database_unavailable", status, code)
+		}
+		if _, err := s.store.GetByID(ctx, target); err != nil {
+			t.Errorf("user is gone after the failed commit: %v", err)
+		}
+	})
+}
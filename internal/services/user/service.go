@@ -2,20 +2,39 @@ package user
 
 import (
 	"context"
-	"database/sql"
 	"errors"
+	"fmt"
+	"log/slog"
+	"time"
 
+	"github.com/bercivarga/go-basic-server/internal/apperr"
+	"github.com/bercivarga/go-basic-server/internal/audit"
+	"github.com/bercivarga/go-basic-server/internal/config"
+	"github.com/bercivarga/go-basic-server/internal/db/querier"
+	"github.com/bercivarga/go-basic-server/internal/db/sqlc"
+	"github.com/bercivarga/go-basic-server/internal/stores/role"
 	"github.com/bercivarga/go-basic-server/internal/stores/user"
+	"github.com/bercivarga/go-basic-server/internal/utils"
 	"golang.org/x/crypto/bcrypt"
 )
 
+var ErrWrongPassword = apperr.New(apperr.ErrInvalid, "wrong_password", "current password is incorrect")
+
 type Service struct {
-	store *user.Store
+	store      *user.Store
+	roles      *role.Store
+	audit      *audit.Recorder
+	bcryptCost int
 }
 
-func New(db *sql.DB) *Service {
+func New(db *querier.DB, config *config.Config, recorder *audit.Recorder) *Service {
 	store := user.NewStore(db)
-	return &Service{store: store}
+	return &Service{
+		store:      store,
+		roles:      role.NewStore(db),
+		audit:      recorder,
+		bcryptCost: config.Security.BcryptCost,
+	}
 }
 
 type CreateUserRequest struct {
@@ -24,38 +43,93 @@ type CreateUserRequest struct {
 }
 
 type UserResponse struct {
-	ID    int64  `json:"id"`
-	Email string `json:"email"`
-	Role  string `json:"role"`
+	ID            int64     `json:"id"`
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"email_verified"`
+	TwoFactor     bool      `json:"two_factor_enabled"`
+	Role          string    `json:"role"`
+	Disabled      bool      `json:"disabled"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func toResponse(u *sqlc.User) UserResponse {
+	return UserResponse{
+		ID:            u.ID,
+		Email:         u.Email,
+		EmailVerified: u.EmailVerifiedAt.Valid,
+		TwoFactor:     u.TotpEnabledAt.Valid,
+		Role:          u.Role,
+		Disabled:      u.DisabledAt.Valid,
+		CreatedAt:     u.CreatedAt,
+	}
 }
 
 func (s *Service) CreateUser(ctx context.Context, req CreateUserRequest) error {
 	// Hash the password
-	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), s.bcryptCost)
 	if err != nil {
-		return errors.New("password hashing failed")
+		return fmt.Errorf("hashing password: %w", err)
 	}
 
 	// Create the user
-	_, err = s.store.Create(ctx, req.Email, string(hash))
+	u, err := s.store.Create(ctx, req.Email, string(hash))
+	if errors.Is(err, user.ErrEmailTaken) {
+		return fmt.Errorf("%w: %w", ErrEmailTaken, err)
+	}
 	if err != nil {
-		return errors.New("user already exists or database error")
+		return fmt.Errorf("creating user: %w", err)
 	}
 
+	s.audit.Record(ctx, audit.Event{Action: audit.ActionSignup, ActorID: u.ID, TargetID: u.ID})
+
 	return nil
 }
 
 func (s *Service) GetUserByID(ctx context.Context, userID int64) (*UserResponse, error) {
-	user, err := s.store.GetByID(ctx, userID)
+	u, err := s.store.GetByID(ctx, userID)
+	if errors.Is(err, user.ErrNotFound) {
+		return nil, fmt.Errorf("%w: %w", ErrUserNotFound, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fetching user %d: %w", userID, err)
+	}
+
+	response := toResponse(u)
+	return &response, nil
+}
+
+type ChangePasswordRequest struct {
+	UserID          int64
+	SessionID       string // login to keep, every other session is revoked
+	CurrentPassword string
+	NewPassword     string
+}
+
+func (s *Service) ChangePassword(ctx context.Context, req ChangePasswordRequest) error {
+	u, err := s.store.GetByID(ctx, req.UserID)
+	if errors.Is(err, user.ErrNotFound) {
+		return fmt.Errorf("%w: %w", ErrUserNotFound, err)
+	}
+	if err != nil {
+		return fmt.Errorf("fetching user %d: %w", req.UserID, err)
+	}
+	if !utils.CheckPasswordHash(req.CurrentPassword, u.PasswordHash) {
+		return ErrWrongPassword
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), s.bcryptCost)
+	if err != nil {
+		return fmt.Errorf("hashing password: %w", err)
+	}
+
+	err = s.store.ChangePassword(ctx, req.UserID, string(hash), req.SessionID)
 	if err != nil {
-		return nil, errors.New("user not found")
+		return fmt.Errorf("changing password: %w", err)
 	}
 
-	return &UserResponse{
-		ID:    user.ID,
-		Email: user.Email,
-		Role:  user.Role,
-	}, nil
+	slog.InfoContext(ctx, "security: password changed, other sessions revoked", "user_id", req.UserID)
+	s.audit.Record(ctx, audit.Event{Action: audit.ActionPasswordChanged, ActorID: req.UserID, TargetID: req.UserID})
+	return nil
 }
 
 type ListUsersRequest struct {
@@ -66,16 +140,12 @@ type ListUsersRequest struct {
 func (s *Service) ListUsers(ctx context.Context, req ListUsersRequest) ([]UserResponse, error) {
 	users, err := s.store.GetAll(ctx, req.Limit, req.Offset)
 	if err != nil {
-		return nil, errors.New("failed to fetch users")
+		return nil, fmt.Errorf("listing users: %w", err)
 	}
 
 	response := make([]UserResponse, len(users))
-	for i, user := range users {
-		response[i] = UserResponse{
-			ID:    user.ID,
-			Email: user.Email,
-			Role:  user.Role,
-		}
+	for i := range users {
+		response[i] = toResponse(&users[i])
 	}
 
 	return response, nil
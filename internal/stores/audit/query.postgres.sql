@@ -0,0 +1,26 @@
+-- audit/query.postgres.sql
+-- ------------------------------------------------------------
+-- Audit log for sqlc (PostgreSQL engine)
+-- Rows are only ever inserted, and deleted once past retention
+-- ------------------------------------------------------------
+
+-- name: CreateAuditEvent :exec
+INSERT INTO audit_events (created_at, actor_id, target_id, action, ip, user_agent, metadata)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
+
+-- Newest first, every filter is optional, before_id is the pagination cursor ------
+-- name: ListAuditEvents :many
+SELECT * FROM audit_events
+WHERE  (sqlc.narg(actor_id)::BIGINT IS NULL OR actor_id = sqlc.narg(actor_id)::BIGINT)
+  AND  (sqlc.narg(target_id)::BIGINT IS NULL OR target_id = sqlc.narg(target_id)::BIGINT)
+  AND  (sqlc.narg(action)::TEXT IS NULL OR action = sqlc.narg(action)::TEXT)
+  AND  (sqlc.narg(since)::TIMESTAMPTZ IS NULL OR created_at >= sqlc.narg(since)::TIMESTAMPTZ)
+  AND  (sqlc.narg(until)::TIMESTAMPTZ IS NULL OR created_at < sqlc.narg(until)::TIMESTAMPTZ)
+  AND  (sqlc.narg(before_id)::BIGINT IS NULL OR id < sqlc.narg(before_id)::BIGINT)
+ORDER  BY id DESC
+LIMIT  sqlc.arg(limit);
+
+-- Drop events past retention ------------------------------------------------------
+-- name: PurgeAuditEvents :execrows
+DELETE FROM audit_events
+WHERE  id IN (SELECT id FROM audit_events WHERE created_at < sqlc.arg(before) LIMIT sqlc.arg(batch_size));
@@ -0,0 +1,26 @@
+-- audit/query.sql
+-- ------------------------------------------------------------
+-- Audit log for sqlc (SQLite engine)
+-- Rows are only ever inserted, and deleted once past retention
+-- ------------------------------------------------------------
+
+-- name: CreateAuditEvent :exec
+INSERT INTO audit_events (created_at, actor_id, target_id, action, ip, user_agent, metadata)
+VALUES (?, ?, ?, ?, ?, ?, ?);
+
+-- Newest first, every filter is optional, before_id is the pagination cursor ------
+-- name: ListAuditEvents :many
+SELECT * FROM audit_events
+WHERE  (sqlc.narg(actor_id) IS NULL OR actor_id = sqlc.narg(actor_id))
+  AND  (sqlc.narg(target_id) IS NULL OR target_id = sqlc.narg(target_id))
+  AND  (sqlc.narg(action) IS NULL OR action = sqlc.narg(action))
+  AND  (sqlc.narg(since) IS NULL OR created_at >= sqlc.narg(since))
+  AND  (sqlc.narg(until) IS NULL OR created_at < sqlc.narg(until))
+  AND  (sqlc.narg(before_id) IS NULL OR id < sqlc.narg(before_id))
+ORDER  BY id DESC
+LIMIT  sqlc.arg(limit);
+
+-- Drop events past retention ------------------------------------------------------
+-- name: PurgeAuditEvents :execrows
+DELETE FROM audit_events
+WHERE  id IN (SELECT id FROM audit_events WHERE created_at < sqlc.arg(before) LIMIT sqlc.arg(batch_size));
@@ -0,0 +1,111 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/bercivarga/go-basic-server/internal/db/querier"
+	"github.com/bercivarga/go-basic-server/internal/db/sqlc"
+)
+
+// Event is one entry of the audit log. ActorID and TargetID are 0 when
+// there is no such user, e.g. the actor of a failed login.
+type Event struct {
+	ID        int64          `json:"id"`
+	Time      time.Time      `json:"time"`
+	Action    string         `json:"action"`
+	ActorID   int64          `json:"actor_id,omitempty"`
+	TargetID  int64          `json:"target_id,omitempty"`
+	IP        string         `json:"ip"`
+	UserAgent string         `json:"user_agent"`
+	Metadata  map[string]any `json:"metadata"`
+}
+
+// Filter selects events for List. Zero fields match everything.
+type Filter struct {
+	ActorID  int64
+	TargetID int64
+	Action   string
+	Since    time.Time // inclusive
+	Until    time.Time // exclusive
+	BeforeID int64     // only events older than this one
+	Limit    int64
+}
+
+type Store struct {
+	q sqlc.Querier
+}
+
+func NewStore(db *querier.DB) *Store {
+	return &Store{q: db.Queries()}
+}
+
+// Append adds e to the log. ID is assigned by the database.
+func (s *Store) Append(ctx context.Context, e Event) error {
+	metadata := []byte("{}")
+	if len(e.Metadata) > 0 {
+		var err error
+		if metadata, err = json.Marshal(e.Metadata); err != nil {
+			return err
+		}
+	}
+
+	return s.q.CreateAuditEvent(ctx, sqlc.CreateAuditEventParams{
+		CreatedAt: e.Time.UTC(),
+		ActorID:   nullID(e.ActorID),
+		TargetID:  nullID(e.TargetID),
+		Action:    e.Action,
+		Ip:        e.IP,
+		UserAgent: e.UserAgent,
+		Metadata:  string(metadata),
+	})
+}
+
+// List returns the events matching f, newest first.
+func (s *Store) List(ctx context.Context, f Filter) ([]Event, error) {
+	rows, err := s.q.ListAuditEvents(ctx, sqlc.ListAuditEventsParams{
+		ActorID:  nullID(f.ActorID),
+		TargetID: nullID(f.TargetID),
+		Action:   sql.NullString{String: f.Action, Valid: f.Action != ""},
+		Since:    nullTime(f.Since),
+		Until:    nullTime(f.Until),
+		BeforeID: nullID(f.BeforeID),
+		Limit:    f.Limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]Event, len(rows))
+	for i, r := range rows {
+		events[i] = Event{
+			ID:        r.ID,
+			Time:      r.CreatedAt,
+			Action:    r.Action,
+			ActorID:   r.ActorID.Int64,
+			TargetID:  r.TargetID.Int64,
+			IP:        r.Ip,
+			UserAgent: r.UserAgent,
+		}
+		if err := json.Unmarshal([]byte(r.Metadata), &events[i].Metadata); err != nil {
+			return nil, err
+		}
+	}
+	return events, nil
+}
+
+// PurgeBefore deletes up to batchSize events recorded before before and
+// returns how many it deleted.
+func (s *Store) PurgeBefore(ctx context.Context, before time.Time, batchSize int64) (int64, error) {
+	return s.q.PurgeAuditEvents(ctx, sqlc.PurgeAuditEventsParams{Before: before.UTC(), BatchSize: batchSize})
+}
+
+func nullID(id int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: id, Valid: id != 0}
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t.UTC(), Valid: !t.IsZero()}
+}
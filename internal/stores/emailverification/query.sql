@@ -0,0 +1,26 @@
+-- emailverification/query.sql
+-- ------------------------------------------------------------
+-- Email verification tokens for sqlc (SQLite engine)
+-- Only hashes of the emailed tokens are stored
+-- ------------------------------------------------------------
+
+-- name: CreateEmailVerification :exec
+INSERT INTO email_verifications (user_id, token_hash, expires_at)
+VALUES (?, ?, ?);
+
+-- Use up a token, matches at most once and only before it expires ---------------
+-- name: ConsumeEmailVerification :one
+UPDATE email_verifications
+SET    used_at = CURRENT_TIMESTAMP
+WHERE  token_hash = ? AND used_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+RETURNING user_id;
+
+-- Drop the tokens of a user that were never used ---------------------------------
+-- name: DeleteUnusedEmailVerifications :exec
+DELETE FROM email_verifications
+WHERE user_id = ? AND used_at IS NULL;
+
+-- Drop expired verification tokens, used or not ----------------------------------
+-- name: PurgeExpiredEmailVerifications :execrows
+DELETE FROM email_verifications
+WHERE  id IN (SELECT id FROM email_verifications WHERE expires_at < sqlc.arg(before) LIMIT sqlc.arg(batch_size));
@@ -0,0 +1,70 @@
+package emailverification
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bercivarga/go-basic-server/internal/db/dberr"
+	"github.com/bercivarga/go-basic-server/internal/db/querier"
+	"github.com/bercivarga/go-basic-server/internal/db/sqlc"
+	"github.com/bercivarga/go-basic-server/internal/utils"
+)
+
+var ErrInvalidToken = errors.New("invalid or expired verification token")
+
+// Store keeps hashes of email verification tokens, keyed with the same
+// pepper as session tokens.
+type Store struct {
+	db     *querier.DB
+	q      sqlc.Querier
+	pepper string
+}
+
+func NewStore(db *querier.DB, pepper string) *Store {
+	return &Store{db: db, q: db.Queries(), pepper: pepper}
+}
+
+func (s *Store) Create(ctx context.Context, userID int64, token string, expiresAt time.Time) error {
+	return s.q.CreateEmailVerification(ctx, sqlc.CreateEmailVerificationParams{
+		UserID:    userID,
+		TokenHash: utils.HashToken(token, s.pepper),
+		ExpiresAt: expiresAt.UTC(),
+	})
+}
+
+// Redeem uses up token, marks the user's email as verified and drops
+// their other unused tokens, in one transaction. It returns the user's
+// ID, or ErrInvalidToken when token is unknown, expired or already used.
+func (s *Store) Redeem(ctx context.Context, token string) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	q := s.db.WithTx(tx)
+
+	userID, err := q.ConsumeEmailVerification(ctx, utils.HashToken(token, s.pepper))
+	if errors.Is(err, dberr.ErrNotFound) {
+		return 0, ErrInvalidToken
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if err := q.MarkEmailVerified(ctx, userID); err != nil {
+		return 0, err
+	}
+	if err := q.DeleteUnusedEmailVerifications(ctx, userID); err != nil {
+		return 0, err
+	}
+
+	return userID, tx.Commit()
+}
+
+// PurgeExpired deletes up to batchSize tokens that expired before before
+// and returns how many it deleted.
+func (s *Store) PurgeExpired(ctx context.Context, before time.Time, batchSize int64) (int64, error) {
+	return s.q.PurgeExpiredEmailVerifications(ctx, sqlc.PurgeExpiredEmailVerificationsParams{Before: before.UTC(), BatchSize: batchSize})
+}
@@ -0,0 +1,38 @@
+-- loginfailure/query.postgres.sql
+-- ------------------------------------------------------------
+-- Failed login tracking for sqlc (PostgreSQL engine)
+-- ------------------------------------------------------------
+
+-- name: GetLoginFailure :one
+SELECT * FROM login_failures
+WHERE scope = $1 AND subject = $2;
+
+-- Count a failure, starting over when the last one is older than reset_before -
+-- name: RecordLoginFailure :one
+INSERT INTO login_failures (scope, subject, failures, last_failure_at)
+VALUES (sqlc.arg(scope), sqlc.arg(subject), 1, sqlc.arg(now))
+ON CONFLICT (scope, subject) DO UPDATE
+SET    failures = CASE WHEN login_failures.last_failure_at < sqlc.arg(reset_before) THEN 1
+                       ELSE login_failures.failures + 1 END,
+       last_failure_at = excluded.last_failure_at
+RETURNING failures;
+
+-- name: LockLogin :exec
+UPDATE login_failures
+SET    locked_until = $1
+WHERE  scope = $2 AND subject = $3;
+
+-- name: ListLoginLockouts :many
+SELECT * FROM login_failures
+WHERE  locked_until > $1
+ORDER  BY locked_until DESC
+LIMIT  $2 OFFSET $3;
+
+-- name: DeleteLoginFailure :execrows
+DELETE FROM login_failures
+WHERE scope = $1 AND subject = $2;
+
+-- Drop failure counts that would start over anyway -------------------------------
+-- name: PurgeStaleLoginFailures :execrows
+DELETE FROM login_failures
+WHERE  id IN (SELECT id FROM login_failures WHERE last_failure_at < sqlc.arg(before) LIMIT sqlc.arg(batch_size));
@@ -0,0 +1,38 @@
+-- loginfailure/query.sql
+-- ------------------------------------------------------------
+-- Failed login tracking for sqlc (SQLite engine)
+-- ------------------------------------------------------------
+
+-- name: GetLoginFailure :one
+SELECT * FROM login_failures
+WHERE scope = ? AND subject = ?;
+
+-- Count a failure, starting over when the last one is older than reset_before -
+-- name: RecordLoginFailure :one
+INSERT INTO login_failures (scope, subject, failures, last_failure_at)
+VALUES (sqlc.arg(scope), sqlc.arg(subject), 1, sqlc.arg(now))
+ON CONFLICT (scope, subject) DO UPDATE
+SET    failures = CASE WHEN login_failures.last_failure_at < sqlc.arg(reset_before) THEN 1
+                       ELSE login_failures.failures + 1 END,
+       last_failure_at = excluded.last_failure_at
+RETURNING failures;
+
+-- name: LockLogin :exec
+UPDATE login_failures
+SET    locked_until = ?
+WHERE  scope = ? AND subject = ?;
+
+-- name: ListLoginLockouts :many
+SELECT * FROM login_failures
+WHERE  locked_until > ?
+ORDER  BY locked_until DESC
+LIMIT  ? OFFSET ?;
+
+-- name: DeleteLoginFailure :execrows
+DELETE FROM login_failures
+WHERE scope = ? AND subject = ?;
+
+-- Drop failure counts that would start over anyway -------------------------------
+-- name: PurgeStaleLoginFailures :execrows
+DELETE FROM login_failures
+WHERE  id IN (SELECT id FROM login_failures WHERE last_failure_at < sqlc.arg(before) LIMIT sqlc.arg(batch_size));
@@ -0,0 +1,89 @@
+package loginfailure
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/bercivarga/go-basic-server/internal/db/dberr"
+	"github.com/bercivarga/go-basic-server/internal/db/querier"
+	"github.com/bercivarga/go-basic-server/internal/db/sqlc"
+)
+
+// Failed logins are counted per account and per client IP.
+const (
+	ScopeAccount = "account"
+	ScopeIP      = "ip"
+)
+
+var ErrNotFound = errors.New("no failed logins recorded")
+
+type Store struct {
+	q sqlc.Querier
+}
+
+func NewStore(db *querier.DB) *Store {
+	return &Store{q: db.Queries()}
+}
+
+// LockedUntil returns when the lockout of subject ends, the zero time if
+// it is not locked.
+func (s *Store) LockedUntil(ctx context.Context, scope, subject string) (time.Time, error) {
+	r, err := s.q.GetLoginFailure(ctx, sqlc.GetLoginFailureParams{Scope: scope, Subject: subject})
+	if errors.Is(err, dberr.ErrNotFound) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return r.LockedUntil.Time, nil
+}
+
+// RecordFailure counts a failed login and returns the number of failures
+// in a row. The count starts over when the previous failure is older than
+// resetAfter.
+func (s *Store) RecordFailure(ctx context.Context, scope, subject string, resetAfter time.Duration) (int64, error) {
+	now := time.Now().UTC()
+	return s.q.RecordLoginFailure(ctx, sqlc.RecordLoginFailureParams{
+		Scope:       scope,
+		Subject:     subject,
+		Now:         now,
+		ResetBefore: now.Add(-resetAfter),
+	})
+}
+
+func (s *Store) Lock(ctx context.Context, scope, subject string, until time.Time) error {
+	return s.q.LockLogin(ctx, sqlc.LockLoginParams{
+		LockedUntil: sql.NullTime{Time: until.UTC(), Valid: true},
+		Scope:       scope,
+		Subject:     subject,
+	})
+}
+
+// ListLocked returns the current lockouts, the one ending last first.
+func (s *Store) ListLocked(ctx context.Context, limit, offset int64) ([]sqlc.LoginFailure, error) {
+	return s.q.ListLoginLockouts(ctx, sqlc.ListLoginLockoutsParams{
+		LockedUntil: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		Limit:       limit,
+		Offset:      offset,
+	})
+}
+
+// Clear forgets the failures of subject, lifting a lockout.
+func (s *Store) Clear(ctx context.Context, scope, subject string) error {
+	n, err := s.q.DeleteLoginFailure(ctx, sqlc.DeleteLoginFailureParams{Scope: scope, Subject: subject})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// PurgeStale deletes up to batchSize failure counts whose last failure
+// is older than before and returns how many it deleted.
+func (s *Store) PurgeStale(ctx context.Context, before time.Time, batchSize int64) (int64, error) {
+	return s.q.PurgeStaleLoginFailures(ctx, sqlc.PurgeStaleLoginFailuresParams{Before: before.UTC(), BatchSize: batchSize})
+}
@@ -0,0 +1,26 @@
+-- passwordreset/query.sql
+-- ------------------------------------------------------------
+-- Password reset tokens for sqlc (SQLite engine)
+-- Only hashes of the emailed tokens are stored
+-- ------------------------------------------------------------
+
+-- name: CreatePasswordReset :exec
+INSERT INTO password_resets (user_id, token_hash, expires_at)
+VALUES (?, ?, ?);
+
+-- Use up a token, matches at most once and only before it expires ---------------
+-- name: ConsumePasswordReset :one
+UPDATE password_resets
+SET    used_at = CURRENT_TIMESTAMP
+WHERE  token_hash = ? AND used_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+RETURNING user_id;
+
+-- Drop the tokens of a user that were never used ---------------------------------
+-- name: DeleteUnusedPasswordResets :exec
+DELETE FROM password_resets
+WHERE user_id = ? AND used_at IS NULL;
+
+-- Drop expired reset tokens, used or not -----------------------------------------
+-- name: PurgeExpiredPasswordResets :execrows
+DELETE FROM password_resets
+WHERE  id IN (SELECT id FROM password_resets WHERE expires_at < sqlc.arg(before) LIMIT sqlc.arg(batch_size));
@@ -0,0 +1,79 @@
+package passwordreset
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bercivarga/go-basic-server/internal/db/dberr"
+	"github.com/bercivarga/go-basic-server/internal/db/querier"
+	"github.com/bercivarga/go-basic-server/internal/db/sqlc"
+	"github.com/bercivarga/go-basic-server/internal/utils"
+)
+
+var ErrInvalidToken = errors.New("invalid or expired reset token")
+
+// Store keeps hashes of password reset tokens, keyed with the same pepper
+// as session tokens.
+type Store struct {
+	db     *querier.DB
+	q      sqlc.Querier
+	pepper string
+}
+
+func NewStore(db *querier.DB, pepper string) *Store {
+	return &Store{db: db, q: db.Queries(), pepper: pepper}
+}
+
+func (s *Store) Create(ctx context.Context, userID int64, token string, expiresAt time.Time) error {
+	return s.q.CreatePasswordReset(ctx, sqlc.CreatePasswordResetParams{
+		UserID:    userID,
+		TokenHash: utils.HashToken(token, s.pepper),
+		ExpiresAt: expiresAt.UTC(),
+	})
+}
+
+// Redeem uses up token and, in the same transaction, sets the new
+// password hash, drops the other unused reset tokens of the user and revokes
+// all of their sessions. It returns the user's ID, or ErrInvalidToken
+// when token is unknown, expired or already used.
+func (s *Store) Redeem(ctx context.Context, token, passwordHash string) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	q := s.db.WithTx(tx)
+
+	userID, err := q.ConsumePasswordReset(ctx, utils.HashToken(token, s.pepper))
+	if errors.Is(err, dberr.ErrNotFound) {
+		return 0, ErrInvalidToken
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	err = q.UpdatePasswordHash(ctx, sqlc.UpdatePasswordHashParams{
+		PasswordHash: passwordHash,
+		ID:           userID,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := q.DeleteUnusedPasswordResets(ctx, userID); err != nil {
+		return 0, err
+	}
+	if err := q.DeleteSessionsByUser(ctx, userID); err != nil {
+		return 0, err
+	}
+
+	return userID, tx.Commit()
+}
+
+// PurgeExpired deletes up to batchSize tokens that expired before before
+// and returns how many it deleted.
+func (s *Store) PurgeExpired(ctx context.Context, before time.Time, batchSize int64) (int64, error) {
+	return s.q.PurgeExpiredPasswordResets(ctx, sqlc.PurgeExpiredPasswordResetsParams{Before: before.UTC(), BatchSize: batchSize})
+}
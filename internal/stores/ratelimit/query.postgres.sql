@@ -0,0 +1,23 @@
+-- ratelimit/query.postgres.sql
+-- ------------------------------------------------------------
+-- Rate limit counters for sqlc (PostgreSQL engine)
+-- ------------------------------------------------------------
+
+-- Count a hit, moving the counters along when a new bucket started ----------
+-- name: IncrementRateLimit :one
+INSERT INTO rate_limits (limiter_key, bucket, hits, prev_hits, expires_at)
+VALUES (sqlc.arg(limiter_key), sqlc.arg(bucket), 1, 0, sqlc.arg(expires_at))
+ON CONFLICT (limiter_key) DO UPDATE
+SET    prev_hits = CASE WHEN rate_limits.bucket = excluded.bucket THEN rate_limits.prev_hits
+                        WHEN rate_limits.bucket = excluded.bucket - 1 THEN rate_limits.hits
+                        ELSE 0 END,
+       hits = CASE WHEN rate_limits.bucket = excluded.bucket THEN rate_limits.hits + 1
+                   ELSE 1 END,
+       bucket = excluded.bucket,
+       expires_at = excluded.expires_at
+RETURNING hits, prev_hits;
+
+-- Drop counters of clients that went quiet ---------------------------------------
+-- name: PurgeExpiredRateLimits :execrows
+DELETE FROM rate_limits
+WHERE  limiter_key IN (SELECT limiter_key FROM rate_limits WHERE expires_at < sqlc.arg(before) LIMIT sqlc.arg(batch_size));
@@ -0,0 +1,37 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/bercivarga/go-basic-server/internal/db/querier"
+	"github.com/bercivarga/go-basic-server/internal/db/sqlc"
+)
+
+// Store keeps rate limit counters in the database, so every instance
+// using it shares the limits. It implements ratelimit.Store.
+type Store struct {
+	q sqlc.Querier
+}
+
+func NewStore(db *querier.DB) *Store {
+	return &Store{q: db.Queries()}
+}
+
+func (s *Store) Increment(ctx context.Context, key string, window int64, expiresAt time.Time) (int64, int64, error) {
+	r, err := s.q.IncrementRateLimit(ctx, sqlc.IncrementRateLimitParams{
+		LimiterKey: key,
+		Bucket:     window,
+		ExpiresAt:  expiresAt.UTC(),
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return r.Hits, r.PrevHits, nil
+}
+
+// PurgeExpired deletes up to batchSize counters that expired before
+// before and returns how many it deleted.
+func (s *Store) PurgeExpired(ctx context.Context, before time.Time, batchSize int64) (int64, error) {
+	return s.q.PurgeExpiredRateLimits(ctx, sqlc.PurgeExpiredRateLimitsParams{Before: before.UTC(), BatchSize: batchSize})
+}
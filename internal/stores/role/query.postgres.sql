@@ -0,0 +1,35 @@
+-- role/query.postgres.sql
+-- ------------------------------------------------------------
+-- Roles, their permissions and role assignment for sqlc (PostgreSQL engine)
+-- ------------------------------------------------------------
+
+-- name: ListRoles :many
+SELECT name, description
+FROM   roles
+ORDER  BY name;
+
+-- name: ListRolePermissions :many
+SELECT * FROM role_permissions
+ORDER  BY role, permission;
+
+-- name: GetRolePermissions :many
+SELECT permission FROM role_permissions
+WHERE  role = $1
+ORDER  BY permission;
+
+-- The user's role and one row per permission, permission is NULL for a role
+-- without any ----------------------------------------------------------------
+-- name: GetUserPermissions :many
+SELECT u.role, rp.permission
+FROM   users u
+LEFT   JOIN role_permissions rp ON rp.role = u.role
+WHERE  u.id = $1;
+
+-- name: RoleExists :one
+SELECT COUNT(*) FROM roles
+WHERE  name = $1;
+
+-- name: SetUserRole :execrows
+UPDATE users
+SET    role = $1
+WHERE  id = $2;
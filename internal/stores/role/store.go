@@ -0,0 +1,145 @@
+package role
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bercivarga/go-basic-server/internal/db/querier"
+	"github.com/bercivarga/go-basic-server/internal/db/sqlc"
+)
+
+var (
+	ErrRoleNotFound = errors.New("role not found")
+	ErrUserNotFound = errors.New("user not found")
+)
+
+// Role is a named set of permissions.
+type Role struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type Store struct {
+	q sqlc.Querier
+}
+
+func NewStore(db *querier.DB) *Store {
+	return &Store{q: db.Queries()}
+}
+
+// List returns every role with its permissions, sorted by name.
+func (s *Store) List(ctx context.Context) ([]Role, error) {
+	roles, err := s.q.ListRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	grants, err := s.q.ListRolePermissions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byRole := map[string][]string{}
+	for _, g := range grants {
+		byRole[g.Role] = append(byRole[g.Role], g.Permission)
+	}
+
+	out := make([]Role, len(roles))
+	for i, r := range roles {
+		perms := byRole[r.Name]
+		if perms == nil {
+			perms = []string{}
+		}
+		out[i] = Role{Name: r.Name, Description: r.Description, Permissions: perms}
+	}
+	return out, nil
+}
+
+// Permissions returns the role of userID and the permissions it grants.
+func (s *Store) Permissions(ctx context.Context, userID int64) (string, []string, error) {
+	rows, err := s.q.GetUserPermissions(ctx, userID)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(rows) == 0 {
+		return "", nil, ErrUserNotFound
+	}
+
+	var perms []string
+	for _, r := range rows {
+		if r.Permission.Valid {
+			perms = append(perms, r.Permission.String)
+		}
+	}
+	return rows[0].Role, perms, nil
+}
+
+// Assign gives userID the role named role.
+func (s *Store) Assign(ctx context.Context, userID int64, role string) error {
+	n, err := s.q.RoleExists(ctx, role)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrRoleNotFound
+	}
+
+	n, err = s.q.SetUserRole(ctx, sqlc.SetUserRoleParams{Role: role, ID: userID})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// Covers reports whether actorID holds every permission userID holds, so
+// acting on userID's account never gives actorID more than they have.
+func (s *Store) Covers(ctx context.Context, actorID, userID int64) (bool, error) {
+	_, actorPerms, err := s.Permissions(ctx, actorID)
+	if err != nil {
+		return false, err
+	}
+	_, userPerms, err := s.Permissions(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return holdsAll(actorPerms, userPerms), nil
+}
+
+// CanGrant reports whether actorID holds every permission of the role
+// named role, so assigning it never hands out more than actorID has. It
+// returns ErrRoleNotFound for an unknown role.
+func (s *Store) CanGrant(ctx context.Context, actorID int64, role string) (bool, error) {
+	n, err := s.q.RoleExists(ctx, role)
+	if err != nil {
+		return false, err
+	}
+	if n == 0 {
+		return false, ErrRoleNotFound
+	}
+
+	_, actorPerms, err := s.Permissions(ctx, actorID)
+	if err != nil {
+		return false, err
+	}
+	rolePerms, err := s.q.GetRolePermissions(ctx, role)
+	if err != nil {
+		return false, err
+	}
+	return holdsAll(actorPerms, rolePerms), nil
+}
+
+func holdsAll(held, perms []string) bool {
+	set := make(map[string]bool, len(held))
+	for _, perm := range held {
+		set[perm] = true
+	}
+	for _, perm := range perms {
+		if !set[perm] {
+			return false
+		}
+	}
+	return true
+}
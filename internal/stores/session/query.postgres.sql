@@ -0,0 +1,62 @@
+-- name: CreateSession :exec
+INSERT INTO sessions (user_id, token_hash, expires_at, refresh_token_hash, refresh_expires_at, family_id,
+                      user_agent, ip, last_used_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, CURRENT_TIMESTAMP);
+
+-- name: IsValidSession :one
+SELECT COUNT(*) FROM sessions
+WHERE user_id = $1 AND token_hash = $2 AND expires_at > CURRENT_TIMESTAMP AND rotated_at IS NULL;
+
+-- name: DeleteSessionByToken :exec
+DELETE FROM sessions
+WHERE token_hash = $1;
+
+-- Rotated sessions are returned too, the caller must check rotated_at ------------
+-- name: GetSessionByRefreshToken :one
+SELECT * FROM sessions
+WHERE refresh_token_hash = $1 AND refresh_expires_at > CURRENT_TIMESTAMP;
+
+-- name: DeleteSessionByRefreshToken :exec
+DELETE FROM sessions
+WHERE refresh_token_hash = $1;
+
+-- Affects no rows when a concurrent refresh already rotated the session ---------
+-- name: MarkSessionRotated :execrows
+UPDATE sessions
+SET    rotated_at = CURRENT_TIMESTAMP
+WHERE  id = $1 AND rotated_at IS NULL;
+
+-- name: DeleteSessionFamily :exec
+DELETE FROM sessions
+WHERE family_id = $1;
+
+-- name: DeleteSessionsByUser :exec
+DELETE FROM sessions
+WHERE user_id = $1;
+
+-- Sign a user out everywhere except the given login -------------------------------
+-- name: DeleteOtherSessions :exec
+DELETE FROM sessions
+WHERE user_id = $1 AND family_id <> $2;
+
+-- Record a use of the access token, at most once per interval -------------------
+-- name: TouchSession :exec
+UPDATE sessions
+SET    last_used_at = CURRENT_TIMESTAMP, ip = $1
+WHERE  token_hash = $2 AND (last_used_at IS NULL OR last_used_at < $3);
+
+-- The live session of every login of a user, rotated ones left out ---------------
+-- name: ListActiveSessions :many
+SELECT family_id, user_agent, ip, last_used_at, refresh_expires_at
+FROM   sessions
+WHERE  user_id = $1 AND rotated_at IS NULL AND refresh_expires_at > CURRENT_TIMESTAMP
+ORDER  BY last_used_at DESC;
+
+-- name: DeleteUserSessionFamily :execrows
+DELETE FROM sessions
+WHERE user_id = $1 AND family_id = $2;
+
+-- Drop sessions whose refresh token expired, rotated ones included ---------------
+-- name: PurgeExpiredSessions :execrows
+DELETE FROM sessions
+WHERE  id IN (SELECT id FROM sessions WHERE refresh_expires_at < sqlc.arg(before) LIMIT sqlc.arg(batch_size));
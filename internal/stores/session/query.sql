@@ -1,19 +1,62 @@
 -- name: CreateSession :exec
-INSERT INTO sessions (user_id, token, expires_at, refresh_token, refresh_expires_at)
-VALUES (?, ?, ?, ?, ?);
+INSERT INTO sessions (user_id, token_hash, expires_at, refresh_token_hash, refresh_expires_at, family_id,
+                      user_agent, ip, last_used_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP);
 
 -- name: IsValidSession :one
 SELECT COUNT(*) FROM sessions
-WHERE user_id = ? AND token = ? AND expires_at > CURRENT_TIMESTAMP;
+WHERE user_id = ? AND token_hash = ? AND expires_at > CURRENT_TIMESTAMP AND rotated_at IS NULL;
 
 -- name: DeleteSessionByToken :exec
 DELETE FROM sessions
-WHERE token = ?;
+WHERE token_hash = ?;
 
+-- Rotated sessions are returned too, the caller must check rotated_at ------------
 -- name: GetSessionByRefreshToken :one
 SELECT * FROM sessions
-WHERE refresh_token = ? AND refresh_expires_at > CURRENT_TIMESTAMP;
+WHERE refresh_token_hash = ? AND refresh_expires_at > CURRENT_TIMESTAMP;
 
 -- name: DeleteSessionByRefreshToken :exec
 DELETE FROM sessions
-WHERE refresh_token = ?;
+WHERE refresh_token_hash = ?;
+
+-- Affects no rows when a concurrent refresh already rotated the session ---------
+-- name: MarkSessionRotated :execrows
+UPDATE sessions
+SET    rotated_at = CURRENT_TIMESTAMP
+WHERE  id = ? AND rotated_at IS NULL;
+
+-- name: DeleteSessionFamily :exec
+DELETE FROM sessions
+WHERE family_id = ?;
+
+-- name: DeleteSessionsByUser :exec
+DELETE FROM sessions
+WHERE user_id = ?;
+
+-- Sign a user out everywhere except the given login -------------------------------
+-- name: DeleteOtherSessions :exec
+DELETE FROM sessions
+WHERE user_id = ? AND family_id <> ?;
+
+-- Record a use of the access token, at most once per interval -------------------
+-- name: TouchSession :exec
+UPDATE sessions
+SET    last_used_at = CURRENT_TIMESTAMP, ip = ?
+WHERE  token_hash = ? AND (last_used_at IS NULL OR last_used_at < ?);
+
+-- The live session of every login of a user, rotated ones left out ---------------
+-- name: ListActiveSessions :many
+SELECT family_id, user_agent, ip, last_used_at, refresh_expires_at
+FROM   sessions
+WHERE  user_id = ? AND rotated_at IS NULL AND refresh_expires_at > CURRENT_TIMESTAMP
+ORDER  BY last_used_at DESC;
+
+-- name: DeleteUserSessionFamily :execrows
+DELETE FROM sessions
+WHERE user_id = ? AND family_id = ?;
+
+-- Drop sessions whose refresh token expired, rotated ones included ---------------
+-- name: PurgeExpiredSessions :execrows
+DELETE FROM sessions
+WHERE  id IN (SELECT id FROM sessions WHERE refresh_expires_at < sqlc.arg(before) LIMIT sqlc.arg(batch_size));
@@ -4,30 +4,66 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"time"
 
+	"github.com/bercivarga/go-basic-server/internal/db/dberr"
+	"github.com/bercivarga/go-basic-server/internal/db/querier"
 	"github.com/bercivarga/go-basic-server/internal/db/sqlc"
+	"github.com/bercivarga/go-basic-server/internal/utils"
 )
 
 var (
-	ErrSessionExpired = errors.New("session expired")
+	ErrSessionExpired  = errors.New("session expired")
+	ErrSessionRotated  = errors.New("session already rotated")
+	ErrSessionNotFound = errors.New("session not found")
 )
 
+// touchInterval limits how often last_used_at is written for one token.
+const touchInterval = time.Minute
+
+// maxUserAgent caps the stored User-Agent, clients control its length.
+const maxUserAgent = 512
+
+// Client is the device a session was started or last refreshed from.
+type Client struct {
+	UserAgent string
+	IP        string
+}
+
+func (c Client) userAgent() string {
+	if len(c.UserAgent) > maxUserAgent {
+		return c.UserAgent[:maxUserAgent]
+	}
+	return c.UserAgent
+}
+
+// Store never writes raw tokens to the database, only their hashes keyed
+// with pepper; every lookup hashes the presented token the same way.
 type Store struct {
-	q *sqlc.Queries
+	db     *querier.DB
+	q      sqlc.Querier
+	pepper string
+}
+
+func NewStore(db *querier.DB, pepper string) *Store {
+	return &Store{db: db, q: db.Queries(), pepper: pepper}
 }
 
-func NewStore(db *sql.DB) *Store {
-	return &Store{q: sqlc.New(db)}
+func (s *Store) hash(token string) string {
+	return utils.HashToken(token, s.pepper)
 }
 
-func (s *Store) Create(ctx context.Context, userID int64, token, refreshToken string, expiresAt, refreshExpiresAt time.Time) error {
+func (s *Store) Create(ctx context.Context, userID int64, token, refreshToken string, expiresAt, refreshExpiresAt time.Time, familyID string, client Client) error {
 	err := s.q.CreateSession(ctx, sqlc.CreateSessionParams{
 		UserID:           userID,
-		Token:            token,
-		ExpiresAt:        expiresAt,
-		RefreshToken:     refreshToken,
-		RefreshExpiresAt: refreshExpiresAt,
+		TokenHash:        s.hash(token),
+		ExpiresAt:        expiresAt.UTC(),
+		RefreshTokenHash: s.hash(refreshToken),
+		RefreshExpiresAt: refreshExpiresAt.UTC(),
+		FamilyID:         familyID,
+		UserAgent:        client.userAgent(),
+		Ip:               client.IP,
 	})
 	if err != nil {
 		return err
@@ -37,8 +73,8 @@ func (s *Store) Create(ctx context.Context, userID int64, token, refreshToken st
 
 func (s *Store) IsValid(ctx context.Context, userID int64, token string) bool {
 	count, err := s.q.IsValidSession(ctx, sqlc.IsValidSessionParams{
-		UserID: userID,
-		Token:  token,
+		UserID:    userID,
+		TokenHash: s.hash(token),
 	})
 	if err != nil {
 		return false
@@ -46,16 +82,57 @@ func (s *Store) IsValid(ctx context.Context, userID int64, token string) bool {
 	return count == 1
 }
 
+// Touch records that token was just used from ip. Writes are skipped
+// while the last recorded use is recent.
+func (s *Store) Touch(ctx context.Context, token, ip string) error {
+	return s.q.TouchSession(ctx, sqlc.TouchSessionParams{
+		Ip:         ip,
+		TokenHash:  s.hash(token),
+		LastUsedAt: sql.NullTime{Time: time.Now().Add(-touchInterval).UTC(), Valid: true},
+	})
+}
+
+// ListActive returns the current session of every login of the user that
+// can still be refreshed, most recently used first.
+func (s *Store) ListActive(ctx context.Context, userID int64) ([]sqlc.ListActiveSessionsRow, error) {
+	return s.q.ListActiveSessions(ctx, userID)
+}
+
+// RevokeUserFamily is RevokeFamily restricted to the logins of userID.
+func (s *Store) RevokeUserFamily(ctx context.Context, userID int64, familyID string) error {
+	n, err := s.q.DeleteUserSessionFamily(ctx, sqlc.DeleteUserSessionFamilyParams{
+		UserID:   userID,
+		FamilyID: familyID,
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// DeleteByUser signs the user out everywhere.
+func (s *Store) DeleteByUser(ctx context.Context, userID int64) error {
+	return s.q.DeleteSessionsByUser(ctx, userID)
+}
+
 func (s *Store) DeleteByToken(ctx context.Context, token string) error {
-	err := s.q.DeleteSessionByToken(ctx, token)
+	err := s.q.DeleteSessionByToken(ctx, s.hash(token))
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+// GetByRefreshToken also returns sessions that were already rotated, so
+// callers can detect refresh token reuse through RotatedAt.
 func (s *Store) GetByRefreshToken(ctx context.Context, token string) (*sqlc.Session, error) {
-	session, err := s.q.GetSessionByRefreshToken(ctx, token)
+	session, err := s.q.GetSessionByRefreshToken(ctx, s.hash(token))
+	if errors.Is(err, dberr.ErrNotFound) {
+		return nil, fmt.Errorf("%w: %w", ErrSessionNotFound, err)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -68,9 +145,57 @@ func (s *Store) GetByRefreshToken(ctx context.Context, token string) (*sqlc.Sess
 }
 
 func (s *Store) DeleteByRefreshToken(ctx context.Context, token string) error {
-	err := s.q.DeleteSessionByRefreshToken(ctx, token)
+	err := s.q.DeleteSessionByRefreshToken(ctx, s.hash(token))
 	if err != nil {
 		return err
 	}
 	return nil
 }
+
+// Rotate marks old as rotated and creates its successor in the same token
+// family, in a single transaction. It returns ErrSessionRotated when old
+// was rotated in the meantime, i.e. its refresh token was used twice.
+func (s *Store) Rotate(ctx context.Context, old *sqlc.Session, token, refreshToken string, expiresAt, refreshExpiresAt time.Time, client Client) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	q := s.db.WithTx(tx)
+
+	rotated, err := q.MarkSessionRotated(ctx, old.ID)
+	if err != nil {
+		return err
+	}
+	if rotated == 0 {
+		return ErrSessionRotated
+	}
+
+	err = q.CreateSession(ctx, sqlc.CreateSessionParams{
+		UserID:           old.UserID,
+		TokenHash:        s.hash(token),
+		ExpiresAt:        expiresAt.UTC(),
+		RefreshTokenHash: s.hash(refreshToken),
+		RefreshExpiresAt: refreshExpiresAt.UTC(),
+		FamilyID:         old.FamilyID,
+		UserAgent:        client.userAgent(),
+		Ip:               client.IP,
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RevokeFamily deletes every session descending from the same login.
+func (s *Store) RevokeFamily(ctx context.Context, familyID string) error {
+	return s.q.DeleteSessionFamily(ctx, familyID)
+}
+
+// PurgeExpired deletes up to batchSize sessions whose refresh token
+// expired before before and returns how many it deleted.
+func (s *Store) PurgeExpired(ctx context.Context, before time.Time, batchSize int64) (int64, error) {
+	return s.q.PurgeExpiredSessions(ctx, sqlc.PurgeExpiredSessionsParams{Before: before.UTC(), BatchSize: batchSize})
+}
@@ -0,0 +1,70 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bercivarga/go-basic-server/internal/db/dbtest"
+	"github.com/bercivarga/go-basic-server/internal/db/querier"
+	"github.com/bercivarga/go-basic-server/internal/stores/user"
+	"github.com/bercivarga/go-basic-server/internal/utils"
+)
+
+const pepper = "session-pepper"
+
+func TestStoreKeepsOnlyTokenHashes(t *testing.T) {
+	ctx := context.Background()
+	db := dbtest.OpenMemory(t)
+	s := NewStore(db, pepper)
+
+	u, err := user.NewStore(db).Create(ctx, "user@example.com", "hash")
+	if err != nil {
+		t.Fatalf("creating user: %v", err)
+	}
+	expiresAt := time.Now().Add(time.Hour)
+
+	err = s.Create(ctx, u.ID, "access-1", "refresh-1", expiresAt, expiresAt, "family", Client{})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	checkLatest(t, db, "access-1", "refresh-1")
+
+	old, err := s.GetByRefreshToken(ctx, "refresh-1")
+	if err != nil {
+		t.Fatalf("GetByRefreshToken: %v", err)
+	}
+	if !s.IsValid(ctx, u.ID, "access-1") {
+		t.Error("IsValid does not find the session by its raw access token")
+	}
+
+	err = s.Rotate(ctx, old, "access-2", "refresh-2", expiresAt, expiresAt, Client{})
+	if err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	checkLatest(t, db, "access-2", "refresh-2")
+}
+
+// checkLatest fails t unless the newest session row holds the peppered
+// hashes of token and refreshToken rather than the tokens themselves.
+func checkLatest(t *testing.T, db *querier.DB, token, refreshToken string) {
+	t.Helper()
+
+	var tokenHash, refreshTokenHash string
+	err := db.QueryRowContext(context.Background(),
+		"SELECT token_hash, refresh_token_hash FROM sessions ORDER BY id DESC LIMIT 1",
+	).Scan(&tokenHash, &refreshTokenHash)
+	if err != nil {
+		t.Fatalf("reading session: %v", err)
+	}
+
+	if tokenHash == token || refreshTokenHash == refreshToken {
+		t.Errorf("raw token stored: token_hash %q, refresh_token_hash %q", tokenHash, refreshTokenHash)
+	}
+	if want := utils.HashToken(token, pepper); tokenHash != want {
+		t.Errorf("token_hash = %q, want %q", tokenHash, want)
+	}
+	if want := utils.HashToken(refreshToken, pepper); refreshTokenHash != want {
+		t.Errorf("refresh_token_hash = %q, want %q", refreshTokenHash, want)
+	}
+}
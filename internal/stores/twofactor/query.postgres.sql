@@ -0,0 +1,44 @@
+-- twofactor/query.postgres.sql
+-- ------------------------------------------------------------
+-- TOTP settings (columns of users) and recovery codes for sqlc (PostgreSQL engine)
+-- ------------------------------------------------------------
+
+-- name: GetTOTP :one
+SELECT totp_secret, totp_enabled_at, totp_last_step
+FROM   users
+WHERE  id = $1;
+
+-- Store a fresh secret, only while 2FA is not enabled ----------------------------
+-- name: SetTOTPSecret :execrows
+UPDATE users
+SET    totp_secret = $1
+WHERE  id = $2 AND totp_enabled_at IS NULL;
+
+-- name: EnableTOTP :execrows
+UPDATE users
+SET    totp_enabled_at = CURRENT_TIMESTAMP, totp_last_step = $1
+WHERE  id = $2 AND totp_secret IS NOT NULL AND totp_enabled_at IS NULL;
+
+-- Accept a time step only when it is newer than the last one used ---------------
+-- name: UseTOTPStep :execrows
+UPDATE users
+SET    totp_last_step = sqlc.arg(step)
+WHERE  id = sqlc.arg(id) AND totp_last_step < sqlc.arg(step);
+
+-- name: DisableTOTP :exec
+UPDATE users
+SET    totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = 0
+WHERE  id = $1;
+
+-- name: CreateRecoveryCode :exec
+INSERT INTO recovery_codes (user_id, code_hash)
+VALUES ($1, $2);
+
+-- name: UseRecoveryCode :execrows
+UPDATE recovery_codes
+SET    used_at = CURRENT_TIMESTAMP
+WHERE  user_id = $1 AND code_hash = $2 AND used_at IS NULL;
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM recovery_codes
+WHERE user_id = $1;
@@ -0,0 +1,154 @@
+package twofactor
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/bercivarga/go-basic-server/internal/db/dberr"
+	"github.com/bercivarga/go-basic-server/internal/db/querier"
+	"github.com/bercivarga/go-basic-server/internal/db/sqlc"
+	"github.com/bercivarga/go-basic-server/internal/utils"
+)
+
+var (
+	ErrNotFound       = errors.New("user not found")
+	ErrAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrNotPending     = errors.New("no two-factor setup in progress")
+	ErrCodeUsed       = errors.New("code already used")
+)
+
+// Settings is the TOTP state of a user. Secret is set from setup on,
+// Enabled only once a code was confirmed.
+type Settings struct {
+	Secret   string
+	Enabled  bool
+	LastStep int64
+}
+
+// Store keeps the TOTP secret on the user row and the recovery codes, the
+// latter hashed with the same pepper as session tokens.
+type Store struct {
+	db     *querier.DB
+	q      sqlc.Querier
+	pepper string
+}
+
+func NewStore(db *querier.DB, pepper string) *Store {
+	return &Store{db: db, q: db.Queries(), pepper: pepper}
+}
+
+func (s *Store) Get(ctx context.Context, userID int64) (*Settings, error) {
+	r, err := s.q.GetTOTP(ctx, userID)
+	if errors.Is(err, dberr.ErrNotFound) {
+		return nil, fmt.Errorf("%w: %w", ErrNotFound, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Settings{
+		Secret:   r.TotpSecret.String,
+		Enabled:  r.TotpEnabledAt.Valid,
+		LastStep: r.TotpLastStep,
+	}, nil
+}
+
+// SetSecret starts (or restarts) a setup. It returns ErrAlreadyEnabled
+// when 2FA is already on, a new secret then needs a reset first.
+func (s *Store) SetSecret(ctx context.Context, userID int64, secret string) error {
+	n, err := s.q.SetTOTPSecret(ctx, sqlc.SetTOTPSecretParams{
+		TotpSecret: sql.NullString{String: secret, Valid: true},
+		ID:         userID,
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrAlreadyEnabled
+	}
+	return nil
+}
+
+// Enable turns 2FA on and replaces the recovery codes of the user, step
+// being the time step of the code that confirmed the setup.
+func (s *Store) Enable(ctx context.Context, userID, step int64, recoveryCodes []string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	q := s.db.WithTx(tx)
+
+	n, err := q.EnableTOTP(ctx, sqlc.EnableTOTPParams{TotpLastStep: step, ID: userID})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotPending
+	}
+
+	if err := q.DeleteRecoveryCodes(ctx, userID); err != nil {
+		return err
+	}
+	for _, code := range recoveryCodes {
+		err := q.CreateRecoveryCode(ctx, sqlc.CreateRecoveryCodeParams{
+			UserID:   userID,
+			CodeHash: utils.HashToken(code, s.pepper),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// UseStep records step as used. It returns ErrCodeUsed for a step that is
+// not newer than the last one, so a code can't be replayed.
+func (s *Store) UseStep(ctx context.Context, userID, step int64) error {
+	n, err := s.q.UseTOTPStep(ctx, sqlc.UseTOTPStepParams{Step: step, ID: userID})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrCodeUsed
+	}
+	return nil
+}
+
+// UseRecoveryCode burns code. It returns ErrCodeUsed when the code is
+// unknown or was used before.
+func (s *Store) UseRecoveryCode(ctx context.Context, userID int64, code string) error {
+	n, err := s.q.UseRecoveryCode(ctx, sqlc.UseRecoveryCodeParams{
+		UserID:   userID,
+		CodeHash: utils.HashToken(code, s.pepper),
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrCodeUsed
+	}
+	return nil
+}
+
+// Reset turns 2FA off and drops the secret and recovery codes.
+func (s *Store) Reset(ctx context.Context, userID int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	q := s.db.WithTx(tx)
+
+	if err := q.DisableTOTP(ctx, userID); err != nil {
+		return err
+	}
+	if err := q.DeleteRecoveryCodes(ctx, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,66 @@
+-- user/query.postgres.sql
+-- ------------------------------------------------------------
+-- Users basic queries for sqlc (PostgreSQL engine)
+-- Schema: id BIGSERIAL PK, email TEXT UNIQUE, password_hash TEXT, created_at TIMESTAMPTZ
+-- ------------------------------------------------------------
+
+-- Create a new user and return the generated row --------------------------------
+-- name: CreateUser :one
+INSERT INTO users (email, password_hash)
+VALUES ($1, $2)
+RETURNING id, email, created_at;
+
+-- Fetch a user by primary key ----------------------------------------------------
+-- name: GetUserByID :one
+SELECT id, email, password_hash, role, created_at, email_verified_at,
+       totp_secret, totp_enabled_at, totp_last_step, disabled_at
+FROM   users
+WHERE  id = $1;
+
+-- Fetch a user by unique email ---------------------------------------------------
+-- name: GetUserByEmail :one
+SELECT id, email, password_hash, role, created_at, email_verified_at, totp_enabled_at,
+       disabled_at
+FROM   users
+WHERE  email = $1;
+
+-- Get user role -----------------------------------------------------------------
+-- name: GetRole :one
+SELECT role FROM users
+WHERE id = $1;
+
+-- List active users (simple pagination) -----------------------------------------
+-- name: ListUsers :many
+SELECT id, email, role, created_at, email_verified_at, totp_enabled_at, disabled_at
+FROM   users
+ORDER  BY id
+LIMIT  $1  OFFSET $2;
+
+-- Update only the password hash --------------------------------------------------
+-- name: UpdatePasswordHash :exec
+UPDATE users
+SET    password_hash = $1
+WHERE  id = $2;
+
+-- Mark the email address as verified, keeps the first verification time ---------
+-- name: MarkEmailVerified :exec
+UPDATE users
+SET    email_verified_at = CURRENT_TIMESTAMP
+WHERE  id = $1 AND email_verified_at IS NULL;
+
+-- A new address has to be verified again ---------------------------------------
+-- name: UpdateUserEmail :execrows
+UPDATE users
+SET    email = $1, email_verified_at = NULL
+WHERE  id = $2;
+
+-- NULL enables the account again ------------------------------------------------
+-- name: SetUserDisabled :execrows
+UPDATE users
+SET    disabled_at = $1
+WHERE  id = $2;
+
+-- Delete a user -----------------------------------------------------------------
+-- name: DeleteUser :execrows
+DELETE FROM users
+WHERE  id = $1;
@@ -12,13 +12,15 @@ RETURNING id, email, created_at;
 
 -- Fetch a user by primary key ----------------------------------------------------
 -- name: GetUserByID :one
-SELECT id, email, password_hash, role, created_at
+SELECT id, email, password_hash, role, created_at, email_verified_at,
+       totp_secret, totp_enabled_at, totp_last_step, disabled_at
 FROM   users
 WHERE  id = ?;
 
 -- Fetch a user by unique email ---------------------------------------------------
 -- name: GetUserByEmail :one
-SELECT id, email, password_hash, created_at
+SELECT id, email, password_hash, role, created_at, email_verified_at, totp_enabled_at,
+       disabled_at
 FROM   users
 WHERE  email = ?;
 
@@ -29,7 +31,7 @@ WHERE id = ?;
 
 -- List active users (simple pagination) -----------------------------------------
 -- name: ListUsers :many
-SELECT id, email, role, created_at
+SELECT id, email, role, created_at, email_verified_at, totp_enabled_at, disabled_at
 FROM   users
 ORDER  BY id
 LIMIT  ?  OFFSET ?;
@@ -40,7 +42,25 @@ UPDATE users
 SET    password_hash = ?
 WHERE  id = ?;
 
+-- Mark the email address as verified, keeps the first verification time ---------
+-- name: MarkEmailVerified :exec
+UPDATE users
+SET    email_verified_at = CURRENT_TIMESTAMP
+WHERE  id = ? AND email_verified_at IS NULL;
+
+-- A new address has to be verified again ---------------------------------------
+-- name: UpdateUserEmail :execrows
+UPDATE users
+SET    email = ?, email_verified_at = NULL
+WHERE  id = ?;
+
+-- NULL enables the account again ------------------------------------------------
+-- name: SetUserDisabled :execrows
+UPDATE users
+SET    disabled_at = ?
+WHERE  id = ?;
+
 -- Delete a user -----------------------------------------------------------------
--- name: DeleteUser :exec
+-- name: DeleteUser :execrows
 DELETE FROM users
 WHERE  id = ?;
@@ -3,16 +3,27 @@ package user
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"time"
 
+	"github.com/bercivarga/go-basic-server/internal/db/dberr"
+	"github.com/bercivarga/go-basic-server/internal/db/querier"
 	"github.com/bercivarga/go-basic-server/internal/db/sqlc"
 )
 
+var (
+	ErrNotFound   = errors.New("user not found")
+	ErrEmailTaken = errors.New("email address already in use")
+)
+
 type Store struct {
-	q *sqlc.Queries
+	db *querier.DB
+	q  sqlc.Querier
 }
 
-func NewStore(db *sql.DB) *Store {
-	return &Store{q: sqlc.New(db)}
+func NewStore(db *querier.DB) *Store {
+	return &Store{db: db, q: db.Queries()}
 }
 
 func (s *Store) GetAll(ctx context.Context, limit, offset int64) ([]sqlc.User, error) {
@@ -25,7 +36,15 @@ func (s *Store) GetAll(ctx context.Context, limit, offset int64) ([]sqlc.User, e
 	}
 	out := make([]sqlc.User, len(rows))
 	for i, r := range rows {
-		out[i] = sqlc.User{ID: r.ID, Email: r.Email, Role: r.Role}
+		out[i] = sqlc.User{
+			ID:              r.ID,
+			Email:           r.Email,
+			Role:            r.Role,
+			CreatedAt:       r.CreatedAt,
+			EmailVerifiedAt: r.EmailVerifiedAt,
+			TotpEnabledAt:   r.TotpEnabledAt,
+			DisabledAt:      r.DisabledAt,
+		}
 	}
 	return out, nil
 }
@@ -33,17 +52,25 @@ func (s *Store) GetAll(ctx context.Context, limit, offset int64) ([]sqlc.User, e
 func (s *Store) GetByID(ctx context.Context, id int64) (*sqlc.User, error) {
 	r, err := s.q.GetUserByID(ctx, id)
 	if err != nil {
-		return nil, err
+		return nil, notFound(err)
 	}
-	return &sqlc.User{ID: r.ID, Email: r.Email, Role: r.Role}, nil
+	return &r, nil
 }
 
 func (s *Store) GetByEmail(ctx context.Context, email string) (*sqlc.User, error) {
 	r, err := s.q.GetUserByEmail(ctx, email)
 	if err != nil {
-		return nil, err
+		return nil, notFound(err)
 	}
-	return &sqlc.User{ID: r.ID, Email: r.Email, PasswordHash: r.PasswordHash}, nil
+	return &sqlc.User{
+		ID:              r.ID,
+		Email:           r.Email,
+		PasswordHash:    r.PasswordHash,
+		Role:            r.Role,
+		EmailVerifiedAt: r.EmailVerifiedAt,
+		TotpEnabledAt:   r.TotpEnabledAt,
+		DisabledAt:      r.DisabledAt,
+	}, nil
 }
 
 func (s *Store) Create(ctx context.Context, email string, passwordHash string) (*sqlc.User, error) {
@@ -52,7 +79,7 @@ func (s *Store) Create(ctx context.Context, email string, passwordHash string) (
 		PasswordHash: passwordHash,
 	})
 	if err != nil {
-		return nil, err
+		return nil, emailTaken(err)
 	}
 	return &sqlc.User{ID: r.ID, Email: r.Email}, nil
 }
@@ -60,7 +87,131 @@ func (s *Store) Create(ctx context.Context, email string, passwordHash string) (
 func (s *Store) GetRole(ctx context.Context, userID int64) (string, error) {
 	userRole, err := s.q.GetRole(ctx, userID)
 	if err != nil {
-		return "", err
+		return "", notFound(err)
 	}
 	return userRole, nil
 }
+
+// ChangePassword stores a new password hash and, in the same transaction,
+// deletes every session of the user outside the keepSessionID login.
+func (s *Store) ChangePassword(ctx context.Context, userID int64, passwordHash, keepSessionID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	q := s.db.WithTx(tx)
+
+	err = q.UpdatePasswordHash(ctx, sqlc.UpdatePasswordHashParams{
+		PasswordHash: passwordHash,
+		ID:           userID,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = q.DeleteOtherSessions(ctx, sqlc.DeleteOtherSessionsParams{
+		UserID:   userID,
+		FamilyID: keepSessionID,
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Changes lists what Update sets; nil fields are left alone.
+type Changes struct {
+	Email    *string
+	Disabled *bool
+}
+
+// Update applies changes to userID in one transaction. A new email
+// address starts out unverified and drops the verification links sent to
+// the old one. Disabling the account deletes all of its sessions.
+func (s *Store) Update(ctx context.Context, userID int64, c Changes) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	q := s.db.WithTx(tx)
+
+	current, err := q.GetUserByID(ctx, userID)
+	if err != nil {
+		return notFound(err)
+	}
+
+	if c.Email != nil && *c.Email != current.Email {
+		_, err = q.UpdateUserEmail(ctx, sqlc.UpdateUserEmailParams{Email: *c.Email, ID: userID})
+		if err != nil {
+			return emailTaken(err)
+		}
+		if err := q.DeleteUnusedEmailVerifications(ctx, userID); err != nil {
+			return err
+		}
+	}
+
+	if c.Disabled != nil && *c.Disabled != current.DisabledAt.Valid {
+		var disabledAt sql.NullTime
+		if *c.Disabled {
+			disabledAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+		}
+		_, err := q.SetUserDisabled(ctx, sqlc.SetUserDisabledParams{DisabledAt: disabledAt, ID: userID})
+		if err != nil {
+			return err
+		}
+		if *c.Disabled {
+			if err := q.DeleteSessionsByUser(ctx, userID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Delete removes userID together with their sessions. The sessions are
+// deleted explicitly so they go even where foreign keys are not enforced.
+func (s *Store) Delete(ctx context.Context, userID int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	q := s.db.WithTx(tx)
+
+	if err := q.DeleteSessionsByUser(ctx, userID); err != nil {
+		return err
+	}
+	n, err := q.DeleteUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+
+	return tx.Commit()
+}
+
+// notFound reports missing rows as ErrNotFound.
+func notFound(err error) error {
+	if errors.Is(err, dberr.ErrNotFound) {
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	}
+	return err
+}
+
+// emailTaken reports a clash on the unique email column as
+// ErrEmailTaken.
+func emailTaken(err error) error {
+	if errors.Is(err, dberr.ErrUnique) {
+		return fmt.Errorf("%w: %w", ErrEmailTaken, err)
+	}
+	return err
+}
@@ -3,10 +3,13 @@ package utils
 import (
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
 	"strings"
 
 	"github.com/go-playground/validator/v10"
+
+	"github.com/bercivarga/go-basic-server/internal/apperr"
 )
 
 type ValidationError struct {
@@ -14,12 +17,12 @@ type ValidationError struct {
 	Error string `json:"error"`
 }
 
-type ValidationErrorResponse struct {
-	Errors []ValidationError `json:"errors"`
-}
-
 var validate = validator.New()
 
+// ErrInvalidJSON is returned by BindAndValidate for bodies that do not
+// decode.
+var ErrInvalidJSON = apperr.New(apperr.ErrInvalid, "invalid_json", "request body is not valid JSON")
+
 // Validate validates the provided data using the validator package
 func Validate(data any) error {
 	return validate.Struct(data)
@@ -28,35 +31,11 @@ func Validate(data any) error {
 // BindAndValidate binds and validates the request body against the provided struct
 func BindAndValidate(r *http.Request, dst any) error {
 	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
-		return fmt.Errorf("invalid json: %w", err)
+		return fmt.Errorf("%w: %v", ErrInvalidJSON, err)
 	}
 	return Validate(dst)
 }
 
-// RespondWithValidationErrors handles both validation and general errors as JSON
-func RespondWithValidationErrors(w http.ResponseWriter, err error) {
-	w.Header().Set("Content-Type", "application/json")
-
-	if ve, ok := err.(validator.ValidationErrors); ok {
-		w.WriteHeader(http.StatusBadRequest)
-		var errs []ValidationError
-		for _, fe := range ve {
-			errs = append(errs, ValidationError{
-				Field: fe.Field(),
-				Error: fmt.Sprintf("failed on %s", fe.Tag()),
-			})
-		}
-		json.NewEncoder(w).Encode(ValidationErrorResponse{Errors: errs})
-		return
-	}
-
-	// fallback for non-validation errors (e.g., bad JSON)
-	w.WriteHeader(http.StatusBadRequest)
-	json.NewEncoder(w).Encode(map[string]string{
-		"error": err.Error(),
-	})
-}
-
 // ExtractBearerToken extracts the bearer token from the Authorization header
 func ExtractBearerToken(r *http.Request) string {
 	auth := r.Header.Get("Authorization")
@@ -65,3 +44,14 @@ func ExtractBearerToken(r *http.Request) string {
 	}
 	return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
 }
+
+// ClientIP returns the IP of the peer the request came from. Forwarding
+// headers are ignored since any client can set them; behind a proxy this
+// is the proxy's address.
+func ClientIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return ip
+}
@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/bercivarga/go-basic-server/internal/apperr"
+	"github.com/bercivarga/go-basic-server/internal/requestid"
+)
+
+// Problem is an RFC 7807 problem details body. Code and RequestID are
+// extension members: Code is stable for clients to match on, RequestID
+// finds the request in the server logs.
+type Problem struct {
+	Type      string            `json:"type"`
+	Title     string            `json:"title"`
+	Status    int               `json:"status"`
+	Detail    string            `json:"detail,omitempty"`
+	Instance  string            `json:"instance,omitempty"`
+	Code      string            `json:"code"`
+	RequestID string            `json:"request_id,omitempty"`
+	Errors    []ValidationError `json:"errors,omitempty"`
+}
+
+var statuses = []struct {
+	kind   error
+	status int
+	code   string
+}{
+	{apperr.ErrInvalid, http.StatusBadRequest, "invalid_request"},
+	{apperr.ErrUnauthorized, http.StatusUnauthorized, "unauthorized"},
+	{apperr.ErrForbidden, http.StatusForbidden, "forbidden"},
+	{apperr.ErrNotFound, http.StatusNotFound, "not_found"},
+	{apperr.ErrMethodNotAllowed, http.StatusMethodNotAllowed, "method_not_allowed"},
+	{apperr.ErrConflict, http.StatusConflict, "conflict"},
+	{apperr.ErrTooManyRequests, http.StatusTooManyRequests, "too_many_requests"},
+	{apperr.ErrUnavailable, http.StatusServiceUnavailable, "unavailable"},
+}
+
+// RespondError answers with err as application/problem+json. Validation
+// errors list the failing fields, *apperr.Error gets the status of its
+// kind and shows its message, and anything else is logged and answered
+// 500 without details.
+func RespondError(w http.ResponseWriter, r *http.Request, err error) {
+	if p := WriteProblem(w, r, err); p.Status >= http.StatusInternalServerError {
+		slog.ErrorContext(r.Context(), "request failed",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"error", err,
+		)
+	}
+}
+
+// WriteProblem answers with err like RespondError but leaves logging to
+// the caller. It returns the problem it sent.
+func WriteProblem(w http.ResponseWriter, r *http.Request, err error) Problem {
+	p := problemFor(err)
+	p.Type = "about:blank"
+	p.Title = http.StatusText(p.Status)
+	p.Instance = r.URL.Path
+	p.RequestID = requestid.FromContext(r.Context())
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+	return p
+}
+
+func problemFor(err error) Problem {
+	var ve validator.ValidationErrors
+	if errors.As(err, &ve) {
+		p := Problem{
+			Status: http.StatusBadRequest,
+			Code:   "validation_failed",
+			Detail: "request validation failed",
+		}
+		for _, fe := range ve {
+			p.Errors = append(p.Errors, ValidationError{
+				Field: fe.Field(),
+				Error: fmt.Sprintf("failed on %s", fe.Tag()),
+			})
+		}
+		return p
+	}
+
+	var e *apperr.Error
+	if errors.As(err, &e) {
+		for _, s := range statuses {
+			if errors.Is(e.Kind, s.kind) {
+				return Problem{Status: s.status, Code: e.Code, Detail: e.Message}
+			}
+		}
+	}
+	for _, s := range statuses {
+		if errors.Is(err, s.kind) {
+			return Problem{Status: s.status, Code: s.code, Detail: s.kind.Error()}
+		}
+	}
+
+	return Problem{
+		Status: http.StatusInternalServerError,
+		Code:   "internal_error",
+		Detail: "internal server error",
+	}
+}
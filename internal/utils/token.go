@@ -12,3 +12,23 @@ func GenerateRefreshToken() (string, error) {
 	}
 	return hex.EncodeToString(b), nil
 }
+
+// GenerateTokenFamilyID returns the random ID shared by a login session
+// and every session rotated from it.
+func GenerateTokenFamilyID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// GenerateOneTimeToken returns a random token for single-use links sent by
+// email, e.g. password resets.
+func GenerateOneTimeToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
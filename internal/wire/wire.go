@@ -2,6 +2,7 @@ package wire
 
 import (
 	"github.com/bercivarga/go-basic-server/internal/app"
+	"github.com/bercivarga/go-basic-server/internal/handlers/admin"
 	"github.com/bercivarga/go-basic-server/internal/handlers/auth"
 	"github.com/bercivarga/go-basic-server/internal/handlers/health"
 	"github.com/bercivarga/go-basic-server/internal/handlers/user"
@@ -13,6 +14,7 @@ type Components struct {
 	User   *user.Handler
 	Health *health.Handler
 	Auth   *auth.Handler
+	Admin  *admin.Handler
 }
 
 // New builds all handlers that need *app.App.
@@ -21,6 +23,7 @@ func New(a *app.App) *Components {
 		Auth:   auth.New(a),
 		User:   user.New(a),
 		Health: health.New(a),
+		Admin:  admin.New(a),
 	}
 }
 
@@ -29,4 +32,5 @@ func (c *Components) RegisterRoutes(r *router.Router) {
 	c.Auth.Register(r)
 	c.User.Register(r)
 	c.Health.Register(r)
+	c.Admin.Register(r)
 }